@@ -0,0 +1,52 @@
+package api
+
+import (
+	"DCar/logic/validation"
+	"encoding/json"
+	carTypes "github.com/ccsapp/cargotypes"
+)
+
+// batchStatus is the outcome of a single car of a batch import.
+type batchStatus string
+
+const (
+	batchCreated   batchStatus = "created"
+	batchDuplicate batchStatus = "duplicate"
+	batchInvalid   batchStatus = "invalid"
+)
+
+// batchResult is the result of a single car of a batch import. The results are returned in the order of the cars.
+type batchResult struct {
+	// Vin is empty if the car has no VIN
+	Vin     carTypes.Vin `json:"vin,omitempty"`
+	Status  batchStatus  `json:"status"`
+	Message string       `json:"message,omitempty"`
+
+	// Violations lists the violated plausibility rules if the car is implausible
+	Violations []validation.Violation `json:"violations,omitempty"`
+
+	// Warnings lists the issues of a created car, e.g. an invalid VIN that is only warned about
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// decodeStaticCar validates the given JSON value against the staticCar schema and decodes it. The VIN is returned
+// whenever the value has one, even if the car is invalid, so that the client can relate the result to its car.
+func decodeStaticCar(value json.RawMessage) (carTypes.Vin, carTypes.Car, error) {
+	var vin carTypes.Vin
+	var document interface{}
+	// the value is valid JSON since it was part of the request body
+	_ = json.Unmarshal(value, &document)
+	if object, ok := document.(map[string]interface{}); ok {
+		vin, _ = object["vin"].(string)
+	}
+
+	if err := validateSchema("staticCar", document); err != nil {
+		return vin, carTypes.Car{}, err
+	}
+
+	var car carTypes.Car
+	if err := json.Unmarshal(value, &car); err != nil {
+		return vin, carTypes.Car{}, err
+	}
+	return vin, car, nil
+}
@@ -2,33 +2,251 @@ package api
 
 import (
 	"DCar/infrastructure/database"
+	"DCar/logic/model"
+	"DCar/logic/operations"
+	"DCar/logic/units"
+	"DCar/logic/validation"
+	"DCar/logic/vin"
+	"encoding/json"
+	"errors"
 	carTypes "github.com/ccsapp/cargotypes"
 	"github.com/labstack/echo/v4"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
+var invalidLocationError = errors.New("near must be a valid latitude and longitude separated by a comma")
+
 type controller struct {
-	crud database.ICRUD
+	operations operations.IOperations
 }
 
-// NewController creates a new controller instance and takes a high level CRUD interface as a parameter.
-func NewController(crud database.ICRUD) Controller {
+// NewController creates a new controller instance and takes the business logic layer as a parameter.
+func NewController(operations operations.IOperations) Controller {
 	return controller{
-		crud,
+		operations,
 	}
 }
 
-func (c controller) GetCars(ctx echo.Context) error {
-	allVins, err := c.crud.ReadAllVins(ctx.Request().Context())
+func (c controller) GetCars(ctx echo.Context, params GetCarsParams) error {
+	paginated := params.Limit != nil || params.Cursor != nil
+	if paginated && (params.Near != nil || params.Radius != nil) {
+		return echo.NewHTTPError(http.StatusBadRequest, "limit and cursor cannot be combined with near and radius")
+	}
+
+	filter := carFilterFromParams(&params)
+
+	fields, err := parseFields(params.Fields)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if fields != nil || (params.Expand != nil && *params.Expand) {
+		return c.getCarObjects(ctx, params, filter, fields)
+	}
+
+	if paginated {
+		return c.getCarsPage(ctx, params, filter)
+	}
+
+	if params.Near == nil && params.Radius == nil {
+		var vins []carTypes.Vin
+		var err error
+		if filter.IsEmpty() {
+			vins, err = c.operations.ReadAllVins(ctx.Request().Context())
+		} else {
+			vins, err = c.operations.ReadVinsMatching(ctx.Request().Context(), filter)
+		}
+
+		if err != nil {
+			return err
+		}
 
+		return ctx.JSON(http.StatusOK, vins)
+	}
+
+	position, err := nearFromParams(&params)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(http.StatusOK, allVins)
+	nearVins, err := c.operations.ReadVinsNear(ctx.Request().Context(), position, *params.Radius, filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, nearVins)
 }
 
-func (c controller) AddCar(ctx echo.Context) error {
+// getCarsPage responds with a page of VINs. If there is a next page, a Link header pointing to it is set.
+func (c controller) getCarsPage(ctx echo.Context, params GetCarsParams, filter model.CarFilter) error {
+	after, limit, err := pageFromParams(&params)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.operations.ReadVinsPage(ctx.Request().Context(), filter, after, limit)
+	if err != nil {
+		return err
+	}
+
+	if page.Next != nil {
+		ctx.Response().Header().Set("Link", nextPageLink(ctx.Request().URL, *page.Next, limit))
+	}
+
+	return ctx.JSON(http.StatusOK, page.Vins)
+}
+
+// getCarObjects responds with car objects instead of VINs. The cars are selected like the VINs in GetCars. If fields
+// is not nil, the cars only contain the VIN and the given fields. The cars are converted into the requested unit
+// system.
+func (c controller) getCarObjects(ctx echo.Context, params GetCarsParams, filter model.CarFilter,
+	fields []string) error {
+
+	request := ctx.Request()
+	header := ctx.Response().Header()
+	header.Add("Vary", "Accept")
+
+	system := unitSystem(request, params.Units)
+	readFields := fields
+	if fields != nil && system != units.Metric {
+		readFields = append(append([]string{}, fields...), fuelField)
+	}
+
+	var cars []carTypes.Car
+	var err error
+
+	switch {
+	case params.Limit != nil || params.Cursor != nil:
+		after, limit, err := pageFromParams(&params)
+		if err != nil {
+			return err
+		}
+
+		page, err := c.operations.ReadCarsPage(request.Context(), filter, after, limit, readFields)
+		if err != nil {
+			return err
+		}
+
+		if page.Next != nil {
+			header.Set("Link", nextPageLink(request.URL, *page.Next, limit))
+		}
+		cars = page.Cars
+	case params.Near != nil || params.Radius != nil:
+		position, err := nearFromParams(&params)
+		if err != nil {
+			return err
+		}
+
+		cars, err = c.operations.ReadCarsNear(request.Context(), position, *params.Radius, filter, readFields)
+		if err != nil {
+			return err
+		}
+	default:
+		cars, err = c.operations.ReadCarsMatching(request.Context(), filter, readFields)
+		if err != nil {
+			return err
+		}
+	}
+
+	if system == units.Metric {
+		return respondWithMetricCars(ctx, cars, fields)
+	}
+
+	if fields == nil {
+		convertedCars := make([]unitCar, len(cars))
+		for i := range cars {
+			convertedCars[i].Car, convertedCars[i].Units = units.ConvertCar(cars[i], system)
+		}
+		return ctx.JSON(http.StatusOK, convertedCars)
+	}
+
+	projectedCars := make([]map[string]interface{}, len(cars))
+	for i := range cars {
+		converted, carUnits := units.ConvertCar(cars[i], system)
+		if projectedCars[i], err = projectCar(&converted, fields); err != nil {
+			return err
+		}
+		if projectedUnits := projectUnits(carUnits, fields); len(projectedUnits) > 0 {
+			projectedCars[i]["units"] = projectedUnits
+		}
+	}
+	return ctx.JSON(http.StatusOK, projectedCars)
+}
+
+// respondWithMetricCars responds with the cars as they are stored. If fields is not nil, the cars only contain the
+// VIN and the given fields.
+func respondWithMetricCars(ctx echo.Context, cars []carTypes.Car, fields []string) error {
+	if fields == nil {
+		return ctx.JSON(http.StatusOK, cars)
+	}
+
+	projectedCars := make([]map[string]interface{}, len(cars))
+	for i := range cars {
+		var err error
+		if projectedCars[i], err = projectCar(&cars[i], fields); err != nil {
+			return err
+		}
+	}
+	return ctx.JSON(http.StatusOK, projectedCars)
+}
+
+// pageFromParams returns the VIN the requested page starts after and the size of the page. Errors are HTTP errors.
+func pageFromParams(params *GetCarsParams) (*carTypes.Vin, int, error) {
+	limit := defaultPageSize
+	if params.Limit != nil {
+		limit = *params.Limit
+	}
+
+	if params.Cursor == nil {
+		return nil, limit, nil
+	}
+
+	vin, err := decodeCursor(*params.Cursor)
+	if err != nil {
+		return nil, 0, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return &vin, limit, nil
+}
+
+// nearFromParams returns the location to search around. The radius is checked to be given as well.
+// Errors are HTTP errors.
+func nearFromParams(params *GetCarsParams) (carTypes.DynamicDataPosition, error) {
+	if params.Near == nil || params.Radius == nil {
+		return carTypes.DynamicDataPosition{}, echo.NewHTTPError(http.StatusBadRequest,
+			"near and radius must be given together")
+	}
+
+	position, err := parseLocation(*params.Near)
+	if err != nil {
+		return carTypes.DynamicDataPosition{}, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return position, nil
+}
+
+// carFilterFromParams collects the filter parameters of GetCars.
+func carFilterFromParams(params *GetCarsParams) model.CarFilter {
+	return model.CarFilter{
+		Brand:           params.Brand,
+		Model:           params.Model,
+		Color:           params.Color,
+		Fuel:            params.Fuel,
+		Transmission:    params.Transmission,
+		MinSeats:        params.MinSeats,
+		MinTrunkVolume:  params.MinTrunkVolume,
+		IncludeArchived: params.IncludeArchived != nil && *params.IncludeArchived,
+	}
+}
+
+func (c controller) AddCar(ctx echo.Context, params AddCarParams) error {
+	return c.idempotent(ctx, params.IdempotencyKey, func() error {
+		return c.addCar(ctx)
+	})
+}
+
+// addCar adds the car in the request body.
+func (c controller) addCar(ctx echo.Context) error {
 	// get request body
 	var car carTypes.Car
 
@@ -38,39 +256,275 @@ func (c controller) AddCar(ctx echo.Context) error {
 		return err
 	}
 
-	vin, err := c.crud.CreateCar(ctx.Request().Context(), &car)
+	createdVin, warnings, err := c.operations.CreateCar(ctx.Request().Context(), &car)
 	if err != nil {
+		if database.IsArchivedCarError(err) {
+			return echo.NewHTTPError(http.StatusConflict, "VIN belongs to an archived car, use :restore instead")
+		}
 		if database.IsDuplicateKeyError(err) {
 			return echo.NewHTTPError(http.StatusConflict, "VIN already exists")
 		}
+		var validationError *validation.Error
+		if errors.As(err, &validationError) {
+			return newViolationsError(validationError)
+		}
+		if vin.IsCheckDigitError(err) || vin.IsInconsistencyError(err) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return err
+	}
+
+	// the warnings are only part of the header since the body is the VIN
+	for _, warning := range warnings {
+		ctx.Response().Header().Add("Warning", warningHeader(warning))
+	}
+	return ctx.JSON(http.StatusCreated, createdVin)
+}
+
+// warningHeader returns the value of a Warning header field with the given text. The warn code 299 marks a
+// persistent warning that is not related to caching.
+func warningHeader(text string) string {
+	return "299 - " + strconv.Quote(text)
+}
+
+func (c controller) AddCars(ctx echo.Context) error {
+	var values []json.RawMessage
+
+	// bind errors are unexpected since we validated that the request body is an array
+	err := ctx.Bind(&values)
+	if err != nil {
+		return err
+	}
+
+	// invalid cars are reported right away, the valid ones are created together
+	results := make([]batchResult, len(values))
+	var cars []carTypes.Car
+	var carIndices []int
+	for i, value := range values {
+		vin, car, err := decodeStaticCar(value)
+		results[i].Vin = vin
+		if err != nil {
+			results[i].Status = batchInvalid
+			results[i].Message = err.Error()
+			continue
+		}
+		cars = append(cars, car)
+		carIndices = append(carIndices, i)
+	}
+
+	carErrors, carWarnings, err := c.operations.CreateCars(ctx.Request().Context(), cars)
+	if err != nil {
+		return err
+	}
+
+	var validationError *validation.Error
+	for i, carErr := range carErrors {
+		result := &results[carIndices[i]]
+		switch {
+		case carErr == nil:
+			result.Status = batchCreated
+			result.Warnings = carWarnings[i]
+		case database.IsDuplicateKeyError(carErr):
+			result.Status = batchDuplicate
+			result.Message = "VIN already exists"
+		case vin.IsCheckDigitError(carErr) || vin.IsInconsistencyError(carErr):
+			result.Status = batchInvalid
+			result.Message = carErr.Error()
+		case errors.As(carErr, &validationError):
+			result.Status = batchInvalid
+			result.Message = carErr.Error()
+			result.Violations = validationError.Violations
+		default:
+			return carErr
+		}
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (c controller) ExportCars(ctx echo.Context, params ExportCarsParams) error {
+	format := ExportCarsParamsFormatNdjson
+	if params.Format != nil {
+		format = *params.Format
+	}
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentDisposition, `attachment; filename="cars.`+string(format)+`"`)
+
+	var writer carWriter
+	if format == ExportCarsParamsFormatCsv {
+		response.Header().Set(echo.HeaderContentType, "text/csv")
+		csvWriter, err := newCsvWriter(response)
+		if err != nil {
+			return err
+		}
+		writer = csvWriter
+	} else {
+		response.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+		writer = newNdjsonWriter(response)
+	}
+
+	// the cars are written while they are read, so errors can only end the export early
+	err := c.operations.ForEachCar(ctx.Request().Context(), writer.Write)
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (c controller) DeleteCar(ctx echo.Context, vin carTypes.VinParam, params DeleteCarParams) error {
+	revision, err := revisionFromIfMatch(params.IfMatch)
+	if err != nil {
 		return err
 	}
-	return ctx.JSON(http.StatusCreated, vin)
+
+	archived, err := c.operations.ArchiveCar(ctx.Request().Context(), vin, revision)
+	if database.IsRevisionMismatchError(err) {
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "ETag does not match")
+	}
+	if err != nil {
+		return err
+	}
+	if archived {
+		return ctx.NoContent(http.StatusNoContent)
+	}
+	return carNotFoundError(params.IfMatch)
 }
 
-func (c controller) DeleteCar(ctx echo.Context, vin carTypes.VinParam) error {
-	deleted, err := c.crud.DeleteCar(ctx.Request().Context(), vin)
+func (c controller) RestoreCar(ctx echo.Context, vin carTypes.VinParam) error {
+	restored, err := c.operations.RestoreCar(ctx.Request().Context(), vin)
 	if err != nil {
 		return err
 	}
-	if deleted {
+	if restored {
+		return ctx.NoContent(http.StatusNoContent)
+	}
+	return echo.NewHTTPError(http.StatusNotFound, "no archived car with this VIN")
+}
+
+func (c controller) PurgeCar(ctx echo.Context, vin carTypes.VinParam) error {
+	purged, err := c.operations.PurgeCar(ctx.Request().Context(), vin)
+	if err != nil {
+		return err
+	}
+	if purged {
 		return ctx.NoContent(http.StatusNoContent)
 	}
 	return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
 }
 
-func (c controller) GetCar(ctx echo.Context, vin carTypes.VinParam) error {
-	car, err := c.crud.ReadCar(ctx.Request().Context(), vin)
+func (c controller) GetCar(ctx echo.Context, vin carTypes.VinParam, params GetCarParams) error {
+	request := ctx.Request()
+	car, err := c.operations.ReadStoredCar(request.Context(), vin)
 	if err != nil {
 		if database.IsNotFoundError(err) {
 			return echo.NewHTTPError(http.StatusNotFound)
 		}
 		return err
 	}
-	return ctx.JSON(http.StatusOK, car)
+
+	system := unitSystem(request, params.Units)
+	header := ctx.Response().Header()
+	header.Set("ETag", etag(car.Revision, system))
+	header.Add("Vary", "Accept")
+	if matchesIfNoneMatch(params.IfNoneMatch, car.Revision, system) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+
+	converted, carUnits := units.ConvertCar(car.Car, system)
+	result := newDecodedCar(converted, car.TireSpecification)
+	result.Units = carUnits
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (c controller) PatchCar(ctx echo.Context, vin carTypes.VinParam, params PatchCarParams) error {
+	revision, err := revisionFromIfMatch(params.IfMatch)
+	if err != nil {
+		return err
+	}
+
+	// echo only binds application/json, so the merge patch is decoded here
+	var patch map[string]interface{}
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&patch); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "the merge patch must be a JSON object")
+	}
+	if _, ok := patch["dynamicData"]; ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "only the static data of a car can be patched")
+	}
+
+	car, err := c.operations.UpdateStaticData(ctx.Request().Context(), vin, revision, func(car *carTypes.Car) error {
+		return applyMergePatch(car, patch)
+	})
+
+	var patchError *invalidPatchError
+	if errors.As(err, &patchError) {
+		return echo.NewHTTPError(http.StatusBadRequest, patchError.Error())
+	}
+	var validationError *validation.Error
+	if errors.As(err, &validationError) {
+		return newViolationsError(validationError)
+	}
+	if database.IsNotFoundError(err) {
+		return carNotFoundError(params.IfMatch)
+	}
+	if database.IsRevisionMismatchError(err) {
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "ETag does not match")
+	}
+	if operations.IsRuleViolationError(err) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set("ETag", etag(car.Revision, units.Metric))
+	return ctx.JSON(http.StatusOK, car.Car)
+}
+
+func (c controller) ChangeTrunkLockState(ctx echo.Context, vin carTypes.VinParam,
+	params ChangeTrunkLockStateParams) error {
+
+	return c.idempotent(ctx, params.IdempotencyKey, func() error {
+		return c.changeTrunkLockState(ctx, vin, params)
+	})
+}
+
+// changeTrunkLockState sets the trunk lock state in the request body.
+func (c controller) changeTrunkLockState(ctx echo.Context, vin carTypes.VinParam,
+	params ChangeTrunkLockStateParams) error {
+
+	revision, err := revisionFromIfMatch(params.IfMatch)
+	if err != nil {
+		return err
+	}
+
+	// get request body
+	var lockState carTypes.DynamicDataLockState
+
+	// bind errors are unexpected since we validated the request body
+	err = ctx.Bind(&lockState)
+
+	if err != nil {
+		return err
+	}
+
+	err = c.operations.SetTrunkLockState(ctx.Request().Context(), vin, lockState, revision)
+	if database.IsNotFoundError(err) {
+		return carNotFoundError(params.IfMatch)
+	}
+	if database.IsRevisionMismatchError(err) {
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "ETag does not match")
+	}
+	if operations.IsRuleViolationError(err) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
 }
 
-func (c controller) ChangeTrunkLockState(ctx echo.Context, vin carTypes.VinParam) error {
+func (c controller) ChangeDoorsLockState(ctx echo.Context, vin carTypes.VinParam) error {
 	// get request body
 	var lockState carTypes.DynamicDataLockState
 
@@ -81,7 +535,54 @@ func (c controller) ChangeTrunkLockState(ctx echo.Context, vin carTypes.VinParam
 		return err
 	}
 
-	err = c.crud.SetTrunkLockState(ctx.Request().Context(), vin, lockState)
+	err = c.operations.SetDoorsLockState(ctx.Request().Context(), vin, lockState)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c controller) ChangeEngineState(ctx echo.Context, vin carTypes.VinParam) error {
+	// get request body
+	var engineState carTypes.DynamicDataEngineState
+
+	// bind errors are unexpected since we validated the request body
+	err := ctx.Bind(&engineState)
+
+	if err != nil {
+		return err
+	}
+
+	err = c.operations.SetEngineState(ctx.Request().Context(), vin, engineState)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if operations.IsRuleViolationError(err) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c controller) ChangeDynamicData(ctx echo.Context, vin carTypes.VinParam) error {
+	// get request body
+	var dynamicData carTypes.DynamicData
+
+	// bind errors are unexpected since we validated the request body
+	err := ctx.Bind(&dynamicData)
+
+	if err != nil {
+		return err
+	}
+
+	err = c.operations.SetDynamicData(ctx.Request().Context(), vin, &dynamicData)
 	if database.IsNotFoundError(err) {
 		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
 	}
@@ -91,3 +592,86 @@ func (c controller) ChangeTrunkLockState(ctx echo.Context, vin carTypes.VinParam
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+func (c controller) GetPositions(ctx echo.Context, vin carTypes.VinParam, params GetPositionsParams) error {
+	positions, err := c.operations.ReadPositionRecords(ctx.Request().Context(), vin, params.From, params.To)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, positions)
+}
+
+func (c controller) GetTrips(ctx echo.Context, vin carTypes.VinParam) error {
+	trips, err := c.operations.ReadTrips(ctx.Request().Context(), vin)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, trips)
+}
+
+func (c controller) GetRange(ctx echo.Context, vin carTypes.VinParam, params GetRangeParams) error {
+	profile := model.RangeProfileCombined
+	if params.Profile != nil {
+		profile = model.RangeProfile(*params.Profile)
+	}
+
+	estimate, err := c.operations.EstimateRange(ctx.Request().Context(), vin, profile)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if model.IsRangeUnknownError(err) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, estimate)
+}
+
+func (c controller) GetCarEvents(ctx echo.Context, vin carTypes.VinParam) error {
+	current, carEvents, err := c.operations.SubscribeCarEvents(ctx.Request().Context(), vin)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	return streamEvents(ctx, carEvents, current)
+}
+
+func (c controller) GetFleetEvents(ctx echo.Context) error {
+	return streamEvents(ctx, c.operations.SubscribeFleetEvents(ctx.Request().Context()))
+}
+
+// parseLocation parses a location given as latitude and longitude separated by a comma.
+func parseLocation(location string) (carTypes.DynamicDataPosition, error) {
+	latitudeString, longitudeString, found := strings.Cut(location, ",")
+	if !found {
+		return carTypes.DynamicDataPosition{}, invalidLocationError
+	}
+
+	latitude, err := strconv.ParseFloat(latitudeString, 32)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return carTypes.DynamicDataPosition{}, invalidLocationError
+	}
+
+	longitude, err := strconv.ParseFloat(longitudeString, 32)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return carTypes.DynamicDataPosition{}, invalidLocationError
+	}
+
+	return carTypes.DynamicDataPosition{
+		Latitude:  float32(latitude),
+		Longitude: float32(longitude),
+	}, nil
+}
@@ -1,8 +1,15 @@
 package api
 
 import (
+	"DCar/infrastructure/database"
+	"DCar/logic/model"
+	"DCar/logic/operations"
+	"DCar/logic/units"
+	"DCar/logic/validation"
+	"DCar/logic/vin"
 	"DCar/mocks"
 	"context"
+	"encoding/json"
 	"errors"
 	carTypes "github.com/ccsapp/cargotypes"
 	openapiTypes "github.com/deepmap/oapi-codegen/pkg/types"
@@ -11,6 +18,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/mongo"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -74,19 +83,19 @@ func TestController_GetCars_success(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockCrud.
+	mockOperations.
 		EXPECT().ReadAllVins(ctx).Return(vins, nil)
 	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
 
-	controller := NewController(mockCrud)
-	err := controller.GetCars(mockEchoContext)
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{})
 	assert.Nil(t, err)
 }
 
-func TestController_GetCars_crudError(t *testing.T) {
+func TestController_GetCars_operationsError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -95,18 +104,599 @@ func TestController_GetCars_crudError(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
 
-	crudError := errors.New("crud error")
-	mockCrud.
+	operationsError := errors.New("operations error")
+	mockOperations.
 		EXPECT().
-		ReadAllVins(ctx).Return(nil, crudError)
+		ReadAllVins(ctx).Return(nil, operationsError)
 
-	controller := NewController(mockCrud)
-	err := controller.GetCars(mockEchoContext)
-	assert.ErrorIs(t, err, crudError)
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_GetCars_filter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vins := []string{"12345678901234567"}
+	brand := "Volkswagen"
+	fuel := carTypes.ELECTRIC
+	minSeats := 7
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().
+		ReadVinsMatching(ctx, model.CarFilter{Brand: &brand, Fuel: &fuel, MinSeats: &minSeats}).
+		Return(vins, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Brand: &brand, Fuel: &fuel, MinSeats: &minSeats})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_includeArchived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vins := []string{"12345678901234567"}
+	includeArchived := true
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().
+		ReadVinsMatching(ctx, model.CarFilter{IncludeArchived: true}).
+		Return(vins, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{IncludeArchived: &includeArchived})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_filterOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	color := "black"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().ReadVinsMatching(ctx, gomock.Any()).Return(nil, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Color: &color})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_GetCars_near(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vins := []string{"12345678901234568", "12345678901234567"}
+	near := "49.0069,-8.4037"
+	radius := 1500.0
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().
+		ReadVinsNear(ctx, carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: -8.4037}, radius,
+			model.CarFilter{}).
+		Return(vins, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Near: &near, Radius: &radius})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_nearWithoutRadius(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	near := "49.0069,8.4037"
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Near: &near})
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, "near and radius must be given together"), err)
+}
+
+func TestController_GetCars_nearInvalidLocation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	radius := 1500.0
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	controller := NewController(mockOperations)
+
+	for _, near := range []string{"91,8.4037", "49.0069,-181", "49.0069", "north,east"} {
+		near := near
+		err := controller.GetCars(mockEchoContext, GetCarsParams{Near: &near, Radius: &radius})
+		assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, invalidLocationError.Error()), err, near)
+	}
+}
+
+func TestController_GetCars_nearOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	near := "49.0069,8.4037"
+	radius := 1500.0
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().ReadVinsNear(ctx, gomock.Any(), radius, gomock.Any()).Return(nil, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Near: &near, Radius: &radius})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_GetCars_page(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	limit := 2
+	cursor := encodeCursor("12345678901234566")
+	vins := []string{"12345678901234567", "12345678901234568"}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	after := "12345678901234566"
+	mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockOperations.
+		EXPECT().
+		ReadVinsPage(ctx, model.CarFilter{}, &after, limit).
+		Return(model.VinPage{Vins: vins, Next: &vins[1]}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Limit: &limit, Cursor: &cursor})
+	assert.Nil(t, err)
+	assert.Equal(t, "</cars?cursor="+encodeCursor(vins[1])+"&limit=2>; rel=\"next\"", recorder.Header().Get("Link"))
+}
+
+func TestController_GetCars_pageFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	limit := 1
+	transmission := carTypes.AUTOMATIC
+	vins := []string{"12345678901234567"}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET",
+		"https://example.com/cars?limit=1&transmission=AUTOMATIC", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockOperations.
+		EXPECT().
+		ReadVinsPage(ctx, model.CarFilter{Transmission: &transmission}, nil, limit).
+		Return(model.VinPage{Vins: vins, Next: &vins[0]}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Limit: &limit, Transmission: &transmission})
+	assert.Nil(t, err)
+
+	// the filter is kept for the next page
+	assert.Equal(t, "</cars?cursor="+encodeCursor(vins[0])+"&limit=1&transmission=AUTOMATIC>; rel=\"next\"",
+		recorder.Header().Get("Link"))
+}
+
+func TestController_GetCars_lastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	limit := 5
+	vins := []string{"12345678901234567"}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().ReadVinsPage(ctx, model.CarFilter{}, nil, limit).Return(model.VinPage{Vins: vins}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Limit: &limit})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_pageDefaultLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cursor := encodeCursor("12345678901234566")
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().ReadVinsPage(ctx, gomock.Any(), gomock.Any(), defaultPageSize).Return(model.VinPage{}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, gomock.Any())
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Cursor: &cursor})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_pageInvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	controller := NewController(mockOperations)
+
+	for _, cursor := range []string{"", "not base64!", "QQ=="} {
+		cursor := cursor
+		err := controller.GetCars(mockEchoContext, GetCarsParams{Cursor: &cursor})
+		assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, invalidCursorError.Error()), err, cursor)
+	}
+}
+
+func TestController_GetCars_pageWithNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	limit := 10
+	near := "49.0069,8.4037"
+	radius := 1500.0
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Limit: &limit, Near: &near, Radius: &radius})
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest,
+		"limit and cursor cannot be combined with near and radius"), err)
+}
+
+func TestController_GetCars_pageOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	limit := 10
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().ReadVinsPage(ctx, gomock.Any(), nil, limit).Return(model.VinPage{}, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Limit: &limit})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_GetCars_expand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	expand := true
+	cars := []carTypes.Car{exampleModelCar}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars?expand=true", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.EXPECT().ReadCarsMatching(ctx, model.CarFilter{}, nil).Return(cars, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, cars)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Expand: &expand})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_expandFalse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	expand := false
+	vins := []string{"12345678901234567"}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars?expand=false", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().ReadAllVins(ctx).Return(vins, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vins)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Expand: &expand})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_fields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	fields := "brand,dynamicData.position,technicalSpecification.tire"
+	color := "black"
+	cars := []carTypes.Car{exampleModelCar}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.
+		EXPECT().
+		ReadCarsMatching(ctx, model.CarFilter{Color: &color},
+			[]string{"vin", "brand", "dynamicData.position", "technicalSpecification.tire"}).
+		Return(cars, nil)
+
+	expected := []map[string]interface{}{{
+		"vin":   "12345678901234567",
+		"brand": "Volkswagen",
+		"dynamicData": map[string]interface{}{
+			"position": map[string]interface{}{"latitude": 49.0069, "longitude": 8.4037},
+		},
+		"technicalSpecification": map[string]interface{}{
+			"tire": map[string]interface{}{"manufacturer": "GOODYEAR", "type": "185/65R15"},
+		},
+	}}
+	mockEchoContext.EXPECT().JSON(http.StatusOK, expected)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Fields: &fields, Color: &color})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_fieldsUnknown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	controller := NewController(mockOperations)
+
+	for _, fields := range []string{"brand,color", "dynamicData.", "", "technicalSpecification.tire.type"} {
+		fields := fields
+		err := controller.GetCars(mockEchoContext, GetCarsParams{Fields: &fields})
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, fields)
+	}
+}
+
+func TestController_GetCars_expandPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	expand := true
+	limit := 1
+	cars := []carTypes.Car{exampleModelCar}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars?expand=true&limit=1", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockOperations.
+		EXPECT().
+		ReadCarsPage(ctx, model.CarFilter{}, nil, limit, nil).
+		Return(model.CarPage{Cars: cars, Next: &cars[0].Vin}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, cars)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Expand: &expand, Limit: &limit})
+	assert.Nil(t, err)
+	assert.Equal(t, "</cars?cursor="+encodeCursor(cars[0].Vin)+"&expand=true&limit=1>; rel=\"next\"",
+		recorder.Header().Get("Link"))
+}
+
+func TestController_GetCars_expandNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	fields := "model"
+	near := "49.0069,8.4037"
+	radius := 1500.0
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.
+		EXPECT().
+		ReadCarsNear(ctx, carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}, radius,
+			model.CarFilter{}, []string{"vin", "model"}).
+		Return([]carTypes.Car{exampleModelCar}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK,
+		[]map[string]interface{}{{"vin": "12345678901234567", "model": "Golf"}})
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Fields: &fields, Near: &near, Radius: &radius})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCars_expandOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	expand := true
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.EXPECT().ReadCarsMatching(ctx, gomock.Any(), gomock.Any()).Return(nil, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Expand: &expand})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_GetCars_expandImperial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	expand := true
+	imperial := UnitsParamImperial
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars?expand=true&units=imperial", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockOperations.EXPECT().ReadCarsMatching(ctx, model.CarFilter{}, nil).Return([]carTypes.Car{exampleModelCar}, nil)
+
+	converted, carUnits := units.ConvertCar(exampleModelCar, units.Imperial)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, []unitCar{{Car: converted, Units: carUnits}})
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Expand: &expand, Units: &imperial})
+	assert.Nil(t, err)
+	assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+}
+
+func TestController_GetCars_fieldsImperial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	fields := "brand,technicalSpecification.consumption"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+	request.Header.Set("Accept", "application/json; profile=imperial")
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	// the fuel is read as well because the unit of the consumption depends on it
+	mockOperations.
+		EXPECT().
+		ReadCarsMatching(ctx, model.CarFilter{},
+			[]string{"vin", "brand", "technicalSpecification.consumption", "technicalSpecification.fuel"}).
+		Return([]carTypes.Car{exampleModelCar}, nil)
+
+	expected := []map[string]interface{}{{
+		"vin":   "12345678901234567",
+		"brand": "Volkswagen",
+		"technicalSpecification": map[string]interface{}{
+			"consumption": map[string]interface{}{"city": 10.3, "combined": 8.4, "overland": 7.4},
+		},
+		"units": units.Units{"technicalSpecification.consumption": "kWh/100mi"},
+	}}
+	mockEchoContext.EXPECT().JSON(http.StatusOK, expected)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCars(mockEchoContext, GetCarsParams{Fields: &fields})
+	assert.Nil(t, err)
 }
 
 func TestController_AddCar_success(t *testing.T) {
@@ -115,80 +705,1330 @@ func TestController_AddCar_success(t *testing.T) {
 
 	ctx := context.Background()
 
-	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockOperations.
+		EXPECT().CreateCar(ctx, &exampleModelCar).Return(exampleModelCar.Vin, nil, nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().JSON(http.StatusCreated, exampleModelCar.Vin)
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.Nil(t, err)
+
+}
+
+func TestController_AddCar_warnings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockOperations.
+		EXPECT().CreateCar(ctx, &exampleModelCar).Return(exampleModelCar.Vin, []string{`invalid "VIN"`}, nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockEchoContext.EXPECT().JSON(http.StatusCreated, exampleModelCar.Vin)
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`299 - "invalid \"VIN\""`}, recorder.Header().Values("Warning"))
+}
+
+func TestController_AddCar_duplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().CreateCar(ctx, &exampleModelCar).Return("", nil,
+		mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}})
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusConflict, "VIN already exists"), err)
+}
+
+func TestController_AddCar_archived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().CreateCar(ctx, &exampleModelCar).Return("", nil, &database.ArchivedCarError{Vin: exampleModelCar.Vin})
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusConflict, "VIN belongs to an archived car, use :restore instead"), err)
+}
+
+func TestController_AddCar_invalidVin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	for _, vinError := range []error{
+		&vin.CheckDigitError{Vin: exampleModelCar.Vin, Expected: '7'},
+		&vin.InconsistencyError{Vin: exampleModelCar.Vin, Reason: "the brand does not match"},
+	} {
+		request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+		mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+		mockEchoContext.EXPECT().Request().Return(request)
+		mockOperations.
+			EXPECT().CreateCar(ctx, &exampleModelCar).Return("", nil, vinError)
+
+		controller := NewController(mockOperations)
+		err := controller.AddCar(mockEchoContext, AddCarParams{})
+		assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, vinError.Error()), err)
+	}
+}
+
+// exampleValidationError is the error of a car without seats.
+// exampleStoredCar was stored before the tire specification was derived, so it is parsed from the tire type
+var exampleStoredCar = model.StoredCar{CarWithRevision: model.CarWithRevision{Car: exampleModelCar, Revision: 4}}
+
+var exampleValidationError = &validation.Error{Violations: []validation.Violation{
+	{Field: "technicalSpecification.numberOfSeats", Message: "A car needs at least one seat."},
+}}
+
+func TestController_AddCar_implausible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().CreateCar(ctx, &exampleModelCar).Return("", nil, exampleValidationError)
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusUnprocessableEntity, violationsMessage{
+		Message:    "The car is implausible.",
+		Violations: exampleValidationError.Violations,
+	}), err)
+}
+
+func TestController_AddCar_unexpectedBindError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	bindError := errors.New("bind error")
+	mockEchoContext.EXPECT().Bind(gomock.Any()).Return(bindError)
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.ErrorIs(t, err, bindError)
+}
+
+func TestController_AddCar_unexpectedOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	operationsError := errors.New("operations error")
+	mockOperations.
+		EXPECT().CreateCar(ctx, &exampleModelCar).Return("", nil, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.AddCar(mockEchoContext, AddCarParams{})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_AddCars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars:batch", nil)
+
+	otherCar := exampleModelCar
+	otherCar.Vin = "12345678901234568"
+
+	exampleJson, _ := json.Marshal(exampleModelCar)
+	otherJson, _ := json.Marshal(otherCar)
+	values := []json.RawMessage{
+		exampleJson,
+		json.RawMessage(`{"vin": "12345678901234569", "brand": "Volkswagen"}`),
+		otherJson,
+		json.RawMessage(`"WVWAA71K08W201030"`),
+	}
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, values).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	// only the valid cars are created
+	mockOperations.
+		EXPECT().
+		CreateCars(ctx, []carTypes.Car{exampleModelCar, otherCar}).
+		Return([]error{nil, mongo.WriteError{Code: 11000}}, [][]string{{"invalid VIN"}, nil}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, []batchResult{
+		{Vin: exampleModelCar.Vin, Status: batchCreated, Warnings: []string{"invalid VIN"}},
+		{Vin: "12345678901234569", Status: batchInvalid, Message: `property "/model": property "model" is missing`},
+		{Vin: otherCar.Vin, Status: batchDuplicate, Message: "VIN already exists"},
+		{Status: batchInvalid, Message: `property "/": value must be an object`},
+	})
+
+	controller := NewController(mockOperations)
+	err := controller.AddCars(mockEchoContext)
+	assert.Nil(t, err)
+}
+
+func TestController_AddCars_invalidCheckDigit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars:batch", nil)
+
+	exampleJson, _ := json.Marshal(exampleModelCar)
+	checkDigitError := &vin.CheckDigitError{Vin: exampleModelCar.Vin, Expected: '7'}
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, []json.RawMessage{exampleJson}).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().
+		CreateCars(ctx, []carTypes.Car{exampleModelCar}).
+		Return([]error{checkDigitError}, [][]string{nil}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, []batchResult{
+		{Vin: exampleModelCar.Vin, Status: batchInvalid, Message: checkDigitError.Error()},
+	})
+
+	controller := NewController(mockOperations)
+	err := controller.AddCars(mockEchoContext)
+	assert.Nil(t, err)
+}
+
+func TestController_AddCars_implausible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars:batch", nil)
+
+	exampleJson, _ := json.Marshal(exampleModelCar)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, []json.RawMessage{exampleJson}).Return(nil)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().
+		CreateCars(ctx, []carTypes.Car{exampleModelCar}).
+		Return([]error{exampleValidationError}, [][]string{nil}, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, []batchResult{{
+		Vin:        exampleModelCar.Vin,
+		Status:     batchInvalid,
+		Message:    exampleValidationError.Error(),
+		Violations: exampleValidationError.Violations,
+	}})
+
+	controller := NewController(mockOperations)
+	err := controller.AddCars(mockEchoContext)
+	assert.Nil(t, err)
+}
+
+func TestController_AddCars_unexpectedOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars:batch", nil)
+
+	exampleJson, _ := json.Marshal(exampleModelCar)
+
+	for _, test := range []struct {
+		carErrors       []error
+		operationsError error
+	}{
+		{nil, errors.New("operations error")},
+		{[]error{errors.New("write error")}, nil},
+	} {
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+		mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, []json.RawMessage{exampleJson}).Return(nil)
+		mockEchoContext.EXPECT().Request().Return(request)
+		mockOperations.EXPECT().CreateCars(ctx, gomock.Any()).
+			Return(test.carErrors, make([][]string, len(test.carErrors)), test.operationsError)
+
+		controller := NewController(mockOperations)
+		err := controller.AddCars(mockEchoContext)
+		assert.NotNil(t, err)
+	}
+}
+
+// expectForEachCar lets the mocked operations pass the given cars to the export.
+func expectForEachCar(mockOperations *mocks.MockIOperations, ctx context.Context, cars ...carTypes.Car) {
+	mockOperations.
+		EXPECT().
+		ForEachCar(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, each func(car *carTypes.Car) error) error {
+			for i := range cars {
+				if err := each(&cars[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func TestController_ExportCars_ndjson(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars/export", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	expectForEachCar(mockOperations, ctx, exampleModelCar)
+
+	controller := NewController(mockOperations)
+	err := controller.ExportCars(mockEchoContext, ExportCarsParams{})
+	assert.Nil(t, err)
+
+	expected, _ := json.Marshal(exampleModelCar)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="cars.ndjson"`, recorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, string(expected)+"\n", recorder.Body.String())
+}
+
+func TestController_ExportCars_csv(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	format := ExportCarsParamsFormatCsv
+	otherCar := exampleModelCar
+	otherCar.Vin = "12345678901234568"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars/export?format=csv", nil)
+	recorder := httptest.NewRecorder()
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	expectForEachCar(mockOperations, ctx, exampleModelCar, otherCar)
+
+	controller := NewController(mockOperations)
+	err := controller.ExportCars(mockEchoContext, ExportCarsParams{Format: &format})
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="cars.csv"`, recorder.Header().Get("Content-Disposition"))
+	assert.Len(t, lines, 3)
+	assert.Equal(t, strings.Join(csvColumns, ","), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], exampleModelCar.Vin+","))
+	assert.True(t, strings.HasPrefix(lines[2], otherCar.Vin+","))
+}
+
+func TestController_ExportCars_operationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars/export", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.EXPECT().ForEachCar(ctx, gomock.Any()).Return(operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.ExportCars(mockEchoContext, ExportCarsParams{})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_DeleteCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().ArchiveCar(ctx, vin, nil).Return(true, nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, vin, DeleteCarParams{})
+	assert.Nil(t, err)
+}
+
+func TestController_DeleteCar_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().ArchiveCar(ctx, vin, nil).Return(false, nil)
+
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, vin, DeleteCarParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
+}
+
+func TestController_DeleteCar_ifMatchAnyNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	ifMatch := "*"
+
+	request, _ := http.NewRequestWithContext(ctx, "DELETE", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().ArchiveCar(ctx, vin, nil).Return(false, nil)
+
+	// "*" only matches an existing car
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, vin, DeleteCarParams{IfMatch: &ifMatch})
+	assert.Equal(t, echo.NewHTTPError(http.StatusPreconditionFailed, "VIN not found"), err)
+}
+
+func TestController_DeleteCar_unexpectedOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	operationsError := errors.New("operations error")
+	mockOperations.
+		EXPECT().ArchiveCar(ctx, vin, nil).Return(false, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, vin, DeleteCarParams{})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_DeleteCar_ifMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	ifMatch := `"4"`
+	revision := int64(4)
+
+	request, _ := http.NewRequestWithContext(ctx, "DELETE", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().ArchiveCar(ctx, vin, &revision).Return(true, nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, vin, DeleteCarParams{IfMatch: &ifMatch})
+	assert.Nil(t, err)
+}
+
+func TestController_DeleteCar_revisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	ifMatch := `"3"`
+
+	request, _ := http.NewRequestWithContext(ctx, "DELETE", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().ArchiveCar(ctx, vin, gomock.Any()).Return(false, &database.RevisionMismatchError{Vin: vin})
+
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, vin, DeleteCarParams{IfMatch: &ifMatch})
+	assert.Equal(t, echo.NewHTTPError(http.StatusPreconditionFailed, "ETag does not match"), err)
+}
+
+func TestController_DeleteCar_multipleETags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ifMatch := `"3", "4"`
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	controller := NewController(mockOperations)
+	err := controller.DeleteCar(mockEchoContext, "12345678901234569", DeleteCarParams{IfMatch: &ifMatch})
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, "If-Match must be * or a single ETag"), err)
+}
+
+// expectUpdateStaticData lets the mocked operations apply the update function to exampleModelCar, which results in
+// the revision 5.
+func expectUpdateStaticData(mockOperations *mocks.MockIOperations, ctx context.Context) {
+	mockOperations.
+		EXPECT().
+		UpdateStaticData(ctx, exampleModelCar.Vin, nil, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, vin carTypes.Vin, revision *int64,
+			update func(car *carTypes.Car) error) (model.CarWithRevision, error) {
+
+			car := exampleModelCar
+			if err := update(&car); err != nil {
+				return model.CarWithRevision{}, err
+			}
+			return model.CarWithRevision{Car: car, Revision: 5}, nil
+		})
+}
+
+func TestController_PatchCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "PATCH", "https://example.com/cars/12345678901234567",
+		strings.NewReader(`{"brand": "Audi", "technicalSpecification": {"tire": {"type": "205/55R16"}}}`))
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	expected := exampleModelCar
+	expected.Brand = "Audi"
+	expected.TechnicalSpecification.Tire.Type = "205/55R16"
+
+	response := echo.NewResponse(httptest.NewRecorder(), nil)
+
+	mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+	mockEchoContext.EXPECT().Response().Return(response)
+	expectUpdateStaticData(mockOperations, ctx)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, expected)
+
+	controller := NewController(mockOperations)
+	err := controller.PatchCar(mockEchoContext, exampleModelCar.Vin, PatchCarParams{})
+	assert.Nil(t, err)
+	// the ETag of the patched car can be used for the next conditional request
+	assert.Equal(t, `"5"`, response.Header().Get("ETag"))
+}
+
+func TestController_PatchCar_invalidCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	for _, patch := range []string{
+		`{"brand": null}`,
+		`{"technicalSpecification": {"transmission": "CVT"}}`,
+		`{"technicalSpecification": {"numberOfSeats": "seven"}}`,
+		`{"productionDate": "2023-02-30"}`,
+	} {
+		request, _ := http.NewRequestWithContext(ctx, "PATCH", "https://example.com/cars/12345678901234567",
+			strings.NewReader(patch))
+
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+
+		mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+		expectUpdateStaticData(mockOperations, ctx)
+
+		controller := NewController(mockOperations)
+		err := controller.PatchCar(mockEchoContext, exampleModelCar.Vin, PatchCarParams{})
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, patch)
+	}
+}
+
+func TestController_PatchCar_invalidPatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	for _, patch := range []string{`["brand"]`, `{"brand": `, `{"dynamicData": {"engineState": "ON"}}`} {
+		request, _ := http.NewRequestWithContext(ctx, "PATCH", "https://example.com/cars/12345678901234567",
+			strings.NewReader(patch))
+
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+
+		mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+
+		controller := NewController(mockOperations)
+		err := controller.PatchCar(mockEchoContext, exampleModelCar.Vin, PatchCarParams{})
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, patch)
+	}
+}
+
+func TestController_PatchCar_errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	operationsError := errors.New("operations error")
+	ruleViolation := &operations.RuleViolationError{Reason: "The VIN of a car cannot be changed."}
+
+	for _, test := range []struct {
+		operationsError error
+		expected        error
+	}{
+		{mongo.ErrNoDocuments, echo.NewHTTPError(http.StatusNotFound, "VIN not found")},
+		{&database.RevisionMismatchError{Vin: exampleModelCar.Vin},
+			echo.NewHTTPError(http.StatusPreconditionFailed, "ETag does not match")},
+		{ruleViolation, echo.NewHTTPError(http.StatusConflict, ruleViolation.Reason)},
+		{exampleValidationError, echo.NewHTTPError(http.StatusUnprocessableEntity, violationsMessage{
+			Message:    "The car is implausible.",
+			Violations: exampleValidationError.Violations,
+		})},
+		{operationsError, operationsError},
+	} {
+		request, _ := http.NewRequestWithContext(ctx, "PATCH", "https://example.com/cars/12345678901234567",
+			strings.NewReader(`{"vin": "12345678901234568"}`))
+
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+
+		mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+		mockOperations.
+			EXPECT().
+			UpdateStaticData(ctx, exampleModelCar.Vin, nil, gomock.Any()).
+			Return(model.CarWithRevision{}, test.operationsError)
+
+		controller := NewController(mockOperations)
+		err := controller.PatchCar(mockEchoContext, exampleModelCar.Vin, PatchCarParams{})
+		assert.Equal(t, test.expected, err)
+	}
+}
+
+func TestController_PatchCar_ifMatchAnyNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	ifMatch := "*"
+	request, _ := http.NewRequestWithContext(ctx, "PATCH", "https://example.com/cars/12345678901234567",
+		strings.NewReader(`{"brand": "Audi"}`))
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request).AnyTimes()
+	mockOperations.
+		EXPECT().
+		UpdateStaticData(ctx, exampleModelCar.Vin, nil, gomock.Any()).
+		Return(model.CarWithRevision{}, mongo.ErrNoDocuments)
+
+	// "*" only matches an existing car
+	controller := NewController(mockOperations)
+	err := controller.PatchCar(mockEchoContext, exampleModelCar.Vin, PatchCarParams{IfMatch: &ifMatch})
+	assert.Equal(t, echo.NewHTTPError(http.StatusPreconditionFailed, "VIN not found"), err)
+}
+
+func TestController_RestoreCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars/"+vin+":restore", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().RestoreCar(ctx, vin).Return(true, nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+
+	controller := NewController(mockOperations)
+	err := controller.RestoreCar(mockEchoContext, vin)
+	assert.Nil(t, err)
+}
+
+func TestController_RestoreCar_notArchived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars/"+vin+":restore", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().RestoreCar(ctx, vin).Return(false, nil)
+
+	controller := NewController(mockOperations)
+	err := controller.RestoreCar(mockEchoContext, vin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "no archived car with this VIN"), err)
+}
+
+func TestController_RestoreCar_unexpectedOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars/"+vin+":restore", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().RestoreCar(ctx, vin).Return(false, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.RestoreCar(mockEchoContext, vin)
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_PurgeCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars/"+vin+":purge", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().PurgeCar(ctx, vin).Return(true, nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+
+	controller := NewController(mockOperations)
+	err := controller.PurgeCar(mockEchoContext, vin)
+	assert.Nil(t, err)
+}
+
+func TestController_PurgeCar_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars/"+vin+":purge", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().PurgeCar(ctx, vin).Return(false, nil)
+
+	controller := NewController(mockOperations)
+	err := controller.PurgeCar(mockEchoContext, vin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
+}
+
+func TestController_GetCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	response := echo.NewResponse(httptest.NewRecorder(), nil)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(response)
+	mockOperations.
+		EXPECT().ReadStoredCar(ctx, vin).Return(exampleStoredCar, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, newDecodedCar(exampleModelCar, nil))
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{})
+	assert.Nil(t, err)
+	assert.Equal(t, `"4"`, response.Header().Get("ETag"))
+}
+
+func TestController_GetCar_notModified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	for _, ifNoneMatch := range []string{`"4"`, `W/"4"`, `"3", "4"`, "*"} {
+		request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+
+		response := echo.NewResponse(httptest.NewRecorder(), nil)
+
+		mockEchoContext.EXPECT().Request().Return(request)
+		mockEchoContext.EXPECT().Response().Return(response)
+		mockOperations.
+			EXPECT().ReadStoredCar(ctx, vin).Return(exampleStoredCar, nil)
+		mockEchoContext.EXPECT().NoContent(http.StatusNotModified)
+
+		controller := NewController(mockOperations)
+		err := controller.GetCar(mockEchoContext, vin, GetCarParams{IfNoneMatch: &ifNoneMatch})
+		assert.Nil(t, err, ifNoneMatch)
+		assert.Equal(t, `"4"`, response.Header().Get("ETag"), ifNoneMatch)
+	}
+}
+
+func TestController_GetCar_otherUnitSystem(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	imperial := UnitsParamImperial
+	ifNoneMatch := `"4"`
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.
+		EXPECT().ReadStoredCar(ctx, vin).Return(exampleStoredCar, nil)
+	// the metric ETag of the same revision does not match the imperial representation
+	mockEchoContext.EXPECT().JSON(http.StatusOK, gomock.Any())
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{Units: &imperial, IfNoneMatch: &ifNoneMatch})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCar_modified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	ifNoneMatch := `"3"`
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.
+		EXPECT().ReadStoredCar(ctx, vin).Return(exampleStoredCar, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, newDecodedCar(exampleModelCar, nil))
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{IfNoneMatch: &ifNoneMatch})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCar_imperial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	imperial := UnitsParamImperial
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	response := echo.NewResponse(httptest.NewRecorder(), nil)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(response)
+	mockOperations.
+		EXPECT().ReadStoredCar(ctx, vin).Return(exampleStoredCar, nil)
+
+	converted, carUnits := units.ConvertCar(exampleModelCar, units.Imperial)
+	expected := newDecodedCar(converted, nil)
+	expected.Units = carUnits
+	mockEchoContext.EXPECT().JSON(http.StatusOK, expected)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{Units: &imperial})
+	assert.Nil(t, err)
+	// the imperial representation has its own ETag
+	assert.Equal(t, `"4-imperial"`, response.Header().Get("ETag"))
+	assert.Equal(t, "Accept", response.Header().Get("Vary"))
+}
+
+func TestController_GetCar_imperialAccept(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+	request.Header.Set("Accept", "application/json; profile=imperial")
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(httptest.NewRecorder(), nil))
+	mockOperations.
+		EXPECT().ReadStoredCar(ctx, vin).Return(exampleStoredCar, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, gomock.Any()).DoAndReturn(func(_ int, car decodedCar) error {
+		assert.Equal(t, 2910, car.TechnicalSpecification.Weight)
+		assert.Equal(t, "14.3gal;85.2kWh", car.TechnicalSpecification.FuelCapacity)
+		assert.Equal(t, "lb", car.Units["technicalSpecification.weight"])
+		return nil
+	})
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{})
+	assert.Nil(t, err)
+}
+
+func TestController_GetCar_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.
+		EXPECT().
+		ReadStoredCar(ctx, vin).Return(model.StoredCar{}, mongo.ErrNoDocuments)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound), err)
+}
+
+func TestController_GetCar_unexpectedOperationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	operationsError := errors.New("operations error")
+	mockOperations.
+		EXPECT().
+		ReadStoredCar(ctx, vin).Return(model.StoredCar{}, operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCar(mockEchoContext, vin, GetCarParams{})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_ChangeTrunkLockState_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
+	mockOperations.EXPECT().SetTrunkLockState(ctx, vin, carTypes.UNLOCKED, nil).Return(nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeTrunkLockState(mockEchoContext, vin, ChangeTrunkLockStateParams{})
+	assert.Nil(t, err)
+}
+
+func TestController_ChangeTrunkLockState_carNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
-	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
-	mockCrud.
-		EXPECT().CreateCar(ctx, &exampleModelCar).Return(exampleModelCar.Vin, nil)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockEchoContext.EXPECT().JSON(http.StatusCreated, exampleModelCar.Vin)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
+	mockOperations.EXPECT().SetTrunkLockState(ctx, vin, carTypes.UNLOCKED, nil).Return(mongo.ErrNoDocuments)
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeTrunkLockState(mockEchoContext, vin, ChangeTrunkLockStateParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
+}
+
+func TestController_ChangeTrunkLockState_revisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+	// If-Match uses the strong comparison, so a weak ETag never matches
+	ifMatch := `W/"3"`
+	revision := int64(unknownRevision)
+
+	request, _ := http.NewRequestWithContext(ctx, "PUT", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
+	mockOperations.
+		EXPECT().
+		SetTrunkLockState(ctx, vin, carTypes.UNLOCKED, &revision).
+		Return(&database.RevisionMismatchError{Vin: vin})
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeTrunkLockState(mockEchoContext, vin, ChangeTrunkLockStateParams{IfMatch: &ifMatch})
+	assert.Equal(t, echo.NewHTTPError(http.StatusPreconditionFailed, "ETag does not match"), err)
+}
+
+func TestController_ChangeTrunkLockState_operationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.LOCKED).Return(nil)
+	mockOperations.EXPECT().SetTrunkLockState(ctx, vin, carTypes.LOCKED, nil).Return(operationsError)
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeTrunkLockState(mockEchoContext, vin, ChangeTrunkLockStateParams{})
+	assert.ErrorIs(t, err, operationsError)
+}
+
+func TestController_ChangeTrunkLockState_ruleViolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	ruleViolation := &operations.RuleViolationError{Reason: "some reason"}
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
+	mockOperations.EXPECT().SetTrunkLockState(ctx, vin, carTypes.UNLOCKED, nil).Return(ruleViolation)
 
-	controller := NewController(mockCrud)
-	err := controller.AddCar(mockEchoContext)
+	controller := NewController(mockOperations)
+	err := controller.ChangeTrunkLockState(mockEchoContext, vin, ChangeTrunkLockStateParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusConflict, "some reason"), err)
+}
+
+func TestController_ChangeDoorsLockState_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
+	mockOperations.EXPECT().SetDoorsLockState(ctx, vin, carTypes.UNLOCKED).Return(nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeDoorsLockState(mockEchoContext, vin)
 	assert.Nil(t, err)
+}
+
+func TestController_ChangeDoorsLockState_carNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
+	mockOperations.EXPECT().SetDoorsLockState(ctx, vin, carTypes.UNLOCKED).Return(mongo.ErrNoDocuments)
 
+	controller := NewController(mockOperations)
+	err := controller.ChangeDoorsLockState(mockEchoContext, vin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
 }
 
-func TestController_AddCar_duplicate(t *testing.T) {
+func TestController_ChangeDoorsLockState_operationsError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
-	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
-	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockCrud.
-		EXPECT().CreateCar(ctx, &exampleModelCar).Return("",
-		mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}})
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.LOCKED).Return(nil)
+	mockOperations.EXPECT().SetDoorsLockState(ctx, vin, carTypes.LOCKED).Return(operationsError)
 
-	controller := NewController(mockCrud)
-	err := controller.AddCar(mockEchoContext)
-	assert.Equal(t, echo.NewHTTPError(http.StatusConflict, "VIN already exists"), err)
+	controller := NewController(mockOperations)
+	err := controller.ChangeDoorsLockState(mockEchoContext, vin)
+	assert.ErrorIs(t, err, operationsError)
 }
 
-func TestController_AddCar_unexpectedBindError(t *testing.T) {
+func TestController_ChangeEngineState_success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
-	bindError := errors.New("bind error")
-	mockEchoContext.EXPECT().Bind(gomock.Any()).Return(bindError)
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.ON).Return(nil)
+	mockOperations.EXPECT().SetEngineState(ctx, vin, carTypes.ON).Return(nil)
+	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
 
-	controller := NewController(mockCrud)
-	err := controller.AddCar(mockEchoContext)
-	assert.ErrorIs(t, err, bindError)
+	controller := NewController(mockOperations)
+	err := controller.ChangeEngineState(mockEchoContext, vin)
+	assert.Nil(t, err)
 }
 
-func TestController_AddCar_unexpectedCrudError(t *testing.T) {
+func TestController_ChangeEngineState_carNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
-	request, _ := http.NewRequestWithContext(ctx, "POST", "https://example.com/cars", nil)
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
-	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar).Return(nil)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.ON).Return(nil)
+	mockOperations.EXPECT().SetEngineState(ctx, vin, carTypes.ON).Return(mongo.ErrNoDocuments)
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeEngineState(mockEchoContext, vin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
+}
+
+func TestController_ChangeEngineState_operationsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
+
 	mockEchoContext.EXPECT().Request().Return(request)
-	crudError := errors.New("crud error")
-	mockCrud.
-		EXPECT().CreateCar(ctx, &exampleModelCar).Return("", crudError)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.OFF).Return(nil)
+	mockOperations.EXPECT().SetEngineState(ctx, vin, carTypes.OFF).Return(operationsError)
 
-	controller := NewController(mockCrud)
-	err := controller.AddCar(mockEchoContext)
-	assert.ErrorIs(t, err, crudError)
+	controller := NewController(mockOperations)
+	err := controller.ChangeEngineState(mockEchoContext, vin)
+	assert.ErrorIs(t, err, operationsError)
 }
 
-func TestController_DeleteCar_success(t *testing.T) {
+func TestController_ChangeEngineState_ruleViolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	ruleViolation := &operations.RuleViolationError{Reason: "some reason"}
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.ON).Return(nil)
+	mockOperations.EXPECT().SetEngineState(ctx, vin, carTypes.ON).Return(ruleViolation)
+
+	controller := NewController(mockOperations)
+	err := controller.ChangeEngineState(mockEchoContext, vin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusConflict, "some reason"), err)
+}
+
+func TestController_ChangeDynamicData_success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -199,19 +2039,19 @@ func TestController_DeleteCar_success(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockCrud.
-		EXPECT().DeleteCar(ctx, vin).Return(true, nil)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar.DynamicData).Return(nil)
+	mockOperations.EXPECT().SetDynamicData(ctx, vin, &exampleModelCar.DynamicData).Return(nil)
 	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
 
-	controller := NewController(mockCrud)
-	err := controller.DeleteCar(mockEchoContext, vin)
+	controller := NewController(mockOperations)
+	err := controller.ChangeDynamicData(mockEchoContext, vin)
 	assert.Nil(t, err)
 }
 
-func TestController_DeleteCar_notFound(t *testing.T) {
+func TestController_ChangeDynamicData_carNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -222,18 +2062,18 @@ func TestController_DeleteCar_notFound(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockCrud.
-		EXPECT().DeleteCar(ctx, vin).Return(false, nil)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar.DynamicData).Return(nil)
+	mockOperations.EXPECT().SetDynamicData(ctx, vin, &exampleModelCar.DynamicData).Return(mongo.ErrNoDocuments)
 
-	controller := NewController(mockCrud)
-	err := controller.DeleteCar(mockEchoContext, vin)
+	controller := NewController(mockOperations)
+	err := controller.ChangeDynamicData(mockEchoContext, vin)
 	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
 }
 
-func TestController_DeleteCar_unexpectedCrudError(t *testing.T) {
+func TestController_ChangeDynamicData_operationsError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -244,42 +2084,49 @@ func TestController_DeleteCar_unexpectedCrudError(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	crudError := errors.New("crud error")
-	mockCrud.
-		EXPECT().DeleteCar(ctx, vin).Return(false, crudError)
+	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, exampleModelCar.DynamicData).Return(nil)
+	mockOperations.EXPECT().SetDynamicData(ctx, vin, &exampleModelCar.DynamicData).Return(operationsError)
 
-	controller := NewController(mockCrud)
-	err := controller.DeleteCar(mockEchoContext, vin)
-	assert.ErrorIs(t, err, crudError)
+	controller := NewController(mockOperations)
+	err := controller.ChangeDynamicData(mockEchoContext, vin)
+	assert.ErrorIs(t, err, operationsError)
 }
 
-func TestController_GetCar_success(t *testing.T) {
+func TestController_GetPositions_success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
 	vin := "12345678901234569"
+	from := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	records := []model.PositionRecord{{
+		Timestamp:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		Position:    exampleModelCar.DynamicData.Position,
+		EngineState: carTypes.ON,
+	}}
 
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockCrud.
-		EXPECT().ReadCar(ctx, vin).Return(exampleModelCar, nil)
-	mockEchoContext.EXPECT().JSON(http.StatusOK, exampleModelCar)
+	mockOperations.EXPECT().ReadPositionRecords(ctx, vin, &from, nil).Return(records, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, records)
 
-	controller := NewController(mockCrud)
-	err := controller.GetCar(mockEchoContext, vin)
+	controller := NewController(mockOperations)
+	err := controller.GetPositions(mockEchoContext, vin, GetPositionsParams{From: &from})
 	assert.Nil(t, err)
 }
 
-func TestController_GetCar_notFound(t *testing.T) {
+func TestController_GetPositions_carNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -290,19 +2137,17 @@ func TestController_GetCar_notFound(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockCrud.
-		EXPECT().
-		ReadCar(ctx, vin).Return(carTypes.Car{}, mongo.ErrNoDocuments)
+	mockOperations.EXPECT().ReadPositionRecords(ctx, vin, nil, nil).Return(nil, mongo.ErrNoDocuments)
 
-	controller := NewController(mockCrud)
-	err := controller.GetCar(mockEchoContext, vin)
-	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound), err)
+	controller := NewController(mockOperations)
+	err := controller.GetPositions(mockEchoContext, vin, GetPositionsParams{})
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
 }
 
-func TestController_GetCar_unexpectedCrudError(t *testing.T) {
+func TestController_GetPositions_operationsError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -313,20 +2158,19 @@ func TestController_GetCar_unexpectedCrudError(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	operationsError := errors.New("operations error")
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	crudError := errors.New("crud error")
-	mockCrud.
-		EXPECT().
-		ReadCar(ctx, vin).Return(carTypes.Car{}, crudError)
+	mockOperations.EXPECT().ReadPositionRecords(ctx, vin, nil, nil).Return(nil, operationsError)
 
-	controller := NewController(mockCrud)
-	err := controller.GetCar(mockEchoContext, vin)
-	assert.ErrorIs(t, err, crudError)
+	controller := NewController(mockOperations)
+	err := controller.GetPositions(mockEchoContext, vin, GetPositionsParams{})
+	assert.ErrorIs(t, err, operationsError)
 }
 
-func TestController_ChangeTrunkLockState_success(t *testing.T) {
+func TestController_GetTrips_success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -334,22 +2178,28 @@ func TestController_ChangeTrunkLockState_success(t *testing.T) {
 
 	vin := "12345678901234569"
 
+	trips := []model.Trip{{
+		Start:    model.TripEndpoint{Timestamp: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)},
+		End:      model.TripEndpoint{Timestamp: time.Date(2023, 6, 1, 12, 10, 0, 0, time.UTC)},
+		Distance: 1500,
+		Duration: 600,
+	}}
+
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
-	mockCrud.EXPECT().SetTrunkLockState(ctx, vin, carTypes.UNLOCKED).Return(nil)
-	mockEchoContext.EXPECT().NoContent(http.StatusNoContent)
+	mockOperations.EXPECT().ReadTrips(ctx, vin).Return(trips, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, trips)
 
-	controller := NewController(mockCrud)
-	err := controller.ChangeTrunkLockState(mockEchoContext, vin)
+	controller := NewController(mockOperations)
+	err := controller.GetTrips(mockEchoContext, vin)
 	assert.Nil(t, err)
 }
 
-func TestController_ChangeTrunkLockState_carNotFound(t *testing.T) {
+func TestController_GetTrips_carNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -360,18 +2210,48 @@ func TestController_ChangeTrunkLockState_carNotFound(t *testing.T) {
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.UNLOCKED).Return(nil)
-	mockCrud.EXPECT().SetTrunkLockState(ctx, vin, carTypes.UNLOCKED).Return(mongo.ErrNoDocuments)
+	mockOperations.EXPECT().ReadTrips(ctx, vin).Return(nil, mongo.ErrNoDocuments)
 
-	controller := NewController(mockCrud)
-	err := controller.ChangeTrunkLockState(mockEchoContext, vin)
+	controller := NewController(mockOperations)
+	err := controller.GetTrips(mockEchoContext, vin)
 	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
 }
 
-func TestController_ChangeTrunkLockState_crudError(t *testing.T) {
+func TestController_GetRange_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	electric, combustion := 125.0, 500.0
+	estimate := model.RangeEstimate{
+		Profile:    model.RangeProfileCity,
+		Total:      625,
+		Electric:   &electric,
+		Combustion: &combustion,
+	}
+	profile := GetRangeParamsProfileCity
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().EstimateRange(ctx, vin, model.RangeProfileCity).Return(estimate, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, estimate)
+
+	controller := NewController(mockOperations)
+	err := controller.GetRange(mockEchoContext, vin, GetRangeParams{Profile: &profile})
+	assert.Nil(t, err)
+}
+
+func TestController_GetRange_defaultProfile(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -379,18 +2259,147 @@ func TestController_ChangeTrunkLockState_crudError(t *testing.T) {
 
 	vin := "12345678901234569"
 
+	estimate := model.RangeEstimate{Profile: model.RangeProfileCombined}
+
 	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
 
 	mockEchoContext := mocks.NewMockContext(ctrl)
-	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().EstimateRange(ctx, vin, model.RangeProfileCombined).Return(estimate, nil)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, estimate)
+
+	controller := NewController(mockOperations)
+	err := controller.GetRange(mockEchoContext, vin, GetRangeParams{})
+	assert.Nil(t, err)
+}
+
+func TestController_GetRange_errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	operationsError := errors.New("operations error")
+	rangeUnknownError := &model.RangeUnknownError{Reason: "The range cannot be estimated."}
+
+	for _, test := range []struct {
+		operationsError error
+		expected        error
+	}{
+		{mongo.ErrNoDocuments, echo.NewHTTPError(http.StatusNotFound, "VIN not found")},
+		{rangeUnknownError, echo.NewHTTPError(http.StatusUnprocessableEntity, rangeUnknownError.Reason)},
+		{operationsError, operationsError},
+	} {
+		request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+		mockEchoContext := mocks.NewMockContext(ctrl)
+		mockOperations := mocks.NewMockIOperations(ctrl)
+
+		mockEchoContext.EXPECT().Request().Return(request)
+		mockOperations.
+			EXPECT().
+			EstimateRange(ctx, vin, model.RangeProfileCombined).
+			Return(model.RangeEstimate{}, test.operationsError)
+
+		controller := NewController(mockOperations)
+		err := controller.GetRange(mockEchoContext, vin, GetRangeParams{})
+		assert.Equal(t, test.expected, err)
+	}
+}
+
+func TestController_GetCarEvents_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
 
-	crudError := errors.New("crud error")
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+	recorder := httptest.NewRecorder()
+
+	current := model.CarEvent{Vin: vin, DynamicData: exampleModelCar.DynamicData}
+	changed := current
+	changed.DynamicData.EngineState = carTypes.ON
+
+	// the unchanged event is not sent again
+	carEvents := make(chan model.CarEvent, 3)
+	carEvents <- current
+	carEvents <- changed
+	close(carEvents)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
 
 	mockEchoContext.EXPECT().Request().Return(request)
-	mockEchoContext.EXPECT().Bind(gomock.Any()).SetArg(0, carTypes.LOCKED).Return(nil)
-	mockCrud.EXPECT().SetTrunkLockState(ctx, vin, carTypes.LOCKED).Return(crudError)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockOperations.EXPECT().SubscribeCarEvents(ctx, vin).Return(current, carEvents, nil)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCarEvents(mockEchoContext, vin)
+	assert.Nil(t, err)
+
+	currentData, _ := json.Marshal(current)
+	changedData, _ := json.Marshal(changed)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "event: dynamicData\ndata: "+string(currentData)+"\n\n"+
+		"event: dynamicData\ndata: "+string(changedData)+"\n\n", recorder.Body.String())
+}
+
+func TestController_GetCarEvents_carNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vin := "12345678901234569"
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/cars", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().SubscribeCarEvents(ctx, vin).Return(model.CarEvent{}, nil, mongo.ErrNoDocuments)
+
+	controller := NewController(mockOperations)
+	err := controller.GetCarEvents(mockEchoContext, vin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
+}
+
+func TestController_GetFleetEvents_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://example.com/events", nil)
+	recorder := httptest.NewRecorder()
+
+	// the same dynamic data of different cars is sent for each car
+	first := model.CarEvent{Vin: "12345678901234568", DynamicData: exampleModelCar.DynamicData}
+	second := model.CarEvent{Vin: "12345678901234569", DynamicData: exampleModelCar.DynamicData}
+
+	fleetEvents := make(chan model.CarEvent, 2)
+	fleetEvents <- first
+	fleetEvents <- second
+	close(fleetEvents)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockEchoContext.EXPECT().Response().Return(echo.NewResponse(recorder, nil))
+	mockOperations.EXPECT().SubscribeFleetEvents(ctx).Return(fleetEvents)
+
+	controller := NewController(mockOperations)
+	err := controller.GetFleetEvents(mockEchoContext)
+	assert.Nil(t, err)
 
-	controller := NewController(mockCrud)
-	err := controller.ChangeTrunkLockState(mockEchoContext, vin)
-	assert.ErrorIs(t, err, crudError)
+	assert.Equal(t, 2, strings.Count(recorder.Body.String(), "event: dynamicData\n"))
 }
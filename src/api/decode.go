@@ -0,0 +1,37 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"DCar/logic/units"
+	"DCar/logic/vin"
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// decodedCar is a car together with the information decoded from its VIN and its tire specification. The tire
+// specification is nil if the tire type cannot be parsed. Units are only set if the car was converted (see
+// units.ConvertCar).
+type decodedCar struct {
+	carTypes.Car
+	DecodedVin        vin.Decoded              `json:"decodedVin"`
+	TireSpecification *model.TireSpecification `json:"tireSpecification,omitempty"`
+	Units             units.Units              `json:"units,omitempty"`
+}
+
+// newDecodedCar decodes the given car. The tire specification stored with the car is used, only if there is none
+// (e.g. the car was stored before the specification was derived) the tire type is parsed.
+func newDecodedCar(car carTypes.Car, tireSpecification *model.TireSpecification) decodedCar {
+	result := decodedCar{Car: car, DecodedVin: vin.Decode(car.Vin), TireSpecification: tireSpecification}
+	if tireSpecification != nil {
+		return result
+	}
+	if specification, err := model.ParseTireType(car.TechnicalSpecification.Tire.Type); err == nil {
+		result.TireSpecification = &specification
+	}
+	return result
+}
+
+func (c controller) DecodeVin(ctx echo.Context, vinToDecode carTypes.VinParam) error {
+	return ctx.JSON(http.StatusOK, vin.Decode(vinToDecode))
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"DCar/logic/vin"
+	"DCar/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestNewDecodedCar(t *testing.T) {
+	// the example VIN has no known WMI and no valid model year code
+	assert.Equal(t, decodedCar{
+		Car:        exampleModelCar,
+		DecodedVin: vin.Decoded{Wmi: "123", ModelYearCode: "0", ModelYears: []int{}, PlantCode: "1"},
+		TireSpecification: &model.TireSpecification{
+			Width:        185,
+			AspectRatio:  65,
+			Construction: model.TireConstructionRadial,
+			RimDiameter:  15,
+		},
+	}, newDecodedCar(exampleModelCar, nil))
+}
+
+func TestNewDecodedCar_invalidTireType(t *testing.T) {
+	car := exampleModelCar
+	car.TechnicalSpecification.Tire.Type = "unknown"
+
+	assert.Nil(t, newDecodedCar(car, nil).TireSpecification)
+}
+
+func TestNewDecodedCar_storedTireSpecification(t *testing.T) {
+	loadIndex := 91
+	specification := &model.TireSpecification{
+		Width:        205,
+		AspectRatio:  55,
+		Construction: model.TireConstructionRadial,
+		RimDiameter:  16,
+		LoadIndex:    &loadIndex,
+	}
+
+	// the stored specification is used instead of parsing the tire type again
+	assert.Equal(t, specification, newDecodedCar(exampleModelCar, specification).TireSpecification)
+}
+
+func TestController_DecodeVin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manufacturer := "Volkswagen"
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockEchoContext.EXPECT().JSON(http.StatusOK, vin.Decoded{
+		Wmi:           "WVW",
+		Manufacturer:  &manufacturer,
+		ModelYearCode: "8",
+		ModelYears:    []int{2008, 2038},
+		PlantCode:     "W",
+	})
+
+	controller := NewController(mocks.NewMockIOperations(ctrl))
+	err := controller.DecodeVin(mockEchoContext, "WVWAA71K08W201030")
+	assert.Nil(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	carTypes "github.com/ccsapp/cargotypes"
 	"net/http"
+	"strings"
 
 	"github.com/deepmap/oapi-codegen/pkg/runtime"
 	"github.com/labstack/echo/v4"
@@ -14,18 +15,63 @@ import (
 type Controller interface {
 	// GetCars Get VINs of all Cars
 	// (GET /cars)
-	GetCars(ctx echo.Context) error
+	GetCars(ctx echo.Context, params GetCarsParams) error
 	// AddCar Add a New Vehicle
 	// (POST /cars)
-	AddCar(ctx echo.Context) error
-	// DeleteCar DeleteOne a Car With All Components
+	AddCar(ctx echo.Context, params AddCarParams) error
+	// AddCars Add Many New Vehicles
+	// (POST /cars:batch)
+	AddCars(ctx echo.Context) error
+	// ExportCars Export All Cars
+	// (GET /cars/export)
+	ExportCars(ctx echo.Context, params ExportCarsParams) error
+	// DeleteCar Remove a Car from the System
 	// (DELETE /cars/{vin})
-	DeleteCar(ctx echo.Context, vin carTypes.VinParam) error
+	DeleteCar(ctx echo.Context, vin carTypes.VinParam, params DeleteCarParams) error
+	// RestoreCar Restore an Archived Car
+	// (POST /cars/{vin}:restore)
+	RestoreCar(ctx echo.Context, vin carTypes.VinParam) error
+	// PurgeCar Irreversibly Delete a Car
+	// (POST /cars/{vin}:purge)
+	PurgeCar(ctx echo.Context, vin carTypes.VinParam) error
 	// GetCar Get All Information About a Specific Car
 	// (GET /cars/{vin})
-	GetCar(ctx echo.Context, vin carTypes.VinParam) error
+	GetCar(ctx echo.Context, vin carTypes.VinParam, params GetCarParams) error
+	// PatchCar Change the Static Data of a Car
+	// (PATCH /cars/{vin})
+	PatchCar(ctx echo.Context, vin carTypes.VinParam, params PatchCarParams) error
 	// ChangeTrunkLockState Open or Close Trunk
-	ChangeTrunkLockState(ctx echo.Context, vin carTypes.VinParam) error
+	ChangeTrunkLockState(ctx echo.Context, vin carTypes.VinParam, params ChangeTrunkLockStateParams) error
+	// ChangeDoorsLockState Lock or Unlock Doors
+	// (PUT /cars/{vin}/doorsLock)
+	ChangeDoorsLockState(ctx echo.Context, vin carTypes.VinParam) error
+	// ChangeEngineState Start or Stop Engine
+	// (PUT /cars/{vin}/engine)
+	ChangeEngineState(ctx echo.Context, vin carTypes.VinParam) error
+	// ChangeDynamicData Report the Dynamic Data of a Car
+	// (PUT /cars/{vin}/dynamicData)
+	ChangeDynamicData(ctx echo.Context, vin carTypes.VinParam) error
+	// GetPositions Get the Position History of a Car
+	// (GET /cars/{vin}/positions)
+	GetPositions(ctx echo.Context, vin carTypes.VinParam, params GetPositionsParams) error
+	// GetTrips Get the Trips of a Car
+	// (GET /cars/{vin}/trips)
+	GetTrips(ctx echo.Context, vin carTypes.VinParam) error
+	// GetRange Estimate the Remaining Range of a Car
+	// (GET /cars/{vin}/range)
+	GetRange(ctx echo.Context, vin carTypes.VinParam, params GetRangeParams) error
+	// GetCarEvents Stream the Changes of a Car
+	// (GET /cars/{vin}/events)
+	GetCarEvents(ctx echo.Context, vin carTypes.VinParam) error
+	// GetFleetEvents Stream the Changes of all Cars
+	// (GET /events)
+	GetFleetEvents(ctx echo.Context) error
+	// ConnectCar Open a Remote Control Session
+	// (GET /cars/{vin}/ws)
+	ConnectCar(ctx echo.Context, vin carTypes.VinParam) error
+	// DecodeVin Decode a VIN
+	// (GET /vins/{vin}/decode)
+	DecodeVin(ctx echo.Context, vin carTypes.VinParam) error
 }
 
 // ControllerWrapper converts echo contexts to parameters.
@@ -37,8 +83,115 @@ type ControllerWrapper struct {
 func (w *ControllerWrapper) GetCars(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCarsParams
+	// ------------- Optional query parameter "near" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "near", ctx.QueryParams(), &params.Near)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter near: %s", err))
+	}
+
+	// ------------- Optional query parameter "radius" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "radius", ctx.QueryParams(), &params.Radius)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter radius: %s", err))
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", ctx.QueryParams(), &params.Limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter limit: %s", err))
+	}
+
+	// ------------- Optional query parameter "cursor" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "cursor", ctx.QueryParams(), &params.Cursor)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter cursor: %s", err))
+	}
+
+	// ------------- Optional query parameter "brand" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "brand", ctx.QueryParams(), &params.Brand)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter brand: %s", err))
+	}
+
+	// ------------- Optional query parameter "model" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "model", ctx.QueryParams(), &params.Model)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter model: %s", err))
+	}
+
+	// ------------- Optional query parameter "fuel" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fuel", ctx.QueryParams(), &params.Fuel)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fuel: %s", err))
+	}
+
+	// ------------- Optional query parameter "transmission" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "transmission", ctx.QueryParams(), &params.Transmission)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter transmission: %s", err))
+	}
+
+	// ------------- Optional query parameter "minSeats" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "minSeats", ctx.QueryParams(), &params.MinSeats)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter minSeats: %s", err))
+	}
+
+	// ------------- Optional query parameter "minTrunkVolume" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "minTrunkVolume", ctx.QueryParams(), &params.MinTrunkVolume)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter minTrunkVolume: %s", err))
+	}
+
+	// ------------- Optional query parameter "color" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "color", ctx.QueryParams(), &params.Color)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter color: %s", err))
+	}
+
+	// ------------- Optional query parameter "expand" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "expand", ctx.QueryParams(), &params.Expand)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter expand: %s", err))
+	}
+
+	// ------------- Optional query parameter "fields" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fields", ctx.QueryParams(), &params.Fields)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter fields: %s", err))
+	}
+
+	// ------------- Optional query parameter "includeArchived" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "includeArchived", ctx.QueryParams(), &params.IncludeArchived)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter includeArchived: %s", err))
+	}
+
+	// ------------- Optional query parameter "units" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "units", ctx.QueryParams(), &params.Units)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter units: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.GetCars(ctx)
+	err = w.Handler.GetCars(ctx, params)
 	return err
 }
 
@@ -46,8 +199,55 @@ func (w *ControllerWrapper) GetCars(ctx echo.Context) error {
 func (w *ControllerWrapper) AddCar(ctx echo.Context) error {
 	var err error
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params AddCarParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.AddCar(ctx, params)
+	return err
+}
+
+// AddCars converts echo context to params.
+func (w *ControllerWrapper) AddCars(ctx echo.Context) error {
+	var err error
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.AddCar(ctx)
+	err = w.Handler.AddCars(ctx)
+	return err
+}
+
+// ExportCars converts echo context to params.
+func (w *ControllerWrapper) ExportCars(ctx echo.Context) error {
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ExportCarsParams
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", ctx.QueryParams(), &params.Format)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter format: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ExportCars(ctx, params)
 	return err
 }
 
@@ -62,8 +262,60 @@ func (w *ControllerWrapper) DeleteCar(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteCarParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-Match", runtime.ParamLocationHeader, valueList[0], &IfMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteCar(ctx, vin, params)
+	return err
+}
+
+// RestoreCar converts echo context to params.
+func (w *ControllerWrapper) RestoreCar(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.DeleteCar(ctx, vin)
+	err = w.Handler.RestoreCar(ctx, vin)
+	return err
+}
+
+// PurgeCar converts echo context to params.
+func (w *ControllerWrapper) PurgeCar(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PurgeCar(ctx, vin)
 	return err
 }
 
@@ -78,8 +330,70 @@ func (w *ControllerWrapper) GetCar(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetCarParams
+	// ------------- Optional query parameter "units" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "units", ctx.QueryParams(), &params.Units)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter units: %s", err))
+	}
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-None-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-None-Match", runtime.ParamLocationHeader, valueList[0], &IfNoneMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-None-Match: %s", err))
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.GetCar(ctx, vin)
+	err = w.Handler.GetCar(ctx, vin, params)
+	return err
+}
+
+// PatchCar converts echo context to params.
+func (w *ControllerWrapper) PatchCar(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchCarParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-Match", runtime.ParamLocationHeader, valueList[0], &IfMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.PatchCar(ctx, vin, params)
 	return err
 }
 
@@ -93,8 +407,221 @@ func (w *ControllerWrapper) ChangeTrunkLockState(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ChangeTrunkLockStateParams
+
+	headers := ctx.Request().Header
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for If-Match, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "If-Match", runtime.ParamLocationHeader, valueList[0], &IfMatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter If-Match: %s", err))
+		}
+
+		params.IfMatch = &IfMatch
+	}
+	// ------------- Optional header parameter "Idempotency-Key" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Idempotency-Key")]; found {
+		var IdempotencyKey string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for Idempotency-Key, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithLocation("simple", false, "Idempotency-Key", runtime.ParamLocationHeader, valueList[0], &IdempotencyKey)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter Idempotency-Key: %s", err))
+		}
+
+		params.IdempotencyKey = &IdempotencyKey
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ChangeTrunkLockState(ctx, vin, params)
+	return err
+}
+
+// ChangeDoorsLockState converts echo context to params.
+func (w *ControllerWrapper) ChangeDoorsLockState(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ChangeDoorsLockState(ctx, vin)
+	return err
+}
+
+// ChangeEngineState converts echo context to params.
+func (w *ControllerWrapper) ChangeEngineState(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ChangeEngineState(ctx, vin)
+	return err
+}
+
+// ChangeDynamicData converts echo context to params.
+func (w *ControllerWrapper) ChangeDynamicData(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ChangeDynamicData(ctx, vin)
+	return err
+}
+
+// GetPositions converts echo context to params.
+func (w *ControllerWrapper) GetPositions(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetPositionsParams
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", ctx.QueryParams(), &params.From)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter from: %s", err))
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", ctx.QueryParams(), &params.To)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter to: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.ChangeTrunkLockState(ctx, vin)
+	err = w.Handler.GetPositions(ctx, vin, params)
+	return err
+}
+
+// GetTrips converts echo context to params.
+func (w *ControllerWrapper) GetTrips(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetTrips(ctx, vin)
+	return err
+}
+
+// GetRange converts echo context to params.
+func (w *ControllerWrapper) GetRange(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetRangeParams
+	// ------------- Optional query parameter "profile" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "profile", ctx.QueryParams(), &params.Profile)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter profile: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetRange(ctx, vin, params)
+	return err
+}
+
+// GetCarEvents converts echo context to params.
+func (w *ControllerWrapper) GetCarEvents(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCarEvents(ctx, vin)
+	return err
+}
+
+// GetFleetEvents converts echo context to params.
+func (w *ControllerWrapper) GetFleetEvents(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetFleetEvents(ctx)
+	return err
+}
+
+// ConnectCar converts echo context to params.
+func (w *ControllerWrapper) ConnectCar(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ConnectCar(ctx, vin)
+	return err
+}
+
+// DecodeVin converts echo context to params.
+func (w *ControllerWrapper) DecodeVin(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "vin" -------------
+	var vin carTypes.VinParam
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "vin", runtime.ParamLocationPath, ctx.Param("vin"), &vin)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter vin: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DecodeVin(ctx, vin)
 	return err
 }
 
@@ -129,9 +656,56 @@ func RegisterHandlersWithBaseURL(router EchoRouter, controller Controller, baseU
 
 	router.GET(baseURL+"/cars", wrapper.GetCars)
 	router.POST(baseURL+"/cars", wrapper.AddCar)
+	// the colon of the custom method is escaped, otherwise echo would treat it as a path parameter
+	router.POST(baseURL+"/cars\\:batch", wrapper.AddCars)
+	router.GET(baseURL+"/cars/export", wrapper.ExportCars)
 	router.DELETE(baseURL+"/cars/:vin", wrapper.DeleteCar)
+	// echo cannot match a custom method after a path parameter, so it is split off the VIN by customMethods
+	router.POST(baseURL+"/cars/:vin", customMethods("vin", map[string]echo.HandlerFunc{
+		"restore": wrapper.RestoreCar,
+		"purge":   wrapper.PurgeCar,
+	}))
 	router.GET(baseURL+"/cars/:vin", wrapper.GetCar)
+	router.PATCH(baseURL+"/cars/:vin", wrapper.PatchCar)
 	router.PUT(baseURL+"/cars/:vin/trunkLock", wrapper.ChangeTrunkLockState)
+	router.PUT(baseURL+"/cars/:vin/doorsLock", wrapper.ChangeDoorsLockState)
+	router.PUT(baseURL+"/cars/:vin/engine", wrapper.ChangeEngineState)
+	router.PUT(baseURL+"/cars/:vin/dynamicData", wrapper.ChangeDynamicData)
+	router.GET(baseURL+"/cars/:vin/positions", wrapper.GetPositions)
+	router.GET(baseURL+"/cars/:vin/trips", wrapper.GetTrips)
+	router.GET(baseURL+"/cars/:vin/range", wrapper.GetRange)
+	router.GET(baseURL+"/cars/:vin/events", wrapper.GetCarEvents)
+	router.GET(baseURL+"/events", wrapper.GetFleetEvents)
+	router.GET(baseURL+"/cars/:vin/ws", wrapper.ConnectCar)
+	router.GET(baseURL+"/vins/:vin/decode", wrapper.DecodeVin)
 
 	return nil
 }
+
+// customMethods routes requests for paths like /cars/{vin}:restore, where a custom method follows the path parameter
+// with the given name. The custom method is removed from the parameter before the request is passed to the handler
+// of the method. Requests without a known custom method are not found.
+func customMethods(param string, handlers map[string]echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		names := ctx.ParamNames()
+		values := ctx.ParamValues()
+		for i, name := range names {
+			if name != param {
+				continue
+			}
+
+			value, method, found := strings.Cut(values[i], ":")
+			handler, known := handlers[method]
+			if !found || !known {
+				break
+			}
+
+			// ParamValues returns the slice echo keeps the values in, so it must not be changed directly
+			values = append([]string{}, values...)
+			values[i] = value
+			ctx.SetParamValues(values...)
+			return handler(ctx)
+		}
+		return echo.ErrNotFound
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCustomMethods(t *testing.T) {
+	router := echo.New()
+	router.POST("/cars/:vin", customMethods("vin", map[string]echo.HandlerFunc{
+		"restore": func(ctx echo.Context) error {
+			return ctx.String(http.StatusOK, "restore "+ctx.Param("vin"))
+		},
+		"purge": func(ctx echo.Context) error {
+			return ctx.String(http.StatusOK, "purge "+ctx.Param("vin"))
+		},
+	}))
+
+	for _, test := range []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/cars/WVWAA71K08W201030:restore", http.StatusOK, "restore WVWAA71K08W201030"},
+		{"/cars/WVWAA71K08W201030:purge", http.StatusOK, "purge WVWAA71K08W201030"},
+		{"/cars/WVWAA71K08W201030:unknown", http.StatusNotFound, ""},
+		{"/cars/WVWAA71K08W201030", http.StatusNotFound, ""},
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, test.path, nil))
+
+		assert.Equal(t, test.expectedCode, recorder.Code, test.path)
+		if test.expectedBody != "" {
+			assert.Equal(t, test.expectedBody, recorder.Body.String(), test.path)
+		}
+	}
+}
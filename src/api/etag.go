@@ -0,0 +1,72 @@
+package api
+
+import (
+	"DCar/logic/units"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// unknownRevision is expected by conditional writes whose ETag was not issued by us, so they never match a car.
+const unknownRevision = -1
+
+// etag returns the strong entity tag of the given revision of a car in the given unit system. The representations
+// of a revision differ between the unit systems, so all but the metric one are marked, e.g. "3-imperial".
+func etag(revision int64, system units.System) string {
+	tag := strconv.FormatInt(revision, 10)
+	if system != units.Metric {
+		tag += "-" + string(system)
+	}
+	return `"` + tag + `"`
+}
+
+// revisionFromIfMatch returns the revision expected by the given If-Match header. Without the header or for "*",
+// which matches every existing car (see carNotFoundError), nil is returned. If-Match uses the strong comparison, so weak and foreign entity
+// tags result in unknownRevision. The unit system of an entity tag is ignored since all representations of a
+// revision describe the same car. Only a single entity tag is supported.
+func revisionFromIfMatch(ifMatch *string) (*int64, error) {
+	if ifMatch == nil || strings.TrimSpace(*ifMatch) == "*" {
+		return nil, nil
+	}
+
+	tag := strings.TrimSpace(*ifMatch)
+	if strings.Contains(tag, ",") {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "If-Match must be * or a single ETag")
+	}
+
+	revision := int64(unknownRevision)
+	if len(tag) > 2 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) {
+		value := strings.TrimSuffix(tag[1:len(tag)-1], "-"+string(units.Imperial))
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && parsed >= 0 {
+			revision = parsed
+		}
+	}
+	return &revision, nil
+}
+
+// carNotFoundError returns the error of a conditional write on a car that does not exist. If-Match "*" only matches
+// an existing car, so the precondition fails instead of the car not being found.
+func carNotFoundError(ifMatch *string) error {
+	if ifMatch != nil && strings.TrimSpace(*ifMatch) == "*" {
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "VIN not found")
+	}
+	return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+}
+
+// matchesIfNoneMatch checks if the given If-None-Match header matches the ETag of the given revision in the given
+// unit system. The header is a comma separated list of entity tags or "*" and is compared weakly.
+func matchesIfNoneMatch(ifNoneMatch *string, revision int64, system units.System) bool {
+	if ifNoneMatch == nil {
+		return false
+	}
+
+	current := etag(revision, system)
+	for _, tag := range strings.Split(*ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"DCar/logic/units"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestRevisionFromIfMatch(t *testing.T) {
+	revision := func(value int64) *int64 {
+		return &value
+	}
+	header := func(value string) *string {
+		return &value
+	}
+
+	for _, test := range []struct {
+		ifMatch  *string
+		expected *int64
+	}{
+		{nil, nil},
+		{header("*"), nil},
+		{header(`"0"`), revision(0)},
+		{header(` "12" `), revision(12)},
+		{header(`"12-imperial"`), revision(12)},
+		{header(`"12-unknown"`), revision(unknownRevision)},
+		{header(`W/"12"`), revision(unknownRevision)},
+		{header(`"abc"`), revision(unknownRevision)},
+		{header(`"-3"`), revision(unknownRevision)},
+		{header(`12`), revision(unknownRevision)},
+	} {
+		result, err := revisionFromIfMatch(test.ifMatch)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, result)
+	}
+}
+
+func TestRevisionFromIfMatch_multipleETags(t *testing.T) {
+	ifMatch := `"12", "13"`
+	_, err := revisionFromIfMatch(&ifMatch)
+	assert.NotNil(t, err)
+}
+
+func TestCarNotFoundError(t *testing.T) {
+	anyTag, tag := " * ", `"3"`
+
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), carNotFoundError(nil))
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), carNotFoundError(&tag))
+	assert.Equal(t, echo.NewHTTPError(http.StatusPreconditionFailed, "VIN not found"), carNotFoundError(&anyTag))
+}
+
+func TestMatchesIfNoneMatch(t *testing.T) {
+	for header, expected := range map[string]bool{
+		`"12"`:        true,
+		`W/"12"`:      true,
+		`"11", "12"`:  true,
+		"*":           true,
+		`"11"`:        false,
+		`"1", "2"`:    false,
+		`"abc"`:       false,
+		`W/"11"`:      false,
+		`"12`:         false,
+		`"11",W/"12"`: true,
+	} {
+		header := header
+		assert.Equal(t, expected, matchesIfNoneMatch(&header, 12, units.Metric), header)
+	}
+	assert.False(t, matchesIfNoneMatch(nil, 12, units.Metric))
+}
+
+func TestMatchesIfNoneMatch_imperial(t *testing.T) {
+	for header, expected := range map[string]bool{
+		`"12-imperial"`:   true,
+		`W/"12-imperial"`: true,
+		"*":               true,
+		`"12"`:            false,
+		`"11-imperial"`:   false,
+	} {
+		header := header
+		assert.Equal(t, expected, matchesIfNoneMatch(&header, 12, units.Imperial), header)
+	}
+}
+
+func TestEtag(t *testing.T) {
+	// the representations in different unit systems must not share an ETag
+	assert.Equal(t, `"12"`, etag(12, units.Metric))
+	assert.Equal(t, `"12-imperial"`, etag(12, units.Imperial))
+}
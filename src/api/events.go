@@ -0,0 +1,70 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"encoding/json"
+	"fmt"
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+// keepAliveInterval is the interval in which a comment is sent on an idle event stream, so that proxies do not
+// close the connection.
+var keepAliveInterval = 15 * time.Second
+
+// streamEvents writes the initial events and all events received from the channel as Server-Sent Events until the
+// channel is closed. Events that do not change the dynamic data of a car compared to the last sent event are
+// skipped.
+func streamEvents(ctx echo.Context, carEvents <-chan model.CarEvent, initialEvents ...model.CarEvent) error {
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	response.WriteHeader(http.StatusOK)
+
+	lastSent := make(map[carTypes.Vin]carTypes.DynamicData)
+	send := func(event model.CarEvent) error {
+		if last, ok := lastSent[event.Vin]; ok && last == event.DynamicData {
+			return nil
+		}
+		lastSent[event.Vin] = event.DynamicData
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(response, "event: dynamicData\ndata: %s\n\n", data)
+		return err
+	}
+
+	for _, event := range initialEvents {
+		if err := send(event); err != nil {
+			return nil
+		}
+	}
+	response.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case event, ok := <-carEvents:
+			if !ok {
+				return nil
+			}
+			err = send(event)
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(response, ": keep-alive\n\n")
+		}
+
+		// a failed write means that the client is gone, the subscription ends with the request context
+		if err != nil {
+			return nil
+		}
+		response.Flush()
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	carTypes "github.com/ccsapp/cargotypes"
+	"io"
+	"strings"
+)
+
+// csvColumns are the columns of the CSV export. Every column is the path of a field in the JSON representation of a
+// car, nested fields are separated by a dot.
+var csvColumns = []string{
+	"vin",
+	"brand",
+	"model",
+	"productionDate",
+	"technicalSpecification.color",
+	"technicalSpecification.consumption.city",
+	"technicalSpecification.consumption.combined",
+	"technicalSpecification.consumption.overland",
+	"technicalSpecification.emissions.city",
+	"technicalSpecification.emissions.combined",
+	"technicalSpecification.emissions.overland",
+	"technicalSpecification.engine.power",
+	"technicalSpecification.engine.type",
+	"technicalSpecification.fuel",
+	"technicalSpecification.fuelCapacity",
+	"technicalSpecification.numberOfDoors",
+	"technicalSpecification.numberOfSeats",
+	"technicalSpecification.tire.manufacturer",
+	"technicalSpecification.tire.type",
+	"technicalSpecification.transmission",
+	"technicalSpecification.trunkVolume",
+	"technicalSpecification.weight",
+	"dynamicData.doorsLockState",
+	"dynamicData.engineState",
+	"dynamicData.fuelLevelPercentage",
+	"dynamicData.position.latitude",
+	"dynamicData.position.longitude",
+	"dynamicData.trunkLockState",
+}
+
+// carWriter writes cars one after another in an export format.
+type carWriter interface {
+	// Write writes a single car.
+	Write(car *carTypes.Car) error
+
+	// Flush writes any buffered data to the underlying writer.
+	Flush() error
+}
+
+// ndjsonWriter writes every car as JSON object on its own line.
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func newNdjsonWriter(w io.Writer) carWriter {
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) Write(car *carTypes.Car) error {
+	// the encoder terminates every value with a newline
+	return n.encoder.Encode(car)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
+
+// csvWriter writes every car as a row with the csvColumns. The header row is written on creation.
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func newCsvWriter(w io.Writer) (carWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer}, nil
+}
+
+func (c *csvWriter) Write(car *carTypes.Car) error {
+	// the JSON representation is used so the columns match the field names of the API exactly
+	encoded, err := json.Marshal(car)
+	if err != nil {
+		return err
+	}
+	// numbers are kept as written, float64 would print large values in exponent notation
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var full map[string]interface{}
+	if err := decoder.Decode(&full); err != nil {
+		return err
+	}
+
+	row := make([]string, len(csvColumns))
+	for i, column := range csvColumns {
+		if value, ok := lookupField(full, strings.Split(column, ".")); ok {
+			row[i] = fmt.Sprint(value)
+		}
+	}
+	return c.writer.Write(row)
+}
+
+func (c *csvWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// lookupField returns the value at the given path in a JSON object.
+func lookupField(object map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := object[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupField(nested, path[1:])
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNdjsonWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := newNdjsonWriter(&buffer)
+
+	otherCar := exampleModelCar
+	otherCar.Vin = "12345678901234568"
+
+	assert.Nil(t, writer.Write(&exampleModelCar))
+	assert.Nil(t, writer.Write(&otherCar))
+	assert.Nil(t, writer.Flush())
+
+	lines := strings.Split(buffer.String(), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"vin":"12345678901234567"`)
+	assert.Contains(t, lines[1], `"vin":"12345678901234568"`)
+	assert.Empty(t, lines[2])
+}
+
+func TestCsvWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := newCsvWriter(&buffer)
+	assert.Nil(t, err)
+
+	car := exampleModelCar
+	car.TechnicalSpecification.Weight = 1000000
+	car.TechnicalSpecification.Engine.Type = "1.6 TDI, 110 kW"
+
+	assert.Nil(t, writer.Write(&car))
+	assert.Nil(t, writer.Flush())
+
+	assert.Equal(t, strings.Join(csvColumns, ",")+"\n"+
+		"12345678901234567,Volkswagen,Golf,2022-12-01,black,6.4,5.2,4.6,120,100,90,110,\"1.6 TDI, 110 kW\","+
+		"ELECTRIC,54.0L;85.2kWh,5,5,GOODYEAR,185/65R15,MANUAL,435,1000000,UNLOCKED,OFF,23,49.0069,8.4037,UNLOCKED\n",
+		buffer.String())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection closed")
+}
+
+func TestCsvWriter_writeError(t *testing.T) {
+	writer, err := newCsvWriter(failingWriter{})
+	assert.Nil(t, err)
+
+	// the rows are buffered, so the error surfaces on flush
+	assert.Nil(t, writer.Write(&exampleModelCar))
+	assert.NotNil(t, writer.Flush())
+}
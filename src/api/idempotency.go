@@ -0,0 +1,108 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"DCar/logic/operations"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+)
+
+// idempotentReplayedHeader marks a response that is replayed for a repeated idempotency key.
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
+// idempotent handles the request with handle at most once per idempotency key. Without a key, the request is simply
+// handled. The repetition of a request with the same key is answered with the response to the first request, a
+// different request with the same key is rejected. Server errors are not stored, so such requests can be retried.
+func (c controller) idempotent(ctx echo.Context, key *string, handle func() error) error {
+	if key == nil {
+		return handle()
+	}
+
+	requestHash, err := hashRequest(ctx.Request())
+	if err != nil {
+		return err
+	}
+
+	replay, err := c.operations.RunIdempotent(ctx.Request().Context(), *key, requestHash,
+		func() (*model.IdempotentResponse, error) {
+			return recordResponse(ctx, handle)
+		})
+
+	var keyError *operations.IdempotencyKeyError
+	if errors.As(err, &keyError) {
+		if keyError.InProgress {
+			return echo.NewHTTPError(http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+		}
+		return echo.NewHTTPError(http.StatusUnprocessableEntity,
+			"the Idempotency-Key has already been used for a different request")
+	}
+	if err != nil || replay == nil {
+		return err
+	}
+
+	header := ctx.Response().Header()
+	for name, values := range replay.Header {
+		header[name] = values
+	}
+	header.Set(idempotentReplayedHeader, "true")
+	ctx.Response().WriteHeader(replay.StatusCode)
+	_, err = ctx.Response().Write(replay.Body)
+	return err
+}
+
+// hashRequest returns a hash of the method, the path and the body of the request to recognize its repetitions. The
+// body is restored, so it can still be bound afterwards.
+func hashRequest(request *http.Request) (string, error) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return "", err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// recordResponse handles the request with handle and returns a copy of the response. Client errors are written to
+// the response like echo would do it, so they are replayed as well. Server errors are returned without a response.
+func recordResponse(ctx echo.Context, handle func() error) (*model.IdempotentResponse, error) {
+	response := ctx.Response()
+	recorder := &bodyRecorder{ResponseWriter: response.Writer}
+	response.Writer = recorder
+	defer func() { response.Writer = recorder.ResponseWriter }()
+
+	err := handle()
+
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) && httpError.Code < http.StatusInternalServerError {
+		ctx.Error(err)
+		err = nil
+	}
+	if err != nil || response.Status >= http.StatusInternalServerError {
+		return nil, err
+	}
+
+	return &model.IdempotentResponse{
+		StatusCode: response.Status,
+		Header:     response.Header().Clone(),
+		Body:       recorder.body.Bytes(),
+	}, nil
+}
+
+// bodyRecorder passes the body of a response on and keeps a copy of it.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
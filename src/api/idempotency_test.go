@@ -0,0 +1,197 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"DCar/logic/operations"
+	"DCar/mocks"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newIdempotencyContext returns a real echo context, so the responses can be recorded.
+func newIdempotencyContext(body string) (echo.Context, *httptest.ResponseRecorder) {
+	request := httptest.NewRequest(http.MethodPost, "/cars", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	return echo.New().NewContext(request, recorder), recorder
+}
+
+// expectExecute lets the mocked operations handle the request and checks the stored response.
+func expectExecute(t *testing.T, mockOperations *mocks.MockIOperations, expected *model.IdempotentResponse,
+	expectedError error) {
+
+	mockOperations.
+		EXPECT().
+		RunIdempotent(gomock.Any(), "key", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ interface{}, _ string, _ string,
+			execute func() (*model.IdempotentResponse, error)) (*model.IdempotentResponse, error) {
+
+			response, err := execute()
+			assert.Equal(t, expected, response)
+			assert.ErrorIs(t, err, expectedError)
+			return nil, err
+		})
+}
+
+func TestController_idempotent_noKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, recorder := newIdempotencyContext("")
+
+	controller := controller{mocks.NewMockIOperations(ctrl)}
+	err := controller.idempotent(ctx, nil, func() error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestController_idempotent_firstRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, recorder := newIdempotencyContext(`{"vin": "12345678901234567"}`)
+	key := "key"
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	expectExecute(t, mockOperations, &model.IdempotentResponse{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": {echo.MIMEApplicationJSONCharsetUTF8}},
+		Body:       []byte("\"12345678901234567\"\n"),
+	}, nil)
+
+	controller := controller{mockOperations}
+	err := controller.idempotent(ctx, &key, func() error {
+		// the body can still be read
+		body, err := io.ReadAll(ctx.Request().Body)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"vin": "12345678901234567"}`, string(body))
+		return ctx.JSON(http.StatusCreated, "12345678901234567")
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "\"12345678901234567\"\n", recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+}
+
+func TestController_idempotent_clientError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, recorder := newIdempotencyContext("")
+	key := "key"
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	expectExecute(t, mockOperations, &model.IdempotentResponse{
+		StatusCode: http.StatusConflict,
+		Header:     http.Header{"Content-Type": {echo.MIMEApplicationJSONCharsetUTF8}},
+		Body:       []byte("{\"message\":\"VIN already exists\"}\n"),
+	}, nil)
+
+	controller := controller{mockOperations}
+	err := controller.idempotent(ctx, &key, func() error {
+		return echo.NewHTTPError(http.StatusConflict, "VIN already exists")
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+}
+
+func TestController_idempotent_serverError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, _ := newIdempotencyContext("")
+	key := "key"
+	handleError := errors.New("handle error")
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	expectExecute(t, mockOperations, nil, handleError)
+
+	controller := controller{mockOperations}
+	err := controller.idempotent(ctx, &key, func() error {
+		return handleError
+	})
+
+	assert.ErrorIs(t, err, handleError)
+}
+
+func TestController_idempotent_replay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, recorder := newIdempotencyContext(`{"vin": "12345678901234567"}`)
+	key := "key"
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockOperations.
+		EXPECT().
+		RunIdempotent(gomock.Any(), "key", gomock.Any(), gomock.Any()).
+		Return(&model.IdempotentResponse{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {echo.MIMEApplicationJSONCharsetUTF8}},
+			Body:       []byte(`"12345678901234567"`),
+		}, nil)
+
+	controller := controller{mockOperations}
+	err := controller.idempotent(ctx, &key, func() error {
+		t.Fatal("the request must not be handled again")
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+	assert.Equal(t, `"12345678901234567"`, recorder.Body.String())
+}
+
+func TestController_idempotent_keyErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := "key"
+
+	for _, test := range []struct {
+		err          error
+		expectedCode int
+	}{
+		{&operations.IdempotencyKeyError{}, http.StatusUnprocessableEntity},
+		{&operations.IdempotencyKeyError{InProgress: true}, http.StatusConflict},
+	} {
+		ctx, _ := newIdempotencyContext("")
+
+		mockOperations := mocks.NewMockIOperations(ctrl)
+		mockOperations.EXPECT().RunIdempotent(gomock.Any(), "key", gomock.Any(), gomock.Any()).Return(nil, test.err)
+
+		controller := controller{mockOperations}
+		err := controller.idempotent(ctx, &key, nil)
+
+		var httpError *echo.HTTPError
+		assert.ErrorAs(t, err, &httpError)
+		assert.Equal(t, test.expectedCode, httpError.Code)
+	}
+}
+
+func TestHashRequest(t *testing.T) {
+	hash := func(method string, path string, body string) string {
+		result, err := hashRequest(httptest.NewRequest(method, path, strings.NewReader(body)))
+		assert.Nil(t, err)
+		return result
+	}
+
+	expected := hash(http.MethodPut, "/cars/12345678901234567/trunkLock", `"LOCKED"`)
+	assert.Equal(t, expected, hash(http.MethodPut, "/cars/12345678901234567/trunkLock", `"LOCKED"`))
+	assert.NotEqual(t, expected, hash(http.MethodPut, "/cars/12345678901234567/trunkLock", `"UNLOCKED"`))
+	assert.NotEqual(t, expected, hash(http.MethodPut, "/cars/12345678901234568/trunkLock", `"LOCKED"`))
+	assert.NotEqual(t, expected, hash(http.MethodPost, "/cars/12345678901234567/trunkLock", `"LOCKED"`))
+}
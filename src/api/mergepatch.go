@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// invalidPatchError is returned if a merge patch results in an invalid car. The message can be presented to the
+// client.
+type invalidPatchError struct {
+	reason error
+}
+
+func (e *invalidPatchError) Error() string {
+	return "the patched car is invalid: " + e.reason.Error()
+}
+
+func (e *invalidPatchError) Unwrap() error {
+	return e.reason
+}
+
+// applyMergePatch applies a JSON merge patch to the static data of the car. The patched static data is validated
+// against the staticCar schema, the dynamic data of the car is left unchanged. If the patched car is invalid, an
+// invalidPatchError is returned. Any other errors are unexpected.
+func applyMergePatch(car *carTypes.Car, patch map[string]interface{}) error {
+	// the patch refers to the JSON representation of the car
+	encoded, err := json.Marshal(car)
+	if err != nil {
+		return err
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return err
+	}
+	delete(document, "dynamicData")
+
+	patched := mergePatch(document, patch)
+	err = validateSchema("staticCar", patched)
+	var schemaError *openapi3.SchemaError
+	if errors.As(err, &schemaError) {
+		return &invalidPatchError{err}
+	}
+	if err != nil {
+		return err
+	}
+
+	if encoded, err = json.Marshal(patched); err != nil {
+		return err
+	}
+	patchedCar := carTypes.Car{DynamicData: car.DynamicData}
+	if err := json.Unmarshal(encoded, &patchedCar); err != nil {
+		return &invalidPatchError{err}
+	}
+
+	*car = patchedCar
+	return nil
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to the target and returns the result. Both values must only
+// consist of the types produced by json.Unmarshal into an interface{}. The target may be modified.
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
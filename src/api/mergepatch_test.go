@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// the examples of RFC 7386, appendix A
+	examples := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, example := range examples {
+		var target, patch interface{}
+		assert.Nil(t, json.Unmarshal([]byte(example[0]), &target))
+		assert.Nil(t, json.Unmarshal([]byte(example[1]), &patch))
+
+		result, err := json.Marshal(mergePatch(target, patch))
+		assert.Nil(t, err)
+		assert.JSONEq(t, example[2], string(result), example)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	car := exampleModelCar
+
+	err := applyMergePatch(&car, map[string]interface{}{
+		"model": "Passat",
+		"technicalSpecification": map[string]interface{}{
+			"consumption": map[string]interface{}{"city": 7.1},
+		},
+	})
+
+	expected := exampleModelCar
+	expected.Model = "Passat"
+	expected.TechnicalSpecification.Consumption.City = 7.1
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, car)
+}
+
+func TestApplyMergePatch_invalidCar(t *testing.T) {
+	car := exampleModelCar
+
+	err := applyMergePatch(&car, map[string]interface{}{
+		"technicalSpecification": map[string]interface{}{"fuel": "COAL"},
+	})
+
+	var patchError *invalidPatchError
+	assert.ErrorAs(t, err, &patchError)
+	var schemaError *openapi3.SchemaError
+	assert.ErrorAs(t, err, &schemaError)
+	assert.Contains(t, err.Error(), "/technicalSpecification/fuel")
+	assert.Equal(t, exampleModelCar, car)
+}
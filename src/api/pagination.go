@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	carTypes "github.com/ccsapp/cargotypes"
+	"net/url"
+	"strconv"
+)
+
+// defaultPageSize is used if a cursor is given without a limit
+const defaultPageSize = 100
+
+var invalidCursorError = errors.New("cursor is invalid")
+
+// encodeCursor returns an opaque cursor that points behind the given VIN. Clients must not rely on its format.
+func encodeCursor(vin carTypes.Vin) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(vin))
+}
+
+// decodeCursor returns the VIN the given cursor points behind or invalidCursorError.
+func decodeCursor(cursor string) (carTypes.Vin, error) {
+	vin, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(vin) == 0 {
+		return "", invalidCursorError
+	}
+	return string(vin), nil
+}
+
+// nextPageLink returns the value of a Link header (RFC 8288) that points to the page starting after next. All
+// other query parameters of the request URL are kept, so the next page is filtered in the same way.
+func nextPageLink(requestUrl *url.URL, next carTypes.Vin, limit int) string {
+	query := requestUrl.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("cursor", encodeCursor(next))
+	return fmt.Sprintf("<%s?%s>; rel=\"next\"", requestUrl.Path, query.Encode())
+}
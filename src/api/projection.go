@@ -0,0 +1,72 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"encoding/json"
+	"fmt"
+	carTypes "github.com/ccsapp/cargotypes"
+	"strings"
+)
+
+// parseFields parses a comma separated list of car fields (see model.CarFields). The VIN is always added so the cars
+// can be identified. If no list is given, nil is returned.
+func parseFields(fieldList *string) ([]string, error) {
+	if fieldList == nil {
+		return nil, nil
+	}
+
+	fields := []string{"vin"}
+	for _, field := range strings.Split(*fieldList, ",") {
+		if !model.IsCarField(field) {
+			return nil, fmt.Errorf("fields contains the unknown field %q", field)
+		}
+		if field != "vin" {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// projectCar returns a JSON object that only contains the given fields of the car. Nested fields are separated by a
+// dot and keep their parent objects.
+func projectCar(car *carTypes.Car, fields []string) (map[string]interface{}, error) {
+	// the JSON representation is used so the field names match the API exactly
+	encoded, err := json.Marshal(car)
+	if err != nil {
+		return nil, err
+	}
+	var full map[string]interface{}
+	if err := json.Unmarshal(encoded, &full); err != nil {
+		return nil, err
+	}
+
+	projected := map[string]interface{}{}
+	for _, field := range fields {
+		copyField(full, projected, strings.Split(field, "."))
+	}
+	return projected, nil
+}
+
+// copyField copies the value at the given path from one JSON object to another and creates missing parent objects.
+func copyField(from map[string]interface{}, to map[string]interface{}, path []string) {
+	value, ok := from[path[0]]
+	if !ok {
+		return
+	}
+
+	if len(path) == 1 {
+		to[path[0]] = value
+		return
+	}
+
+	nestedFrom, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	nestedTo, ok := to[path[0]].(map[string]interface{})
+	if !ok {
+		nestedTo = map[string]interface{}{}
+		to[path[0]] = nestedTo
+	}
+	copyField(nestedFrom, nestedTo, path[1:])
+}
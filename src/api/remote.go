@@ -0,0 +1,180 @@
+package api
+
+import (
+	"DCar/infrastructure/database"
+	"DCar/logic/model"
+	"DCar/logic/operations"
+	"context"
+	"encoding/json"
+	"errors"
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+	"net/http"
+)
+
+// Defines values for RemoteCommandType.
+const (
+	RemoteCommandTrunkLock RemoteCommandType = "trunkLock"
+	RemoteCommandDoorsLock RemoteCommandType = "doorsLock"
+	RemoteCommandEngine    RemoteCommandType = "engine"
+)
+
+// Defines values for RemoteMessageType.
+const (
+	RemoteMessageAcknowledgement RemoteMessageType = "ack"
+	RemoteMessageDynamicData     RemoteMessageType = "dynamicData"
+)
+
+var unknownCommandError = errors.New("unknown command")
+var invalidStateError = errors.New("invalid state for the command")
+
+// RemoteCommandType The command to execute, it corresponds to the REST command with the same name
+type RemoteCommandType string
+
+// RemoteMessageType The type of a message sent by the server
+type RemoteMessageType string
+
+// RemoteCommand A command sent by the client of a remote control session
+type RemoteCommand struct {
+	// Id Chosen by the client to match the acknowledgement to the command
+	Id string `json:"id"`
+
+	// Command The command to execute
+	Command RemoteCommandType `json:"command"`
+
+	// State The requested lock state or engine state
+	State string `json:"state"`
+}
+
+// RemoteAcknowledgement The result of a command of a remote control session
+type RemoteAcknowledgement struct {
+	Type RemoteMessageType `json:"type"`
+
+	// Id The id of the acknowledged command
+	Id string `json:"id"`
+
+	// Success Indicates whether the command was executed
+	Success bool `json:"success"`
+
+	// Error The reason why the command was not executed
+	Error string `json:"error,omitempty"`
+}
+
+// RemoteStateUpdate The dynamic data of the car sent at the start of a remote control session and after every change
+type RemoteStateUpdate struct {
+	Type RemoteMessageType `json:"type"`
+	model.CarEvent
+}
+
+func (c controller) ConnectCar(ctx echo.Context, vin carTypes.VinParam) error {
+	// subscribe before the upgrade, so that an unknown car results in a regular 404 response
+	current, carEvents, err := c.operations.SubscribeCarEvents(ctx.Request().Context(), vin)
+	if database.IsNotFoundError(err) {
+		return echo.NewHTTPError(http.StatusNotFound, "VIN not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	server := websocket.Server{
+		// the API does not restrict the origin of the requests, so non-browser clients without origin are accepted
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			c.serveRemoteSession(ctx, conn, vin, current, carEvents)
+		},
+	}
+	server.ServeHTTP(ctx.Response(), ctx.Request())
+	return nil
+}
+
+// serveRemoteSession sends the state updates of the car and executes the received commands until the client closes
+// the connection. The subscription to the car events ends with the request.
+func (c controller) serveRemoteSession(ctx echo.Context, conn *websocket.Conn, vin carTypes.Vin,
+	current model.CarEvent, carEvents <-chan model.CarEvent) {
+
+	go func() {
+		lastSent := current.DynamicData
+		if err := websocket.JSON.Send(conn, RemoteStateUpdate{RemoteMessageDynamicData, current}); err != nil {
+			return
+		}
+		for event := range carEvents {
+			if event.DynamicData == lastSent {
+				continue
+			}
+			lastSent = event.DynamicData
+			if err := websocket.JSON.Send(conn, RemoteStateUpdate{RemoteMessageDynamicData, event}); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			// the client closed the connection
+			return
+		}
+
+		var command RemoteCommand
+		err := json.Unmarshal(data, &command)
+		if err == nil {
+			err = c.executeRemoteCommand(ctx.Request().Context(), vin, &command)
+		}
+
+		acknowledgement := RemoteAcknowledgement{
+			Type:    RemoteMessageAcknowledgement,
+			Id:      command.Id,
+			Success: err == nil,
+		}
+		if err != nil {
+			acknowledgement.Error = c.remoteErrorMessage(ctx, err)
+		}
+
+		if err := websocket.JSON.Send(conn, acknowledgement); err != nil {
+			return
+		}
+	}
+}
+
+// executeRemoteCommand executes the command using the same business logic as the REST commands.
+func (c controller) executeRemoteCommand(ctx context.Context, vin carTypes.Vin, command *RemoteCommand) error {
+	switch command.Command {
+	case RemoteCommandTrunkLock, RemoteCommandDoorsLock:
+		state := carTypes.DynamicDataLockState(command.State)
+		if state != carTypes.LOCKED && state != carTypes.UNLOCKED {
+			return invalidStateError
+		}
+		if command.Command == RemoteCommandTrunkLock {
+			return c.operations.SetTrunkLockState(ctx, vin, state, nil)
+		}
+		return c.operations.SetDoorsLockState(ctx, vin, state)
+	case RemoteCommandEngine:
+		state := carTypes.DynamicDataEngineState(command.State)
+		if state != carTypes.ON && state != carTypes.OFF {
+			return invalidStateError
+		}
+		return c.operations.SetEngineState(ctx, vin, state)
+	default:
+		return unknownCommandError
+	}
+}
+
+// remoteErrorMessage converts the error of a command to a message for the client. Unexpected errors are logged and
+// not passed to the client.
+func (c controller) remoteErrorMessage(ctx echo.Context, err error) string {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxError), errors.As(err, &typeError):
+		return "invalid command: " + err.Error()
+	case err == unknownCommandError, err == invalidStateError, operations.IsRuleViolationError(err):
+		return err.Error()
+	case database.IsNotFoundError(err):
+		return "VIN not found"
+	default:
+		ctx.Logger().Error(err.Error())
+		return "Internal Server Error"
+	}
+}
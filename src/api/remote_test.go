@@ -0,0 +1,171 @@
+package api
+
+import (
+	"DCar/logic/model"
+	"DCar/logic/operations"
+	"DCar/mocks"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/websocket"
+)
+
+const remoteVin = "12345678901234569"
+
+var remoteCurrentEvent = model.CarEvent{Vin: remoteVin, DynamicData: exampleModelCar.DynamicData}
+
+// startRemoteSession serves the remote control endpoint of a controller using the given operations and connects
+// to it. The initial state update is already received.
+func startRemoteSession(t *testing.T, mockOperations *mocks.MockIOperations) *websocket.Conn {
+	app := echo.New()
+	app.GET("/cars/:vin/ws", func(ctx echo.Context) error {
+		return NewController(mockOperations).ConnectCar(ctx, ctx.Param("vin"))
+	})
+	server := httptest.NewServer(app)
+	t.Cleanup(server.Close)
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/cars/"+remoteVin+"/ws", "",
+		server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	var update RemoteStateUpdate
+	assert.Nil(t, websocket.JSON.Receive(conn, &update))
+	assert.Equal(t, RemoteStateUpdate{RemoteMessageDynamicData, remoteCurrentEvent}, update)
+
+	return conn
+}
+
+// sendRemoteCommand sends the command and returns the acknowledgement.
+func sendRemoteCommand(t *testing.T, conn *websocket.Conn, command string) RemoteAcknowledgement {
+	assert.Nil(t, websocket.Message.Send(conn, command))
+
+	var acknowledgement RemoteAcknowledgement
+	assert.Nil(t, websocket.JSON.Receive(conn, &acknowledgement))
+	return acknowledgement
+}
+
+func TestController_ConnectCar_commands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockOperations.EXPECT().SubscribeCarEvents(gomock.Any(), remoteVin).
+		Return(remoteCurrentEvent, make(chan model.CarEvent), nil)
+	mockOperations.EXPECT().SetTrunkLockState(gomock.Any(), remoteVin, carTypes.UNLOCKED, nil).Return(nil)
+	mockOperations.EXPECT().SetDoorsLockState(gomock.Any(), remoteVin, carTypes.LOCKED).Return(nil)
+	mockOperations.EXPECT().SetEngineState(gomock.Any(), remoteVin, carTypes.ON).Return(nil)
+
+	conn := startRemoteSession(t, mockOperations)
+
+	assert.Equal(t, RemoteAcknowledgement{Type: RemoteMessageAcknowledgement, Id: "1", Success: true},
+		sendRemoteCommand(t, conn, `{"id": "1", "command": "trunkLock", "state": "UNLOCKED"}`))
+	assert.Equal(t, RemoteAcknowledgement{Type: RemoteMessageAcknowledgement, Id: "2", Success: true},
+		sendRemoteCommand(t, conn, `{"id": "2", "command": "doorsLock", "state": "LOCKED"}`))
+	assert.Equal(t, RemoteAcknowledgement{Type: RemoteMessageAcknowledgement, Id: "3", Success: true},
+		sendRemoteCommand(t, conn, `{"id": "3", "command": "engine", "state": "ON"}`))
+}
+
+func TestController_ConnectCar_invalidCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockOperations.EXPECT().SubscribeCarEvents(gomock.Any(), remoteVin).
+		Return(remoteCurrentEvent, make(chan model.CarEvent), nil)
+
+	conn := startRemoteSession(t, mockOperations)
+
+	acknowledgement := sendRemoteCommand(t, conn, `{"id": "1", "command": "horn", "state": "ON"}`)
+	assert.Equal(t, RemoteAcknowledgement{
+		Type:  RemoteMessageAcknowledgement,
+		Id:    "1",
+		Error: unknownCommandError.Error(),
+	}, acknowledgement)
+
+	acknowledgement = sendRemoteCommand(t, conn, `{"id": "2", "command": "engine", "state": "LOCKED"}`)
+	assert.Equal(t, RemoteAcknowledgement{
+		Type:  RemoteMessageAcknowledgement,
+		Id:    "2",
+		Error: invalidStateError.Error(),
+	}, acknowledgement)
+
+	acknowledgement = sendRemoteCommand(t, conn, `not json`)
+	assert.False(t, acknowledgement.Success)
+	assert.True(t, strings.HasPrefix(acknowledgement.Error, "invalid command"))
+}
+
+func TestController_ConnectCar_commandErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ruleViolation := &operations.RuleViolationError{Reason: "rule violated"}
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockOperations.EXPECT().SubscribeCarEvents(gomock.Any(), remoteVin).
+		Return(remoteCurrentEvent, make(chan model.CarEvent), nil)
+	gomock.InOrder(
+		mockOperations.EXPECT().SetEngineState(gomock.Any(), remoteVin, carTypes.ON).Return(ruleViolation),
+		mockOperations.EXPECT().SetEngineState(gomock.Any(), remoteVin, carTypes.ON).Return(mongo.ErrNoDocuments),
+		mockOperations.EXPECT().SetEngineState(gomock.Any(), remoteVin, carTypes.ON).
+			Return(errors.New("operations error")),
+	)
+
+	conn := startRemoteSession(t, mockOperations)
+
+	command := `{"id": "1", "command": "engine", "state": "ON"}`
+	assert.Equal(t, "rule violated", sendRemoteCommand(t, conn, command).Error)
+	assert.Equal(t, "VIN not found", sendRemoteCommand(t, conn, command).Error)
+	// unexpected errors are not passed to the client
+	assert.Equal(t, "Internal Server Error", sendRemoteCommand(t, conn, command).Error)
+}
+
+func TestController_ConnectCar_stateUpdates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	changed := remoteCurrentEvent
+	changed.DynamicData.DoorsLockState = carTypes.LOCKED
+
+	// the unchanged event is not sent again
+	carEvents := make(chan model.CarEvent, 2)
+	carEvents <- remoteCurrentEvent
+	carEvents <- changed
+
+	mockOperations := mocks.NewMockIOperations(ctrl)
+	mockOperations.EXPECT().SubscribeCarEvents(gomock.Any(), remoteVin).Return(remoteCurrentEvent, carEvents, nil)
+
+	conn := startRemoteSession(t, mockOperations)
+
+	var update RemoteStateUpdate
+	assert.Nil(t, websocket.JSON.Receive(conn, &update))
+	assert.Equal(t, RemoteStateUpdate{RemoteMessageDynamicData, changed}, update)
+}
+
+func TestController_ConnectCar_carNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	request := httptest.NewRequest(http.MethodGet, "https://example.com/cars/"+remoteVin+"/ws", nil)
+
+	mockEchoContext := mocks.NewMockContext(ctrl)
+	mockOperations := mocks.NewMockIOperations(ctrl)
+
+	mockEchoContext.EXPECT().Request().Return(request)
+	mockOperations.EXPECT().SubscribeCarEvents(request.Context(), remoteVin).
+		Return(model.CarEvent{}, nil, mongo.ErrNoDocuments)
+
+	controller := NewController(mockOperations)
+	err := controller.ConnectCar(mockEchoContext, remoteVin)
+	assert.Equal(t, echo.NewHTTPError(http.StatusNotFound, "VIN not found"), err)
+}
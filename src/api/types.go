@@ -0,0 +1,139 @@
+package api
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"time"
+)
+
+// UnitsParam defines model for unitsParam.
+type UnitsParam string
+
+// Defines values for UnitsParam.
+const (
+	UnitsParamImperial UnitsParam = "imperial"
+	UnitsParamMetric   UnitsParam = "metric"
+)
+
+// GetCarsParams defines parameters for GetCars.
+type GetCarsParams struct {
+	// Near The location to search around as latitude and longitude separated by a comma
+	Near *string `form:"near,omitempty" json:"near,omitempty"`
+
+	// Radius The radius around the location in meters
+	Radius *float64 `form:"radius,omitempty" json:"radius,omitempty"`
+
+	// Limit The maximum number of VINs to return
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Cursor The position to continue from as returned in the Link header of the previous page
+	Cursor *string `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// Brand Only return cars of this brand
+	Brand *string `form:"brand,omitempty" json:"brand,omitempty"`
+
+	// Model Only return cars of this model
+	Model *string `form:"model,omitempty" json:"model,omitempty"`
+
+	// Fuel Only return cars with this fuel
+	Fuel *carTypes.TechnicalSpecificationFuel `form:"fuel,omitempty" json:"fuel,omitempty"`
+
+	// Transmission Only return cars with this transmission
+	Transmission *carTypes.TechnicalSpecificationTransmission `form:"transmission,omitempty" json:"transmission,omitempty"`
+
+	// MinSeats Only return cars with at least this number of seats
+	MinSeats *int `form:"minSeats,omitempty" json:"minSeats,omitempty"`
+
+	// MinTrunkVolume Only return cars with at least this trunk volume in liters
+	MinTrunkVolume *int `form:"minTrunkVolume,omitempty" json:"minTrunkVolume,omitempty"`
+
+	// Color Only return cars of this color
+	Color *string `form:"color,omitempty" json:"color,omitempty"`
+
+	// Expand Return car objects instead of VINs
+	Expand *bool `form:"expand,omitempty" json:"expand,omitempty"`
+
+	// Fields Return car objects that only contain the VIN and these comma separated fields
+	Fields *string `form:"fields,omitempty" json:"fields,omitempty"`
+
+	// IncludeArchived Also return archived cars
+	IncludeArchived *bool `form:"includeArchived,omitempty" json:"includeArchived,omitempty"`
+
+	// Units The unit system of the returned car objects
+	Units *UnitsParam `form:"units,omitempty" json:"units,omitempty"`
+}
+
+// GetPositionsParams defines parameters for GetPositions.
+type GetPositionsParams struct {
+	// From Only return positions recorded at or after this time
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To Only return positions recorded at or before this time
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+}
+
+// AddCarParams defines parameters for AddCar.
+type AddCarParams struct {
+	// IdempotencyKey Replay the response of the first request with this key instead of adding the car again
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// DeleteCarParams defines parameters for DeleteCar.
+type DeleteCarParams struct {
+	// IfMatch Only delete the car if it still has this ETag
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
+// GetCarParams defines parameters for GetCar.
+type GetCarParams struct {
+	// IfNoneMatch Only return the car if it no longer has any of these ETags
+	IfNoneMatch *string `json:"If-None-Match,omitempty"`
+
+	// Units The unit system of the returned car
+	Units *UnitsParam `form:"units,omitempty" json:"units,omitempty"`
+}
+
+// PatchCarParams defines parameters for PatchCar.
+type PatchCarParams struct {
+	// IfMatch Only change the car if it still has this ETag
+	IfMatch *string `json:"If-Match,omitempty"`
+}
+
+// ChangeTrunkLockStateParams defines parameters for ChangeTrunkLockState.
+type ChangeTrunkLockStateParams struct {
+	// IfMatch Only change the car if it still has this ETag
+	IfMatch *string `json:"If-Match,omitempty"`
+
+	// IdempotencyKey Replay the response of the first request with this key instead of changing the state again
+	IdempotencyKey *string `json:"Idempotency-Key,omitempty"`
+}
+
+// GetRangeParams defines parameters for GetRange.
+type GetRangeParams struct {
+	// Profile The driving profile whose consumption is used for the estimate
+	Profile *GetRangeParamsProfile `form:"profile,omitempty" json:"profile,omitempty"`
+}
+
+// GetRangeParamsProfile defines parameters for GetRange.
+type GetRangeParamsProfile string
+
+// Defines values for GetRangeParamsProfile.
+const (
+	GetRangeParamsProfileCity     GetRangeParamsProfile = "city"
+	GetRangeParamsProfileCombined GetRangeParamsProfile = "combined"
+	GetRangeParamsProfileOverland GetRangeParamsProfile = "overland"
+)
+
+// ExportCarsParams defines parameters for ExportCars.
+type ExportCarsParams struct {
+	// Format The format of the export
+	Format *ExportCarsParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// ExportCarsParamsFormat defines parameters for ExportCars.
+type ExportCarsParamsFormat string
+
+// Defines values for ExportCarsParamsFormat.
+const (
+	ExportCarsParamsFormatCsv    ExportCarsParamsFormat = "csv"
+	ExportCarsParamsFormatNdjson ExportCarsParamsFormat = "ndjson"
+)
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"DCar/logic/units"
+	carTypes "github.com/ccsapp/cargotypes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// fuelField is read in addition to the requested fields of a projection if the cars are converted, because the unit
+// of the consumption depends on the fuel.
+const fuelField = "technicalSpecification.fuel"
+
+// unitCar is a car together with the units of its converted fields.
+type unitCar struct {
+	carTypes.Car
+	Units units.Units `json:"units,omitempty"`
+}
+
+// unitSystem returns the unit system a client requested. The units query parameter takes precedence over a profile
+// parameter of the media types in the Accept header (e.g. "application/json; profile=imperial"). By default, the
+// metric system is used.
+func unitSystem(request *http.Request, param *UnitsParam) units.System {
+	if param != nil {
+		return units.System(*param)
+	}
+
+	for _, mediaRange := range strings.Split(request.Header.Get("Accept"), ",") {
+		_, mediaParams, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		switch units.System(mediaParams["profile"]) {
+		case units.Imperial:
+			return units.Imperial
+		case units.Metric:
+			return units.Metric
+		}
+	}
+	return units.Metric
+}
+
+// projectUnits returns the units that belong to the given fields of a projection. A unit belongs to a field if it
+// is the unit of the field itself, of one of its nested fields or of one of its parents.
+func projectUnits(carUnits units.Units, fields []string) units.Units {
+	projected := units.Units{}
+	for field, unit := range carUnits {
+		for _, projectedField := range fields {
+			if field == projectedField || strings.HasPrefix(field, projectedField+".") ||
+				strings.HasPrefix(projectedField, field+".") {
+				projected[field] = unit
+				break
+			}
+		}
+	}
+	return projected
+}
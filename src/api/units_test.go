@@ -0,0 +1,51 @@
+package api
+
+import (
+	"DCar/logic/units"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestUnitSystem(t *testing.T) {
+	param := func(value UnitsParam) *UnitsParam {
+		return &value
+	}
+
+	for _, test := range []struct {
+		param    *UnitsParam
+		accept   string
+		expected units.System
+	}{
+		{nil, "", units.Metric},
+		{nil, "application/json", units.Metric},
+		{nil, "application/json; profile=imperial", units.Imperial},
+		{nil, `text/html, application/json;profile="imperial"`, units.Imperial},
+		{nil, "application/json; profile=metric, */*; profile=imperial", units.Metric},
+		{nil, "application/json; profile=nautical", units.Metric},
+		{nil, "invalid;;, application/json; profile=imperial", units.Imperial},
+		{param(UnitsParamImperial), "", units.Imperial},
+		{param(UnitsParamMetric), "application/json; profile=imperial", units.Metric},
+	} {
+		request, _ := http.NewRequest("GET", "https://example.com/cars", nil)
+		request.Header.Set("Accept", test.accept)
+
+		assert.Equal(t, test.expected, unitSystem(request, test.param), test.accept)
+	}
+}
+
+func TestProjectUnits(t *testing.T) {
+	carUnits := units.Units{
+		"technicalSpecification.weight":       "lb",
+		"technicalSpecification.engine.power": "hp",
+		"technicalSpecification.consumption":  "mpg",
+	}
+
+	assert.Equal(t, carUnits, projectUnits(carUnits, []string{"vin", "technicalSpecification"}))
+	assert.Equal(t, units.Units{"technicalSpecification.engine.power": "hp"},
+		projectUnits(carUnits, []string{"vin", "technicalSpecification.engine"}))
+	assert.Equal(t, units.Units{"technicalSpecification.consumption": "mpg"},
+		projectUnits(carUnits, []string{"vin", "brand", "technicalSpecification.consumption"}))
+	assert.Empty(t, projectUnits(carUnits, []string{"vin", "technicalSpecification.weightless"}))
+	assert.Empty(t, projectUnits(nil, []string{"vin", "technicalSpecification"}))
+}
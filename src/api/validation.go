@@ -1,19 +1,48 @@
 package api
 
 import (
+	"DCar/logic/validation"
 	_ "embed"
+	"errors"
+	"fmt"
 	"github.com/deepmap/oapi-codegen/pkg/middleware"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+	"sync"
 )
 
 //go:embed openapi.yaml
 var openApiData []byte
 
+// mergePatchContentType is the media type of a JSON merge patch (RFC 7386)
+const mergePatchContentType = "application/merge-patch+json"
+
+var loadedOpenApi struct {
+	once    sync.Once
+	swagger *openapi3.T
+	err     error
+}
+
+func init() {
+	// a merge patch is plain JSON, but the validator only knows the decoders of some JSON media types
+	openapi3filter.RegisterBodyDecoder(mergePatchContentType, openapi3filter.RegisteredBodyDecoder("application/json"))
+}
+
+// loadOpenApi returns the parsed OpenAPI specification of D-Car. It is only parsed once.
+func loadOpenApi() (*openapi3.T, error) {
+	loadedOpenApi.once.Do(func() {
+		loadedOpenApi.swagger, loadedOpenApi.err = openapi3.NewLoader().LoadFromData(openApiData)
+	})
+	return loadedOpenApi.swagger, loadedOpenApi.err
+}
+
 // AddOpenApiValidationMiddleware adds validation middleware to the echo server. It uses the OpenAPI specification of
 // D-Car to validate API requests.
 func AddOpenApiValidationMiddleware(e *echo.Echo) error {
-	swagger, err := openapi3.NewLoader().LoadFromData(openApiData)
+	swagger, err := loadOpenApi()
 	if err != nil {
 		return err
 	}
@@ -22,3 +51,50 @@ func AddOpenApiValidationMiddleware(e *echo.Echo) error {
 
 	return nil
 }
+
+// schemaViolationError is a violation of a schema in the OpenAPI specification of D-Car. Its message names the
+// invalid property and can be presented to the client, the default message of a schema error contains the whole
+// schema and value. It wraps the *openapi3.SchemaError.
+type schemaViolationError struct {
+	schemaError *openapi3.SchemaError
+}
+
+func (e *schemaViolationError) Error() string {
+	return fmt.Sprintf("property \"/%s\": %s", strings.Join(e.schemaError.JSONPointer(), "/"), e.schemaError.Reason)
+}
+
+func (e *schemaViolationError) Unwrap() error {
+	return e.schemaError
+}
+
+// validateSchema validates a decoded JSON value against the schema with the given name in the OpenAPI specification
+// of D-Car. The value must only consist of the types produced by json.Unmarshal into an interface{}. A violation of
+// the schema is returned as schemaViolationError. Any other errors are unexpected.
+func validateSchema(schemaName string, value interface{}) error {
+	swagger, err := loadOpenApi()
+	if err != nil {
+		return err
+	}
+
+	err = swagger.Components.Schemas[schemaName].Value.VisitJSON(value)
+
+	var schemaError *openapi3.SchemaError
+	if errors.As(err, &schemaError) {
+		return &schemaViolationError{schemaError}
+	}
+	return err
+}
+
+// violationsMessage is the body of the response to an implausible car. It lists every violated rule.
+type violationsMessage struct {
+	Message    string                 `json:"message"`
+	Violations []validation.Violation `json:"violations"`
+}
+
+// newViolationsError creates the error that is returned to the client for an implausible car.
+func newViolationsError(validationError *validation.Error) *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusUnprocessableEntity, violationsMessage{
+		Message:    "The car is implausible.",
+		Violations: validationError.Violations,
+	})
+}
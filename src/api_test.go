@@ -1,27 +1,41 @@
 package main
 
 import (
+	"DCar/api"
 	"DCar/environment"
 	"DCar/infrastructure/database"
 	"DCar/infrastructure/database/db"
+	"DCar/logic/events"
+	"DCar/logic/model"
 	"DCar/testdata"
 	"DCar/testhelpers"
+	"bufio"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	carTypes "github.com/ccsapp/cargotypes"
 	"github.com/labstack/echo/v4"
 	"github.com/steinfletcher/apitest"
 	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/net/websocket"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
 
 type ApiTestSuite struct {
 	suite.Suite
-	dbConnection       db.IConnection
-	collection         string
-	app                *echo.Echo
-	recordingFormatter *testhelpers.RecordingFormatter
+	dbConnection          db.IConnection
+	collection            string
+	positionsCollection   string
+	idempotencyCollection string
+	app                   *echo.Echo
+	recordingFormatter    *testhelpers.RecordingFormatter
 }
 
 func (suite *ApiTestSuite) SetupSuite() {
@@ -29,6 +43,8 @@ func (suite *ApiTestSuite) SetupSuite() {
 	collectionPrefix := fmt.Sprintf("test-%d-", time.Now().Unix())
 	environment.GetEnvironment().SetAppCollectionPrefix(collectionPrefix)
 	suite.collection = collectionPrefix + database.CarsCollectionBaseName
+	suite.positionsCollection = collectionPrefix + database.PositionsCollectionBaseName
+	suite.idempotencyCollection = collectionPrefix + database.IdempotencyCollectionBaseName
 
 	// create a new database connection
 	dbConnection, err := db.NewDbConnection(environment.GetEnvironment())
@@ -38,7 +54,7 @@ func (suite *ApiTestSuite) SetupSuite() {
 
 	suite.dbConnection = dbConnection
 
-	app, err := newApp(dbConnection)
+	app, err := newApp(dbConnection, events.NewBroker())
 	if err != nil {
 		suite.T().Fatal(err.Error())
 	}
@@ -83,9 +99,11 @@ func (suite *ApiTestSuite) TearDownTest() {
 	diagramFormatter := apitest.SequenceDiagram()
 	diagramFormatter.Format(suite.recordingFormatter.GetRecorder())
 
-	// clear the collection after each test
-	if err := suite.dbConnection.DropCollection(context.Background(), suite.collection); err != nil {
-		suite.T().Fatal(err)
+	// clear the collections after each test, the indexes created by newApp are kept
+	for _, collection := range []string{suite.collection, suite.positionsCollection, suite.idempotencyCollection} {
+		if _, err := suite.dbConnection.DeleteMany(context.Background(), collection, bson.D{}); err != nil {
+			suite.T().Fatal(err)
+		}
 	}
 }
 
@@ -100,51 +118,100 @@ func (suite *ApiTestSuite) newApiTest() *apitest.APITest {
 		Report(suite.recordingFormatter)
 }
 
-func (suite *ApiTestSuite) TestVinOverview_empty() {
+func (suite *ApiTestSuite) TestVinOverview_near() {
+	// the first car is moved to Berlin, the second one stays in Karlsruhe
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar2).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/dynamicData").
+		JSON(testdata.ExampleDynamicData).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
 	suite.newApiTest().
 		Get("/cars").
+		Query("near", "49.0069,8.4037").
+		Query("radius", "1000").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body("[]").
+		Body("[" + testdata.ExampleCar2Vin + "]").
 		End()
-}
 
-func (suite *ApiTestSuite) TestGetCar_invalidFormat() {
 	suite.newApiTest().
-		Get("/cars/abc").
+		Get("/cars").
+		Query("near", "49.0069,8.4037").
+		Query("radius", "1000000").
 		Expect(suite.T()).
-		Status(http.StatusBadRequest).
+		Status(http.StatusOK).
+		Body("[" + testdata.ExampleCar2Vin + "," + testdata.ExampleCarVin + "]").
 		End()
-}
 
-func (suite *ApiTestSuite) TestAddCar_success() {
-	// add the example car to the database
 	suite.newApiTest().
-		Post("/cars").
-		JSON(testdata.ExampleCar).
+		Get("/cars").
+		Query("near", "52.52,13.405").
+		Query("radius", "1000000").
 		Expect(suite.T()).
-		Status(http.StatusCreated).
-		Body(testdata.ExampleCarVin).
+		Status(http.StatusOK).
+		Body("[" + testdata.ExampleCarVin + "," + testdata.ExampleCar2Vin + "]").
 		End()
+}
 
+func (suite *ApiTestSuite) TestVinOverview_nearEmpty() {
 	suite.newApiTest().
 		Get("/cars").
+		Query("near", "49.0069,8.4037").
+		Query("radius", "1000").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarVinArray).
+		Body("[]").
 		End()
+}
 
-	// validate that the car was added to the database
+func (suite *ApiTestSuite) TestVinOverview_nearWithoutRadius() {
 	suite.newApiTest().
-		Get("/cars/" + testdata.ExampleCarVinString).
+		Get("/cars").
+		Query("near", "49.0069,8.4037").
 		Expect(suite.T()).
-		Status(http.StatusOK).
-		Body(testdata.ExampleCarWithDynamicData).
+		Status(http.StatusBadRequest).
 		End()
 }
 
-func (suite *ApiTestSuite) TestAddCar_duplicate() {
-	// add the example car to the database
+func (suite *ApiTestSuite) TestVinOverview_nearInvalidLocation() {
+	for _, near := range []string{"Karlsruhe", "91,8.4037", "49.0069;8.4037"} {
+		suite.newApiTest().
+			Get("/cars").
+			Query("near", near).
+			Query("radius", "1000").
+			Expect(suite.T()).
+			Status(http.StatusBadRequest).
+			End()
+	}
+}
+
+func (suite *ApiTestSuite) TestVinOverview_nearNegativeRadius() {
+	suite.newApiTest().
+		Get("/cars").
+		Query("near", "49.0069,8.4037").
+		Query("radius", "-1").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestVinOverview_pages() {
 	suite.newApiTest().
 		Post("/cars").
 		JSON(testdata.ExampleCar).
@@ -152,82 +219,157 @@ func (suite *ApiTestSuite) TestAddCar_duplicate() {
 		Status(http.StatusCreated).
 		End()
 
-	// try to add another car with the same VIN
 	suite.newApiTest().
 		Post("/cars").
-		JSON(testdata.ExampleCarDuplicate).
+		JSON(testdata.ExampleCar2).
 		Expect(suite.T()).
-		Status(http.StatusConflict).
+		Status(http.StatusCreated).
 		End()
 
-	// validate that the original car did not change
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(testdata.ExampleCarVinString))
+
 	suite.newApiTest().
-		Get("/cars/" + testdata.ExampleCarVinString).
+		Get("/cars").
+		Query("limit", "1").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarWithDynamicData).
+		Header("Link", "</cars?cursor="+cursor+"&limit=1>; rel=\"next\"").
+		Body(testdata.ExampleCarVinArray).
 		End()
 
-	// validate that the VIN does only appear once in the overview
 	suite.newApiTest().
 		Get("/cars").
+		Query("limit", "1").
+		Query("cursor", cursor).
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarVinArray).
+		HeaderNotPresent("Link").
+		Body("[" + testdata.ExampleCar2Vin + "]").
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Query("limit", "2").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		HeaderNotPresent("Link").
+		Body("[" + testdata.ExampleCarVin + "," + testdata.ExampleCar2Vin + "]").
 		End()
 }
 
-func (suite *ApiTestSuite) TestAddCar_invalidJson() {
+func (suite *ApiTestSuite) TestVinOverview_pageInvalid() {
+	for _, query := range [][2]string{{"limit", "0"}, {"limit", "1001"}, {"cursor", "no cursor"}, {"cursor", "Q"}} {
+		suite.newApiTest().
+			Get("/cars").
+			Query(query[0], query[1]).
+			Expect(suite.T()).
+			Status(http.StatusBadRequest).
+			End()
+	}
+
 	suite.newApiTest().
-		Post("/cars").
-		JSON(testdata.ExampleNoJson).
+		Get("/cars").
+		Query("limit", "10").
+		Query("near", "49.0069,8.4037").
+		Query("radius", "1000").
 		Expect(suite.T()).
 		Status(http.StatusBadRequest).
 		End()
-
-	suite.TestVinOverview_empty()
 }
 
-func (suite *ApiTestSuite) TestAddCar_invalidCar() {
+func (suite *ApiTestSuite) TestVinOverview_filter() {
 	suite.newApiTest().
 		Post("/cars").
-		JSON(testdata.ExampleNoCar).
+		JSON(testdata.ExampleCar).
 		Expect(suite.T()).
-		Status(http.StatusBadRequest).
+		Status(http.StatusCreated).
 		End()
 
-	suite.TestVinOverview_empty()
-}
-
-func (suite *ApiTestSuite) TestAddCar_invalidCarEnums() {
 	suite.newApiTest().
 		Post("/cars").
-		JSON(testdata.ExampleCarWrongEnum).
+		JSON(testdata.ExampleCar2).
 		Expect(suite.T()).
-		Status(http.StatusBadRequest).
+		Status(http.StatusCreated).
 		End()
 
-	suite.TestVinOverview_empty()
-}
+	both := "[" + testdata.ExampleCarVin + "," + testdata.ExampleCar2Vin + "]"
 
-func (suite *ApiTestSuite) TestRemoveCar_noSuchCar() {
+	for _, test := range []struct {
+		query    map[string]string
+		expected string
+	}{
+		{map[string]string{"brand": "audi", "color": "BLACK"}, both},
+		{map[string]string{"model": "A4"}, "[" + testdata.ExampleCar2Vin + "]"},
+		{map[string]string{"model": "A"}, "[]"},
+		{map[string]string{"minSeats": "7", "transmission": "MANUAL", "fuel": "ELECTRIC"}, testdata.ExampleCarVinArray},
+		{map[string]string{"minSeats": "7", "fuel": "DIESEL"}, "[]"},
+		{map[string]string{"transmission": "AUTOMATIC"}, "[]"},
+		{map[string]string{"minTrunkVolume": "435"}, both},
+		{map[string]string{"minTrunkVolume": "436"}, "[]"},
+		{map[string]string{"fuel": "DIESEL", "near": "49.0069,8.4037", "radius": "1000"},
+			"[" + testdata.ExampleCar2Vin + "]"},
+	} {
+		suite.newApiTest().
+			Get("/cars").
+			QueryParams(test.query).
+			Expect(suite.T()).
+			Status(http.StatusOK).
+			Body(test.expected).
+			End()
+	}
+
+	// the filter is applied to every page
 	suite.newApiTest().
-		Delete("/cars/" + testdata.ExampleCarVinString).
+		Get("/cars").
+		Query("fuel", "DIESEL").
+		Query("limit", "1").
 		Expect(suite.T()).
-		Status(http.StatusNotFound).
+		Status(http.StatusOK).
+		HeaderNotPresent("Link").
+		Body("[" + testdata.ExampleCar2Vin + "]").
 		End()
 }
 
-func (suite *ApiTestSuite) TestRemoveCar_invalidFormat() {
+func (suite *ApiTestSuite) TestVinOverview_filterInvalid() {
+	for _, query := range [][2]string{{"fuel", "COAL"}, {"transmission", "CVT"}, {"minSeats", "-1"},
+		{"minTrunkVolume", "large"}} {
+
+		suite.newApiTest().
+			Get("/cars").
+			Query(query[0], query[1]).
+			Expect(suite.T()).
+			Status(http.StatusBadRequest).
+			End()
+	}
+}
+
+func (suite *ApiTestSuite) TestVinOverview_expand() {
 	suite.newApiTest().
-		Delete("/cars/xyz").
+		Post("/cars").
+		JSON(testdata.ExampleCar).
 		Expect(suite.T()).
-		Status(http.StatusBadRequest).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Query("expand", "true").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(testdata.ArrayString(testdata.ExampleCarWithDynamicData)).
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Query("expand", "true").
+		Query("fuel", "DIESEL").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("[]").
 		End()
 }
 
-func (suite *ApiTestSuite) TestRemoveCar_success() {
-	// add two cars
+func (suite *ApiTestSuite) TestVinOverview_fields() {
 	suite.newApiTest().
 		Post("/cars").
 		JSON(testdata.ExampleCar).
@@ -242,79 +384,107 @@ func (suite *ApiTestSuite) TestRemoveCar_success() {
 		Status(http.StatusCreated).
 		End()
 
-	// verify that both cars exist
 	suite.newApiTest().
 		Get("/cars").
+		Query("fields", "model,dynamicData.position").
+		Query("limit", "1").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ArrayString(testdata.ExampleCarVin, testdata.ExampleCar2Vin)).
+		Body(`[{"vin": ` + testdata.ExampleCarVin + `, "model": "A3",
+			"dynamicData": {"position": {"latitude": 49.0069, "longitude": 8.4037}}}]`).
 		End()
 
-	// remove one car
 	suite.newApiTest().
-		Delete("/cars/" + testdata.ExampleCar2VinString).
+		Get("/cars").
+		Query("fields", "model").
+		Query("near", "49.0069,8.4037").
+		Query("radius", "1000").
+		Query("fuel", "DIESEL").
 		Expect(suite.T()).
-		Status(http.StatusNoContent).
+		Status(http.StatusOK).
+		Body(`[{"vin": ` + testdata.ExampleCar2Vin + `, "model": "A4"}]`).
 		End()
 
-	// verify that the car was removed
+	for _, fields := range []string{"owner", "brand,,model", "dynamicData.position.latitude"} {
+		suite.newApiTest().
+			Get("/cars").
+			Query("fields", fields).
+			Expect(suite.T()).
+			Status(http.StatusBadRequest).
+			End()
+	}
+}
+
+func (suite *ApiTestSuite) TestVinOverview_empty() {
 	suite.newApiTest().
-		Get("/cars/" + testdata.ExampleCar2VinString).
+		Get("/cars").
 		Expect(suite.T()).
-		Status(http.StatusNotFound).
+		Status(http.StatusOK).
+		Body("[]").
 		End()
+}
 
-	// verify that the other car is still there
+func (suite *ApiTestSuite) TestGetCar_invalidFormat() {
 	suite.newApiTest().
-		Get("/cars/" + testdata.ExampleCarVinString).
+		Get("/cars/abc").
 		Expect(suite.T()).
-		Status(http.StatusOK).
-		Body(testdata.ExampleCarWithDynamicData).
+		Status(http.StatusBadRequest).
 		End()
+}
 
+func (suite *ApiTestSuite) TestDecodeVin_success() {
+	// the VIN does not need to belong to a car
 	suite.newApiTest().
-		Get("/cars").
+		Get("/vins/" + testdata.ExampleCarVinString + "/decode").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarVinArray).
+		Body(exampleDecodedVin).
 		End()
-}
 
-func (suite *ApiTestSuite) TestChangeTrunkLockState_noSuchCar() {
 	suite.newApiTest().
-		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
-		JSON(testdata.QuoteString("UNLOCKED")).
+		Get("/vins/1HGCM82633A004352/decode").
 		Expect(suite.T()).
-		Status(http.StatusNotFound).
+		Status(http.StatusOK).
+		Body(`{"wmi": "1HG", "manufacturer": "Honda", "modelYearCode": "3", "modelYears": [2003], "plantCode": "A"}`).
 		End()
 }
 
-func (suite *ApiTestSuite) TestChangeTrunkLockState_invalidVinFormat() {
+func (suite *ApiTestSuite) TestDecodeVin_invalidFormat() {
 	suite.newApiTest().
-		Put("/cars/xyz/trunkLock").
-		JSON(testdata.QuoteString("UNLOCKED")).
+		Get("/vins/invalid/decode").
 		Expect(suite.T()).
 		Status(http.StatusBadRequest).
 		End()
 }
 
-func (suite *ApiTestSuite) TestChangeTrunkLockState_invalidLockState() {
+func (suite *ApiTestSuite) TestAddCar_success() {
+	// add the example car to the database
 	suite.newApiTest().
 		Post("/cars").
 		JSON(testdata.ExampleCar).
 		Expect(suite.T()).
 		Status(http.StatusCreated).
+		Body(testdata.ExampleCarVin).
 		End()
 
 	suite.newApiTest().
-		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
-		JSON(testdata.QuoteString("xyz")).
+		Get("/cars").
 		Expect(suite.T()).
-		Status(http.StatusBadRequest).
+		Status(http.StatusOK).
+		Body(testdata.ExampleCarVinArray).
+		End()
+
+	// validate that the car was added to the database
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
 		End()
 }
 
-func (suite *ApiTestSuite) TestChangeTrunkLockState_successUnchanged() {
+func (suite *ApiTestSuite) TestAddCar_duplicate() {
+	// add the example car to the database
 	suite.newApiTest().
 		Post("/cars").
 		JSON(testdata.ExampleCar).
@@ -322,54 +492,1844 @@ func (suite *ApiTestSuite) TestChangeTrunkLockState_successUnchanged() {
 		Status(http.StatusCreated).
 		End()
 
+	// try to add another car with the same VIN
 	suite.newApiTest().
-		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
-		JSON(testdata.QuoteString("LOCKED")).
+		Post("/cars").
+		JSON(testdata.ExampleCarDuplicate).
 		Expect(suite.T()).
-		Status(http.StatusNoContent).
+		Status(http.StatusConflict).
 		End()
 
+	// validate that the original car did not change
 	suite.newApiTest().
 		Get("/cars/" + testdata.ExampleCarVinString).
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarWithDynamicData).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+
+	// validate that the VIN does only appear once in the overview
+	suite.newApiTest().
+		Get("/cars").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(testdata.ExampleCarVinArray).
 		End()
 }
 
-func (suite *ApiTestSuite) TestChangeTrunkLockState_successChanged() {
+func (suite *ApiTestSuite) TestAddCar_idempotencyKey() {
 	suite.newApiTest().
 		Post("/cars").
+		Header("Idempotency-Key", "add-example-car").
 		JSON(testdata.ExampleCar).
 		Expect(suite.T()).
 		Status(http.StatusCreated).
+		Body(testdata.ExampleCarVin).
+		HeaderNotPresent("Idempotent-Replayed").
 		End()
 
+	// the retry gets the original response instead of a conflict
 	suite.newApiTest().
-		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
-		JSON(testdata.QuoteString("UNLOCKED")).
+		Post("/cars").
+		Header("Idempotency-Key", "add-example-car").
+		JSON(testdata.ExampleCar).
 		Expect(suite.T()).
-		Status(http.StatusNoContent).
+		Status(http.StatusCreated).
+		Body(testdata.ExampleCarVin).
+		Header("Idempotent-Replayed", "true").
 		End()
 
+	// a request without the key is handled as usual
 	suite.newApiTest().
-		Get("/cars/" + testdata.ExampleCarVinString).
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarUnlockedTrunk).
+		Body(testdata.ExampleCarVinArray).
 		End()
+}
 
+func (suite *ApiTestSuite) TestAddCar_idempotencyKeyReused() {
 	suite.newApiTest().
-		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
-		JSON(testdata.QuoteString("LOCKED")).
+		Post("/cars").
+		Header("Idempotency-Key", "add-car").
+		JSON(testdata.ExampleCar).
 		Expect(suite.T()).
-		Status(http.StatusNoContent).
+		Status(http.StatusCreated).
 		End()
 
 	suite.newApiTest().
-		Get("/cars/" + testdata.ExampleCarVinString).
+		Post("/cars").
+		Header("Idempotency-Key", "add-car").
+		JSON(testdata.ExampleCar2).
+		Expect(suite.T()).
+		Status(http.StatusUnprocessableEntity).
+		End()
+
+	// validate that the second car was not added
+	suite.newApiTest().
+		Get("/cars").
 		Expect(suite.T()).
 		Status(http.StatusOK).
-		Body(testdata.ExampleCarWithDynamicData).
+		Body(testdata.ExampleCarVinArray).
+		End()
+}
+
+func (suite *ApiTestSuite) TestAddCar_idempotencyKeyClientError() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Post("/cars").
+		Header("Idempotency-Key", "add-duplicate").
+		JSON(testdata.ExampleCarDuplicate).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
 		End()
+
+	// the stored conflict is replayed even though the car has been deleted in the meantime
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Post("/cars").
+		Header("Idempotency-Key", "add-duplicate").
+		JSON(testdata.ExampleCarDuplicate).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		Header("Idempotent-Replayed", "true").
+		End()
+}
+
+func (suite *ApiTestSuite) TestAddCar_invalidJson() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleNoJson).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+
+	suite.TestVinOverview_empty()
+}
+
+func (suite *ApiTestSuite) TestAddCar_invalidCar() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleNoCar).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+
+	suite.TestVinOverview_empty()
+}
+
+func (suite *ApiTestSuite) TestAddCar_invalidCarEnums() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCarWrongEnum).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+
+	suite.TestVinOverview_empty()
+}
+
+func (suite *ApiTestSuite) TestAddCar_invalidCheckDigit() {
+	// the check digit of this North American VIN should be 3, but the default check mode only warns about it
+	invalidVin := "1HGCM82643A004352"
+	suite.newApiTest().
+		Post("/cars").
+		JSON(strings.Replace(testdata.ExampleCar, testdata.ExampleCarVinString, invalidVin, 1)).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		Header("Warning", `299 - "VIN `+invalidVin+` has an invalid check digit, expected 3 at position 9"`).
+		Body(`"` + invalidVin + `"`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestAddCar_fuelCapacityContradiction() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(strings.Replace(testdata.ExampleCar, `"fuel": "ELECTRIC"`, `"fuel": "PETROL"`, 1)).
+		Expect(suite.T()).
+		Status(http.StatusUnprocessableEntity).
+		Body(`{"message": "The car is implausible.", "violations": [{"field": "technicalSpecification.fuelCapacity",
+			"message": "A car with a combustion engine needs a fuel capacity in liters only."}]}`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestAddCar_implausible() {
+	implausibleCar := strings.NewReplacer(`"numberOfSeats": 7`, `"numberOfSeats": 0`, `"combined": 5.2`,
+		`"combined": 7.0`, `"city": 0`, `"city": 120`).Replace(testdata.ExampleCar)
+
+	suite.newApiTest().
+		Post("/cars").
+		JSON(implausibleCar).
+		Expect(suite.T()).
+		Status(http.StatusUnprocessableEntity).
+		Body(`{"message": "The car is implausible.", "violations": [
+			{"field": "technicalSpecification.numberOfSeats", "message": "A car needs at least one seat."},
+			{"field": "technicalSpecification.consumption.combined",
+				"message": "The combined consumption must lie between the consumption in the city and overland."},
+			{"field": "technicalSpecification.emissions.city", "message": "An electric car cannot emit CO2."}]}`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestAddCars_success() {
+	// the first car exists before the batch
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	var results []struct {
+		Vin     string
+		Status  string
+		Message string
+	}
+	suite.newApiTest().
+		Post("/cars:batch").
+		JSON("[" + testdata.ExampleCarDuplicate + "," + testdata.ExampleCar2 + "," + testdata.ExampleCarWrongEnum +
+			"," + testdata.ExampleCar2 + "]").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Assert(decodeBody(&results)).
+		End()
+
+	suite.Len(results, 4)
+	suite.Equal(testdata.ExampleCarVinString, results[0].Vin)
+	suite.Equal("duplicate", results[0].Status)
+	suite.Equal(testdata.ExampleCar2VinString, results[1].Vin)
+	suite.Equal("created", results[1].Status)
+	suite.Equal("invalid", results[2].Status)
+	suite.NotEmpty(results[2].Message)
+	// the second car also counts as duplicate within the batch
+	suite.Equal("duplicate", results[3].Status)
+
+	suite.newApiTest().
+		Get("/cars").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(testdata.ArrayString(testdata.ExampleCarVin, testdata.ExampleCar2Vin)).
+		End()
+
+	// the existing car did not change
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestAddCars_noArray() {
+	suite.newApiTest().
+		Post("/cars:batch").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+// readBody reads the whole response body into body.
+// exampleDecodedVin is the information decoded from the VIN of the example car.
+const exampleDecodedVin = `{"wmi": "WVW", "manufacturer": "Volkswagen", "modelYearCode": "8",
+	"modelYears": [2008, 2038], "plantCode": "W"}`
+
+// exampleTireSpecification is the specification parsed from the tire type of the example car.
+const exampleTireSpecification = `{"width": 185, "aspectRatio": 65, "construction": "R", "runFlat": false,
+	"rimDiameter": 15}`
+
+// withDecodedData adds the decoded VIN and the tire specification of the example car to a car object, as returned by
+// GET /cars/{vin}.
+func withDecodedData(car string) string {
+	return strings.TrimSuffix(strings.TrimSpace(car), "}") + `, "decodedVin": ` + exampleDecodedVin +
+		`, "tireSpecification": ` + exampleTireSpecification + "}"
+}
+
+func readBody(body *string) apitest.Assert {
+	return func(res *http.Response, _ *http.Request) error {
+		content, err := io.ReadAll(res.Body)
+		*body = string(content)
+		return err
+	}
+}
+
+func (suite *ApiTestSuite) TestExportCars_ndjson() {
+	for _, car := range []string{testdata.ExampleCar, testdata.ExampleCar2} {
+		suite.newApiTest().
+			Post("/cars").
+			JSON(car).
+			Expect(suite.T()).
+			Status(http.StatusCreated).
+			End()
+	}
+
+	var body string
+	suite.newApiTest().
+		Get("/cars/export").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("Content-Type", "application/x-ndjson").
+		Header("Content-Disposition", `attachment; filename="cars.ndjson"`).
+		Assert(readBody(&body)).
+		End()
+
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	suite.Len(lines, 2)
+	suite.JSONEq(testdata.ExampleCarWithDynamicData, lines[0])
+
+	var car carTypes.Car
+	suite.Nil(json.Unmarshal([]byte(lines[1]), &car))
+	suite.Equal(testdata.ExampleCar2VinString, car.Vin)
+}
+
+func (suite *ApiTestSuite) TestExportCars_csv() {
+	for _, car := range []string{testdata.ExampleCar, testdata.ExampleCar2} {
+		suite.newApiTest().
+			Post("/cars").
+			JSON(car).
+			Expect(suite.T()).
+			Status(http.StatusCreated).
+			End()
+	}
+
+	var body string
+	suite.newApiTest().
+		Get("/cars/export").
+		Query("format", "csv").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("Content-Type", "text/csv").
+		Header("Content-Disposition", `attachment; filename="cars.csv"`).
+		Assert(readBody(&body)).
+		End()
+
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	suite.Len(lines, 3)
+	suite.True(strings.HasPrefix(lines[0], "vin,"))
+	suite.True(strings.HasPrefix(lines[1], testdata.ExampleCarVinString+","))
+	suite.True(strings.HasPrefix(lines[2], testdata.ExampleCar2VinString+","))
+}
+
+func (suite *ApiTestSuite) TestExportCars_empty() {
+	suite.newApiTest().
+		Get("/cars/export").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("").
+		End()
+}
+
+func (suite *ApiTestSuite) TestExportCars_invalidFormat() {
+	suite.newApiTest().
+		Get("/cars/export").
+		Query("format", "xml").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestPatchCar_success() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	// the trunk is unlocked to check that the dynamic data is kept
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(`"UNLOCKED"`).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	var patchedCar carTypes.Car
+	suite.newApiTest().
+		Patch("/cars/"+testdata.ExampleCarVinString).
+		Body(`{"model": "A4", "technicalSpecification": {"color": "red", "tire": {"type": "205/55R16"}}}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"3"`).
+		Assert(decodeBody(&patchedCar)).
+		End()
+
+	suite.Equal("A4", patchedCar.Model)
+	suite.Equal("red", patchedCar.TechnicalSpecification.Color)
+	suite.Equal("205/55R16", patchedCar.TechnicalSpecification.Tire.Type)
+	suite.Equal(carTypes.UNLOCKED, patchedCar.DynamicData.TrunkLockState)
+
+	var storedCar carTypes.Car
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"3"`).
+		Assert(decodeBody(&storedCar)).
+		End()
+
+	suite.Equal(patchedCar, storedCar)
+}
+
+func (suite *ApiTestSuite) TestPatchCar_fuelCapacityContradiction() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Patch("/cars/" + testdata.ExampleCarVinString).
+		Body(`{"technicalSpecification": {"fuelCapacity": "54.0L"}}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusUnprocessableEntity).
+		Body(`{"message": "The car is implausible.", "violations": [{"field": "technicalSpecification.fuelCapacity",
+			"message": "An electric car needs a fuel capacity in kWh."}]}`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestPatchCar_tireSpecification() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Patch("/cars/" + testdata.ExampleCarVinString).
+		Body(`{"technicalSpecification": {"tire": {"type": "225/45RF1791W"}}}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		End()
+
+	var storedCar struct {
+		TireSpecification model.TireSpecification `json:"tireSpecification"`
+	}
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Assert(decodeBody(&storedCar)).
+		End()
+
+	loadIndex, speedRating := 91, "W"
+	suite.Equal(model.TireSpecification{
+		Width:        225,
+		AspectRatio:  45,
+		Construction: model.TireConstructionRadial,
+		RunFlat:      true,
+		RimDiameter:  17,
+		LoadIndex:    &loadIndex,
+		SpeedRating:  &speedRating,
+	}, storedCar.TireSpecification)
+}
+
+func (suite *ApiTestSuite) TestPatchCar_invalid() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	for _, patch := range []string{`{"brand": null}`, `{"technicalSpecification": {"fuel": "COAL"}}`,
+		`{"dynamicData": {"engineState": "ON"}}`, `"red"`} {
+
+		suite.newApiTest().
+			Patch("/cars/" + testdata.ExampleCarVinString).
+			Body(patch).
+			ContentType("application/merge-patch+json").
+			Expect(suite.T()).
+			Status(http.StatusBadRequest).
+			End()
+	}
+
+	suite.newApiTest().
+		Patch("/cars/" + testdata.ExampleCarVinString).
+		Body(`{"vin": ` + testdata.ExampleCar2Vin + `}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		End()
+
+	// nothing was changed
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestPatchCar_noSuchCar() {
+	suite.newApiTest().
+		Patch("/cars/" + testdata.ExampleCarVinString).
+		Body(`{"brand": "VW"}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRemoveCar_noSuchCar() {
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRemoveCar_invalidFormat() {
+	suite.newApiTest().
+		Delete("/cars/xyz").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRemoveCar_success() {
+	// add two cars
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar2).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	// verify that both cars exist
+	suite.newApiTest().
+		Get("/cars").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(testdata.ArrayString(testdata.ExampleCarVin, testdata.ExampleCar2Vin)).
+		End()
+
+	// remove one car
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCar2VinString).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	// verify that the car was removed
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCar2VinString).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+
+	// verify that the other car is still there
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(testdata.ExampleCarVinArray).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRemoveCar_archives() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	// the archived car is hidden, but not gone
+	suite.newApiTest().
+		Get("/cars").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("[]").
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Query("includeArchived", "true").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(testdata.ExampleCarVinArray).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/export").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("").
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+
+	// the VIN is still taken by the archived car
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		Body(`{"message": "VIN belongs to an archived car, use :restore instead"}`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRestoreCar_success() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":restore").
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+
+	// the car is not archived anymore
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":restore").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRestoreCar_noSuchCar() {
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":restore").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRestoreCar_invalidFormat() {
+	suite.newApiTest().
+		Post("/cars/xyz:restore").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestPurgeCar_archived() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":purge").
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Query("includeArchived", "true").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("[]").
+		End()
+
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":restore").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestPurgeCar_noSuchCar() {
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":purge").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetCar_etag() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"1"`).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Header("If-None-Match", `"1"`).
+		Expect(suite.T()).
+		Status(http.StatusNotModified).
+		Header("ETag", `"1"`).
+		End()
+
+	// every change of the car, including its dynamic data, results in a new ETag
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(`"UNLOCKED"`).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Header("If-None-Match", `"1"`).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"2"`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetCar_imperial() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	var car struct {
+		TechnicalSpecification carTypes.TechnicalSpecification `json:"technicalSpecification"`
+		Units                  map[string]string               `json:"units"`
+	}
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Query("units", "imperial").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"1-imperial"`).
+		Header("Vary", "Accept").
+		Assert(decodeBody(&car)).
+		End()
+
+	suite.Equal(2910, car.TechnicalSpecification.Weight)
+	suite.Equal(201, car.TechnicalSpecification.Engine.Power)
+	suite.Equal("14.3gal;85.2kWh", car.TechnicalSpecification.FuelCapacity)
+	suite.Equal(carTypes.TechnicalSpecificationConsumption{City: 10.3, Combined: 8.4, Overland: 7.4},
+		car.TechnicalSpecification.Consumption)
+	suite.Equal(map[string]string{
+		"technicalSpecification.weight":       "lb",
+		"technicalSpecification.engine.power": "hp",
+		"technicalSpecification.fuelCapacity": "gal",
+		"technicalSpecification.consumption":  "kWh/100mi",
+		"technicalSpecification.emissions":    "g/mi",
+	}, car.Units)
+
+	// the stored car is not changed
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetCar_imperialAccept() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	var car struct {
+		TechnicalSpecification carTypes.TechnicalSpecification `json:"technicalSpecification"`
+		Units                  map[string]string               `json:"units"`
+	}
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Header("Accept", "application/json; profile=imperial").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Assert(decodeBody(&car)).
+		End()
+
+	suite.Equal(2910, car.TechnicalSpecification.Weight)
+	suite.Equal("lb", car.Units["technicalSpecification.weight"])
+
+	// the units query parameter takes precedence
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Query("units", "metric").
+		Header("Accept", "application/json; profile=imperial").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetCar_invalidUnits() {
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Query("units", "nautical").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestVinOverview_fieldsImperial() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Get("/cars").
+		Query("fields", "model,technicalSpecification.weight").
+		Query("units", "imperial").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("Vary", "Accept").
+		Body(`[{"vin": ` + testdata.ExampleCarVin + `, "model": "A3", "technicalSpecification": {"weight": 2910},
+			"units": {"technicalSpecification.weight": "lb"}}]`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestPatchCar_ifMatch() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Patch("/cars/"+testdata.ExampleCarVinString).
+		Header("If-Match", `"1"`).
+		Body(`{"model": "A4"}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		End()
+
+	// the first patch changed the revision
+	suite.newApiTest().
+		Patch("/cars/"+testdata.ExampleCarVinString).
+		Header("If-Match", `"1"`).
+		Body(`{"model": "A5"}`).
+		ContentType("application/merge-patch+json").
+		Expect(suite.T()).
+		Status(http.StatusPreconditionFailed).
+		End()
+
+	var storedCar carTypes.Car
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"2"`).
+		Assert(decodeBody(&storedCar)).
+		End()
+
+	suite.Equal("A4", storedCar.Model)
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_ifMatch() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/"+testdata.ExampleCarVinString+"/trunkLock").
+		Header("If-Match", `"2"`).
+		JSON(`"UNLOCKED"`).
+		Expect(suite.T()).
+		Status(http.StatusPreconditionFailed).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/"+testdata.ExampleCarVinString+"/trunkLock").
+		Header("If-Match", `"1"`).
+		JSON(`"UNLOCKED"`).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	var car carTypes.Car
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"2"`).
+		Assert(decodeBody(&car)).
+		End()
+
+	suite.Equal(carTypes.UNLOCKED, car.DynamicData.TrunkLockState)
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_idempotencyKey() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	for i := 0; i < 2; i++ {
+		suite.newApiTest().
+			Put("/cars/"+testdata.ExampleCarVinString+"/trunkLock").
+			Header("Idempotency-Key", "unlock-trunk").
+			JSON(`"UNLOCKED"`).
+			Expect(suite.T()).
+			Status(http.StatusNoContent).
+			End()
+	}
+
+	// the retry must not change the car again
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Header("ETag", `"2"`).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/"+testdata.ExampleCarVinString+"/trunkLock").
+		Header("Idempotency-Key", "unlock-trunk").
+		JSON(`"LOCKED"`).
+		Expect(suite.T()).
+		Status(http.StatusUnprocessableEntity).
+		End()
+}
+
+func (suite *ApiTestSuite) TestRemoveCar_ifMatch() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Delete("/cars/"+testdata.ExampleCarVinString).
+		Header("If-Match", `"2"`).
+		Expect(suite.T()).
+		Status(http.StatusPreconditionFailed).
+		End()
+
+	suite.newApiTest().
+		Delete("/cars/"+testdata.ExampleCarVinString).
+		Header("If-Match", `"1"`).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_noSuchCar() {
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_invalidVinFormat() {
+	suite.newApiTest().
+		Put("/cars/xyz/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_invalidLockState() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("xyz")).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_successUnchanged() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("LOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_successChanged() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarUnlockedTrunk)).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("LOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDoorsLockState_noSuchCar() {
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDoorsLockState_invalidVinFormat() {
+	suite.newApiTest().
+		Put("/cars/xyz/doorsLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDoorsLockState_invalidLockState() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(testdata.QuoteString("xyz")).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDoorsLockState_successUnchanged() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(testdata.QuoteString("LOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDoorsLockState_successChanged() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarUnlockedDoors)).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(testdata.QuoteString("LOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeEngineState_noSuchCar() {
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeEngineState_invalidVinFormat() {
+	suite.newApiTest().
+		Put("/cars/xyz/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeEngineState_invalidEngineState() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("xyz")).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeEngineState_successChanged() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarEngineOn)).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("OFF")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeEngineState_unlockedTrunk() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		End()
+
+	// validate that the engine is still off
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarUnlockedTrunk)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeTrunkLockState_runningEngine() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		End()
+
+	// validate that the trunk is still locked
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarEngineOn)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDynamicData_noSuchCar() {
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/dynamicData").
+		JSON(testdata.ExampleDynamicData).
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDynamicData_invalidVinFormat() {
+	suite.newApiTest().
+		Put("/cars/xyz/dynamicData").
+		JSON(testdata.ExampleDynamicData).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDynamicData_invalidDynamicData() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/dynamicData").
+		JSON(testdata.ExampleDynamicDataInvalidFuelLevel).
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+
+	// validate that the dynamic data did not change
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeDynamicData_success() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/dynamicData").
+		JSON(testdata.ExampleDynamicData).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarWithReportedDynamicData)).
+		End()
+}
+
+func (suite *ApiTestSuite) TestChangeEngineState_emptyTank() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/dynamicData").
+		JSON(testdata.ExampleDynamicDataEmptyTank).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusConflict).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetPositions_noSuchCar() {
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/positions").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetPositions_invalidVinFormat() {
+	suite.newApiTest().
+		Get("/cars/xyz/positions").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetPositions_invalidTime() {
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString+"/positions").
+		Query("from", "yesterday").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetPositions_noHistory() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/positions").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("[]").
+		End()
+}
+
+// drive starts the engine of the example car, moves it to the reported position and stops the engine again.
+func (suite *ApiTestSuite) drive() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/dynamicData").
+		JSON(testdata.ExampleDynamicData).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/engine").
+		JSON(testdata.QuoteString("OFF")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetPositions_success() {
+	suite.drive()
+
+	var records []model.PositionRecord
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/positions").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Assert(decodeBody(&records)).
+		End()
+
+	suite.Len(records, 3)
+	suite.Equal(carTypes.ON, records[0].EngineState)
+	suite.Equal(carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}, records[0].Position)
+	suite.Equal(carTypes.ON, records[1].EngineState)
+	suite.Equal(carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405}, records[1].Position)
+	suite.Equal(carTypes.OFF, records[2].EngineState)
+	suite.Equal(carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405}, records[2].Position)
+}
+
+func (suite *ApiTestSuite) TestGetPositions_timeRange() {
+	suite.drive()
+
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString+"/positions").
+		Query("from", time.Now().Add(time.Hour).UTC().Format(time.RFC3339)).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("[]").
+		End()
+}
+
+func (suite *ApiTestSuite) TestRemoveCar_keepsPositions() {
+	suite.drive()
+
+	suite.newApiTest().
+		Delete("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":restore").
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	var records []model.PositionRecord
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/positions").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Assert(decodeBody(&records)).
+		End()
+
+	suite.NotEmpty(records)
+}
+
+func (suite *ApiTestSuite) TestPurgeCar_removesPositions() {
+	suite.drive()
+
+	suite.newApiTest().
+		Post("/cars/" + testdata.ExampleCarVinString + ":purge").
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/positions").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body("[]").
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetTrips_noSuchCar() {
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/trips").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetTrips_success() {
+	suite.drive()
+
+	var trips []model.Trip
+
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/trips").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Assert(decodeBody(&trips)).
+		End()
+
+	suite.Len(trips, 1)
+	suite.Equal(carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}, trips[0].Start.Position)
+	suite.Equal(carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405}, trips[0].End.Position)
+	// Karlsruhe and Berlin are about 525 km apart
+	suite.InDelta(525000, trips[0].Distance, 5000)
+	suite.GreaterOrEqual(trips[0].Duration, 0.0)
+}
+
+func (suite *ApiTestSuite) TestGetRange_noSuchCar() {
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/range").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetRange_invalidProfile() {
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString+"/range").
+		Query("profile", "fast").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetRange_electric() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	// a new car has a full tank, 85.2 kWh last for 1638.5 km with 5.2 kWh/100 km
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/range").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(`{"profile": "combined", "total": 1638.5, "electric": 1638.5}`).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetRange_hybrid() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(strings.Replace(testdata.ExampleCar, `"fuel": "ELECTRIC"`, `"fuel": "HYBRID_PETROL"`, 1)).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Get("/cars/"+testdata.ExampleCarVinString+"/range").
+		Query("profile", "overland").
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(`{"profile": "overland", "total": 3026.1, "electric": 1852.2, "combustion": 1173.9}`).
+		End()
+}
+
+// decodeBody returns an assertion that decodes the JSON response body into the given value.
+func decodeBody(v interface{}) apitest.Assert {
+	return func(res *http.Response, _ *http.Request) error {
+		return json.NewDecoder(res.Body).Decode(v)
+	}
+}
+
+func (suite *ApiTestSuite) TestGetCarEvents_noSuchCar() {
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/events").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetCarEvents_invalidVinFormat() {
+	suite.newApiTest().
+		Get("/cars/xyz/events").
+		Expect(suite.T()).
+		Status(http.StatusBadRequest).
+		End()
+}
+
+func (suite *ApiTestSuite) TestGetCarEvents_success() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	events := suite.openEventStream("/cars/" + testdata.ExampleCarVinString + "/events")
+
+	// the current state is sent immediately
+	event := readEvent(suite.T(), events)
+	suite.Equal(testdata.ExampleCarVinString, event.Vin)
+	suite.Equal(carTypes.LOCKED, event.DynamicData.TrunkLockState)
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/trunkLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	event = readEvent(suite.T(), events)
+	suite.Equal(carTypes.UNLOCKED, event.DynamicData.TrunkLockState)
+}
+
+func (suite *ApiTestSuite) TestGetFleetEvents_success() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar2).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	events := suite.openEventStream("/events")
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCarVinString + "/doorsLock").
+		JSON(testdata.QuoteString("UNLOCKED")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	suite.newApiTest().
+		Put("/cars/" + testdata.ExampleCar2VinString + "/engine").
+		JSON(testdata.QuoteString("ON")).
+		Expect(suite.T()).
+		Status(http.StatusNoContent).
+		End()
+
+	event := readEvent(suite.T(), events)
+	suite.Equal(testdata.ExampleCarVinString, event.Vin)
+	suite.Equal(carTypes.UNLOCKED, event.DynamicData.DoorsLockState)
+
+	event = readEvent(suite.T(), events)
+	suite.Equal(testdata.ExampleCar2VinString, event.Vin)
+	suite.Equal(carTypes.ON, event.DynamicData.EngineState)
+}
+
+// openEventStream opens a Server-Sent Events stream on a real server and returns a reader of the stream.
+// The stream is closed when the test ends.
+func (suite *ApiTestSuite) openEventStream(path string) *bufio.Reader {
+	server := httptest.NewServer(suite.app)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	suite.T().Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+	suite.Require().Nil(err)
+
+	response, err := server.Client().Do(request)
+	suite.Require().Nil(err)
+	suite.Require().Equal(http.StatusOK, response.StatusCode)
+	suite.Require().Equal("text/event-stream", response.Header.Get(echo.HeaderContentType))
+
+	return bufio.NewReader(response.Body)
+}
+
+// readEvent reads the next event from a Server-Sent Events stream and decodes its data.
+func readEvent(t *testing.T, events *bufio.Reader) model.CarEvent {
+	var event model.CarEvent
+	for {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, found := strings.CutPrefix(line, "data: "); found {
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatal(err)
+			}
+			return event
+		}
+	}
+}
+
+func (suite *ApiTestSuite) TestConnectCar_noSuchCar() {
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString + "/ws").
+		Expect(suite.T()).
+		Status(http.StatusNotFound).
+		End()
+}
+
+func (suite *ApiTestSuite) TestConnectCar_success() {
+	suite.newApiTest().
+		Post("/cars").
+		JSON(testdata.ExampleCar).
+		Expect(suite.T()).
+		Status(http.StatusCreated).
+		End()
+
+	server := httptest.NewServer(suite.app)
+	defer server.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/cars/"+
+		testdata.ExampleCarVinString+"/ws", "", server.URL)
+	suite.Require().Nil(err)
+	defer conn.Close()
+
+	// the current state is sent immediately
+	var update api.RemoteStateUpdate
+	suite.Require().Nil(websocket.JSON.Receive(conn, &update))
+	suite.Equal(carTypes.LOCKED, update.DynamicData.DoorsLockState)
+
+	suite.Require().Nil(websocket.Message.Send(conn, `{"id": "1", "command": "doorsLock", "state": "UNLOCKED"}`))
+
+	// the acknowledgement and the state update may arrive in any order
+	for i := 0; i < 2; i++ {
+		var message map[string]interface{}
+		suite.Require().Nil(websocket.JSON.Receive(conn, &message))
+
+		if message["type"] == string(api.RemoteMessageAcknowledgement) {
+			suite.Equal("1", message["id"])
+			suite.Equal(true, message["success"])
+		} else {
+			suite.Equal(string(carTypes.UNLOCKED), message["dynamicData"].(map[string]interface{})["doorsLockState"])
+		}
+	}
+
+	// the change is visible to the REST API as well
+	suite.newApiTest().
+		Get("/cars/" + testdata.ExampleCarVinString).
+		Expect(suite.T()).
+		Status(http.StatusOK).
+		Body(withDecodedData(testdata.ExampleCarUnlockedDoors)).
+		End()
+
+	// rule violations are reported in the acknowledgement
+	suite.Require().Nil(websocket.Message.Send(conn, `{"id": "2", "command": "engine", "state": "ON"}`))
+	suite.Require().Nil(websocket.Message.Send(conn, `{"id": "3", "command": "trunkLock", "state": "UNLOCKED"}`))
+
+	for {
+		var acknowledgement api.RemoteAcknowledgement
+		suite.Require().Nil(websocket.JSON.Receive(conn, &acknowledgement))
+		if acknowledgement.Id == "3" {
+			suite.False(acknowledgement.Success)
+			suite.Equal("The trunk cannot be unlocked while the engine is running.", acknowledgement.Error)
+			break
+		}
+	}
 }
@@ -1,5 +1,10 @@
 package environment
 
+import (
+	"DCar/logic/vin"
+	"time"
+)
+
 var (
 	environment *Environment
 )
@@ -21,6 +26,10 @@ type Environment struct {
 	appExposePort           int
 	appCollectionPrefix     string
 	isLocalSetupMode        bool
+	isSimulatorEnabled      bool
+	simulatorInterval       time.Duration
+	simulatorSpeed          int
+	vinCheckMode            vin.CheckMode
 }
 
 func (e *Environment) GetMongoDbConnectionString() string {
@@ -48,3 +57,19 @@ func (e *Environment) SetAppCollectionPrefix(prefix string) {
 func (e *Environment) IsLocalSetupMode() bool {
 	return e.isLocalSetupMode
 }
+
+func (e *Environment) IsSimulatorEnabled() bool {
+	return e.isSimulatorEnabled
+}
+
+func (e *Environment) GetSimulatorInterval() time.Duration {
+	return e.simulatorInterval
+}
+
+func (e *Environment) GetSimulatorSpeed() int {
+	return e.simulatorSpeed
+}
+
+func (e *Environment) GetVinCheckMode() vin.CheckMode {
+	return e.vinCheckMode
+}
@@ -1,11 +1,13 @@
 package environment
 
 import (
+	"DCar/logic/vin"
 	_ "embed"
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
 	"strconv"
+	"time"
 )
 
 const (
@@ -14,9 +16,16 @@ const (
 	envAppExposePort           = "CAR_EXPOSE_PORT"
 	envAppCollectionPrefix     = "CAR_COLLECTION_PREFIX"
 	envLocalSetupMode          = "CAR_LOCAL_SETUP"
+	envSimulatorEnabled        = "CAR_SIMULATOR_ENABLED"
+	envSimulatorInterval       = "CAR_SIMULATOR_INTERVAL"
+	envSimulatorSpeed          = "CAR_SIMULATOR_SPEED"
+	envVinCheckMode            = "CAR_VIN_CHECK_MODE"
 
 	defaultAppExposePort       = 80
 	defaultAppCollectionPrefix = ""
+	defaultSimulatorInterval   = 5
+	defaultSimulatorSpeed      = 50
+	defaultVinCheckMode        = vin.CheckModeWarn
 )
 
 func ptr[T any](v T) *T {
@@ -58,9 +67,27 @@ func readEnvironmentFromEnv() *Environment {
 		appExposePort:           getIntegerEnvVariable(envAppExposePort, ptr(defaultAppExposePort)),
 		appCollectionPrefix:     getStringEnvVariable(envAppCollectionPrefix, ptr(defaultAppCollectionPrefix)),
 		isLocalSetupMode:        getBooleanEnvVariable(envLocalSetupMode),
+		isSimulatorEnabled:      getBooleanEnvVariable(envSimulatorEnabled),
+		simulatorInterval: time.Duration(getPositiveIntegerEnvVariable(envSimulatorInterval,
+			ptr(defaultSimulatorInterval))) * time.Second,
+		simulatorSpeed: getPositiveIntegerEnvVariable(envSimulatorSpeed, ptr(defaultSimulatorSpeed)),
+		vinCheckMode:   getVinCheckModeEnvVariable(envVinCheckMode),
 	}
 }
 
+// getVinCheckModeEnvVariable returns the VIN check mode of the environment variable with the given name.
+// If the environment variable is not set, the default mode is returned.
+// If the environment variable is not a valid mode, the program will panic.
+func getVinCheckModeEnvVariable(variableName string) vin.CheckMode {
+	stringValue := getStringEnvVariable(variableName, ptr(string(defaultVinCheckMode)))
+
+	mode, err := vin.ParseCheckMode(stringValue)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid value for environment variable \"%s\": %s", variableName, err.Error()))
+	}
+	return mode
+}
+
 // getStringEnvVariable returns the string value of the environment variable with the given name.
 // You can specify a default value that is returned if the environment variable is not set,
 // set defaultValue to nil to disable this feature.
@@ -99,6 +126,17 @@ func getIntegerEnvVariable(variableName string, defaultValue *int) int {
 	return intValue
 }
 
+// getPositiveIntegerEnvVariable works like getIntegerEnvVariable, but the program will also panic if the value of
+// the environment variable is not positive.
+func getPositiveIntegerEnvVariable(variableName string, defaultValue *int) int {
+	intValue := getIntegerEnvVariable(variableName, defaultValue)
+	if intValue <= 0 {
+		panic(fmt.Sprintf("Invalid value for positive integer environment variable \"%s\": %d",
+			variableName, intValue))
+	}
+	return intValue
+}
+
 // getBooleanEnvVariable returns the boolean value of the environment variable with the given name.
 // If the environment variable is not set, false is returned.
 // If the environment variable is not a valid boolean value, the program will panic.
@@ -0,0 +1,36 @@
+package environment
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// setRequiredEnv sets the environment variables that have no default value.
+func setRequiredEnv(t *testing.T) {
+	t.Setenv(envMongoDbConnectionString, "mongodb://localhost:27017")
+	t.Setenv(envMongoDbDatabase, "dcar")
+}
+
+func TestReadEnvironmentFromEnv_simulatorSpeed(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv(envSimulatorSpeed, "30")
+
+	assert.Equal(t, 30, readEnvironmentFromEnv().GetSimulatorSpeed())
+}
+
+func TestReadEnvironmentFromEnv_defaultSimulatorSpeed(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv(envSimulatorSpeed, "")
+
+	assert.Equal(t, defaultSimulatorSpeed, readEnvironmentFromEnv().GetSimulatorSpeed())
+}
+
+func TestReadEnvironmentFromEnv_nonPositiveSimulatorSpeed(t *testing.T) {
+	setRequiredEnv(t)
+
+	for _, speed := range []string{"0", "-50"} {
+		t.Setenv(envSimulatorSpeed, speed)
+
+		assert.Panics(t, func() { readEnvironmentFromEnv() }, speed)
+	}
+}
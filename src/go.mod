@@ -13,6 +13,7 @@ require (
 	github.com/steinfletcher/apitest v1.5.14
 	github.com/stretchr/testify v1.8.3
 	go.mongodb.org/mongo-driver v1.12.0
+	golang.org/x/net v0.11.0
 )
 
 require (
@@ -71,7 +72,6 @@ require (
 	golang.org/x/arch v0.3.0 // indirect
 	golang.org/x/crypto v0.10.0 // indirect
 	golang.org/x/mod v0.10.0 // indirect
-	golang.org/x/net v0.11.0 // indirect
 	golang.org/x/sync v0.3.0 // indirect
 	golang.org/x/sys v0.9.0 // indirect
 	golang.org/x/text v0.10.0 // indirect
@@ -6,17 +6,36 @@ import (
 	"DCar/infrastructure/database/db"
 	"DCar/infrastructure/database/entities"
 	"DCar/infrastructure/database/mappers"
+	"DCar/logic/model"
 	"context"
 	"errors"
 	carTypes "github.com/ccsapp/cargotypes"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"strings"
+	"time"
 )
 
 const CarsCollectionBaseName = "cars"
+const PositionsCollectionBaseName = "positions"
+const IdempotencyCollectionBaseName = "idempotencyKeys"
+
+// idempotencyRecordLifetime is the time after which an idempotency key is forgotten and can be used again.
+const idempotencyRecordLifetime = 24 * time.Hour
+
+// idempotencyLease is the time an idempotency key stays claimed while its request is handled. If no response is set
+// by then, e.g. because the server stopped, the key can be claimed again.
+const idempotencyLease = time.Minute
 
 var conversionError = errors.New("invalid type in database")
 
+// notArchived is the condition of a database query for cars that are not archived. Cars stored before archiving was
+// introduced have no deletedAt field at all, which matches as well.
+var notArchived = bson.E{"deletedAt", nil}
+
 // IsDuplicateKeyError checks if the error is a duplicate key error. A duplicate key error can occur if you try to
 // insert a car with a duplicate VIN.
 func IsDuplicateKeyError(err error) bool {
@@ -29,49 +48,251 @@ func IsNotFoundError(err error) bool {
 	return err == mongo.ErrNoDocuments
 }
 
+// RevisionMismatchError is returned if you try to change a car that has been changed since the revision you expected.
+type RevisionMismatchError struct {
+	Vin carTypes.Vin
+}
+
+func (e *RevisionMismatchError) Error() string {
+	return "the car " + e.Vin + " has been changed since the expected revision"
+}
+
+// IsRevisionMismatchError checks if the error is a RevisionMismatchError.
+func IsRevisionMismatchError(err error) bool {
+	var revisionMismatchError *RevisionMismatchError
+	return errors.As(err, &revisionMismatchError)
+}
+
+// ArchivedCarError is returned if you try to create a car with the VIN of an archived car. It is a duplicate key
+// error as well, so the car can be restored instead.
+type ArchivedCarError struct {
+	Vin carTypes.Vin
+
+	// duplicateKeyError is the error of the database that is wrapped
+	duplicateKeyError error
+}
+
+func (e *ArchivedCarError) Error() string {
+	return "the car " + e.Vin + " is archived"
+}
+
+func (e *ArchivedCarError) Unwrap() error {
+	return e.duplicateKeyError
+}
+
+// IsArchivedCarError checks if the error is an ArchivedCarError.
+func IsArchivedCarError(err error) bool {
+	var archivedCarError *ArchivedCarError
+	return errors.As(err, &archivedCarError)
+}
+
 type CrudConfig interface {
 	GetAppCollectionPrefix() string
 }
 
 // ICRUD is a high level database interface. It directly maps to the business logic and abstracts away the
-// database entities and the database connection.
+// database entities and the database connection. Archived cars (see ArchiveCar) are treated like cars that do not
+// exist, except by RestoreCar, PurgeCar and filters that include them explicitly.
 type ICRUD interface {
+	// CreateIndexes creates the indexes the database queries rely on. It should be called once before the other
+	// methods are used. Any errors are unexpected.
+	CreateIndexes(ctx context.Context) error
+
 	// CreateCar creates a new car in the database and returns the VIN. If the VIN already exists, an error is returned.
-	// You can check if the error is such an error with IsDuplicateKeyError. Any other errors are unexpected.
+	// You can check if the error is such an error with IsDuplicateKeyError. If the existing car is archived, the error
+	// is also an ArchivedCarError. Any other errors are unexpected.
 	CreateCar(ctx context.Context, car *carTypes.Car) (carTypes.Vin, error)
 
+	// CreateCars creates all given cars in the database at once. A car that cannot be created does not prevent the
+	// creation of the others. The returned slice contains the error for every car at the same index, or nil if the
+	// car was created. You can check if such an error is caused by a duplicate VIN with IsDuplicateKeyError. If the
+	// second return value is not nil, the outcome for the single cars is unknown. Any errors are unexpected.
+	CreateCars(ctx context.Context, cars []carTypes.Car) ([]error, error)
+
 	// ReadAllVins returns all VINs in the database. If there are no cars in the database, an empty slice is returned.
 	// Any errors are unexpected.
 	ReadAllVins(ctx context.Context) ([]carTypes.Vin, error)
 
-	// DeleteCar deletes the car with the given VIN and returns true. If the car does not exist, false is returned.
+	// ReadVinsMatching returns the VINs of all cars matching the given filter. If there are no such cars, an empty
+	// slice is returned. Any errors are unexpected.
+	ReadVinsMatching(ctx context.Context, filter model.CarFilter) ([]carTypes.Vin, error)
+
+	// ReadVinsPage returns at most limit VINs of the cars matching the given filter in ascending order. If after is
+	// not nil, only the VINs following after are returned. If there are no such cars, an empty slice is returned.
 	// Any errors are unexpected.
-	DeleteCar(ctx context.Context, vin carTypes.Vin) (bool, error)
+	ReadVinsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int) ([]carTypes.Vin, error)
+
+	// ReadVinsNear returns the VINs of all cars matching the given filter within the given radius in meters around
+	// the given position, sorted by their distance to the position. If there are no such cars, an empty slice is
+	// returned. Any errors are unexpected.
+	ReadVinsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64,
+		filter model.CarFilter) ([]carTypes.Vin, error)
+
+	// ReadCarsMatching returns all cars matching the given filter. If fields is not nil, only the given fields
+	// (see model.CarFields) are read from the database and all other fields of the returned cars are empty. If there
+	// are no such cars, an empty slice is returned. Any errors are unexpected.
+	ReadCarsMatching(ctx context.Context, filter model.CarFilter, fields []string) ([]carTypes.Car, error)
+
+	// ReadCarsPage returns at most limit cars matching the given filter ordered by their VIN. If after is not nil,
+	// only the cars following after are returned. The fields are handled like in ReadCarsMatching. If there are no
+	// such cars, an empty slice is returned. Any errors are unexpected.
+	ReadCarsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int, fields []string) (
+		[]carTypes.Car, error)
+
+	// ReadCarsNear returns all cars matching the given filter within the given radius in meters around the given
+	// position, sorted by their distance to the position. The fields are handled like in ReadCarsMatching. If there
+	// are no such cars, an empty slice is returned. Any errors are unexpected.
+	ReadCarsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64, filter model.CarFilter,
+		fields []string) ([]carTypes.Car, error)
+
+	// ForEachCar passes all cars ordered by their VIN to each, one after another as they are read from the database,
+	// so all cars never have to be held in memory at once. If each returns an error, no further cars are read and the
+	// error is returned. Any other errors are unexpected.
+	ForEachCar(ctx context.Context, each func(car *carTypes.Car) error) error
+
+	// ArchiveCar archives the car with the given VIN by setting its deletion time and returns true. The car and its
+	// position history are kept, so it can be restored with RestoreCar. If the car does not exist or is already
+	// archived, false is returned. If revision is not nil, the car is only archived if it still has this revision,
+	// otherwise an error is returned that you can check with IsRevisionMismatchError. Any other errors are unexpected.
+	ArchiveCar(ctx context.Context, vin carTypes.Vin, revision *int64) (bool, error)
+
+	// RestoreCar restores the archived car with the given VIN and returns true. If there is no archived car with this
+	// VIN, false is returned. Any errors are unexpected.
+	RestoreCar(ctx context.Context, vin carTypes.Vin) (bool, error)
+
+	// PurgeCar irreversibly deletes the car with the given VIN including its position history and returns true,
+	// regardless of whether the car is archived. If the car does not exist, false is returned. Any errors are
+	// unexpected.
+	PurgeCar(ctx context.Context, vin carTypes.Vin) (bool, error)
 
 	// ReadCar returns the car with the given VIN. If the car does not exist, an error is returned. You can check if the
 	// error is such an error with IsNotFoundError. Any other errors are unexpected.
 	ReadCar(ctx context.Context, vin carTypes.Vin) (carTypes.Car, error)
 
+	// ReadCarWithRevision works like ReadCar, but additionally returns the revision of the car. The revision is
+	// increased with every change of the car, including changes of the dynamic data.
+	ReadCarWithRevision(ctx context.Context, vin carTypes.Vin) (carTypes.Car, int64, error)
+
+	// ReadStoredCar works like ReadCarWithRevision, but additionally returns the values derived from the car when it
+	// was stored.
+	ReadStoredCar(ctx context.Context, vin carTypes.Vin) (model.StoredCar, error)
+
+	// UpdateStaticData updates the static data of the car with the given VIN from original to updated and returns
+	// true if anything was written, which increases the revision of the car. Only the fields that differ between both
+	// cars are written, the dynamic data is never changed. If the car does not exist, an error is returned. You can
+	// check if the error is such an error with IsNotFoundError. If revision is not nil and the car does not have this
+	// revision anymore, an error is returned that you can check with IsRevisionMismatchError. Any other errors are
+	// unexpected.
+	UpdateStaticData(ctx context.Context, vin carTypes.Vin, original *carTypes.Car, updated *carTypes.Car,
+		revision *int64) (bool, error)
+
 	// SetTrunkLockState sets the trunk lock state of the car with the given VIN. If the car does not exist,
+	// an error is returned. You can check if the error is such an error with IsNotFoundError. If revision is not nil
+	// and the car does not have this revision anymore, an error is returned that you can check with
+	// IsRevisionMismatchError. Any other errors are unexpected.
+	SetTrunkLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState,
+		revision *int64) error
+
+	// SetDoorsLockState sets the doors lock state of the car with the given VIN. If the car does not exist,
 	// an error is returned. You can check if the error is such an error with IsNotFoundError. Any other errors are
 	// unexpected.
-	SetTrunkLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState) error
+	SetDoorsLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState) error
+
+	// SetEngineState sets the engine state of the car with the given VIN. If the car does not exist,
+	// an error is returned. You can check if the error is such an error with IsNotFoundError. If revision is not nil
+	// and the car does not have this revision anymore, an error is returned that you can check with
+	// IsRevisionMismatchError. Any other errors are unexpected.
+	SetEngineState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataEngineState,
+		revision *int64) error
+
+	// SetDynamicData replaces the dynamic data of the car with the given VIN. If the car does not exist,
+	// an error is returned. You can check if the error is such an error with IsNotFoundError. Any other errors are
+	// unexpected.
+	SetDynamicData(ctx context.Context, vin carTypes.Vin, dynamicData *carTypes.DynamicData) error
+
+	// ReadCarsWithRunningEngine returns all cars whose engine is ON together with their revisions. If there are no
+	// such cars, an empty slice is returned. Any errors are unexpected.
+	ReadCarsWithRunningEngine(ctx context.Context) ([]model.CarWithRevision, error)
+
+	// SetPositionAndFuelLevel sets the position and the fuel level of the car with the given VIN. If the car does not
+	// exist, an error is returned. You can check if the error is such an error with IsNotFoundError. If revision is
+	// not nil, the car is only changed if it still has this revision. Otherwise, an error is returned that you can
+	// check with IsRevisionMismatchError. Any other errors are unexpected.
+	SetPositionAndFuelLevel(ctx context.Context, vin carTypes.Vin, position carTypes.DynamicDataPosition,
+		fuelLevelPercentage int, revision *int64) error
+
+	// AddPositionRecord adds a record to the position history of the car with the given VIN. The existence of the car
+	// is not checked. Any errors are unexpected.
+	AddPositionRecord(ctx context.Context, vin carTypes.Vin, record *model.PositionRecord) error
+
+	// ReadPositionRecords returns the position history of the car with the given VIN ordered by time. If from or to
+	// are set, only the records in this time range (inclusive) are returned. If there are no records, an empty slice
+	// is returned. The existence of the car is not checked. Any errors are unexpected.
+	ReadPositionRecords(ctx context.Context, vin carTypes.Vin, from *time.Time, to *time.Time) (
+		[]model.PositionRecord, error)
+
+	// CreateIdempotencyRecord records that the given idempotency key is used for the request with the given hash. The
+	// record has no response until it is set with SetIdempotentResponse and expires a day after its creation. A record
+	// without a response is only kept for a short lease and is replaced afterwards. If the key is already recorded,
+	// an error is returned that you can check with IsDuplicateKeyError. Any other errors are unexpected.
+	CreateIdempotencyRecord(ctx context.Context, key string, requestHash string) error
+
+	// ReadIdempotencyRecord returns the record of the given idempotency key. If the key is not recorded, an error is
+	// returned that you can check with IsNotFoundError. Any other errors are unexpected.
+	ReadIdempotencyRecord(ctx context.Context, key string) (model.IdempotencyRecord, error)
+
+	// SetIdempotentResponse sets the response in the record of the given idempotency key. Any errors are unexpected.
+	SetIdempotentResponse(ctx context.Context, key string, response *model.IdempotentResponse) error
+
+	// DeleteIdempotencyRecord deletes the record of the given idempotency key, so the key can be used again. Any
+	// errors are unexpected.
+	DeleteIdempotencyRecord(ctx context.Context, key string) error
 }
 
 type crud struct {
-	db         db.IConnection
-	collection string
+	db                    db.IConnection
+	collection            string
+	positionsCollection   string
+	idempotencyCollection string
 }
 
 func NewICRUD(db db.IConnection, config CrudConfig) ICRUD {
 	return &crud{
-		db:         db,
-		collection: config.GetAppCollectionPrefix() + CarsCollectionBaseName,
+		db:                    db,
+		collection:            config.GetAppCollectionPrefix() + CarsCollectionBaseName,
+		positionsCollection:   config.GetAppCollectionPrefix() + PositionsCollectionBaseName,
+		idempotencyCollection: config.GetAppCollectionPrefix() + IdempotencyCollectionBaseName,
+	}
+}
+
+func (c *crud) CreateIndexes(ctx context.Context) error {
+	// geospatial queries on the current position require a 2dsphere index
+	err := c.db.CreateIndex(ctx, c.collection, mongo.IndexModel{
+		Keys: bson.D{{"mockData_position", "2dsphere"}},
+	})
+	if err != nil {
+		return err
 	}
+
+	err = c.db.CreateIndex(ctx, c.positionsCollection, mongo.IndexModel{
+		Keys: bson.D{{"vin", 1}, {"timestamp", 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// MongoDB removes the expired idempotency records in the background
+	return c.db.CreateIndex(ctx, c.idempotencyCollection, mongo.IndexModel{
+		Keys:    bson.D{{"createdAt", 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(idempotencyRecordLifetime.Seconds())),
+	})
 }
 
 func (c *crud) CreateCar(ctx context.Context, car *carTypes.Car) (carTypes.Vin, error) {
 	res, err := c.db.Insert(ctx, c.collection, mappers.MapCarToDb(car))
+	if IsDuplicateKeyError(err) && c.isArchived(ctx, car.Vin) {
+		return "", &ArchivedCarError{Vin: car.Vin, duplicateKeyError: err}
+	}
 	if err != nil {
 		return "", err
 	}
@@ -82,47 +303,463 @@ func (c *crud) CreateCar(ctx context.Context, car *carTypes.Car) (carTypes.Vin,
 	return vin, nil
 }
 
+func (c *crud) CreateCars(ctx context.Context, cars []carTypes.Car) ([]error, error) {
+	carErrors := make([]error, len(cars))
+	if len(cars) == 0 {
+		return carErrors, nil
+	}
+
+	documents := make([]interface{}, len(cars))
+	for i := range cars {
+		documents[i] = mappers.MapCarToDb(&cars[i])
+	}
+
+	_, err := c.db.InsertMany(ctx, c.collection, documents)
+
+	// the write errors identify the failed cars, a write concern error leaves all cars in doubt
+	var bulkWriteException mongo.BulkWriteException
+	if errors.As(err, &bulkWriteException) && bulkWriteException.WriteConcernError == nil {
+		for _, writeError := range bulkWriteException.WriteErrors {
+			carErrors[writeError.Index] = writeError.WriteError
+		}
+		return carErrors, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return carErrors, nil
+}
+
 func (c *crud) ReadAllVins(ctx context.Context) ([]carTypes.Vin, error) {
 	var ids []bson.M
-	if err := c.db.GetIDs(ctx, c.collection, &ids); err != nil {
+	if err := c.db.GetIDs(ctx, c.collection, bson.D{notArchived}, &ids); err != nil {
 		return nil, err
 	}
-	vins := make([]carTypes.Vin, len(ids))
-	for i, id := range ids {
-		vins[i] = id["_id"].(carTypes.Vin)
+	return mapVins(ids), nil
+}
+
+func (c *crud) ReadVinsMatching(ctx context.Context, filter model.CarFilter) ([]carTypes.Vin, error) {
+	var ids []bson.M
+	opts := options.Find().SetProjection(bson.D{{"_id", 1}})
+	if err := c.db.Find(ctx, c.collection, mapCarFilter(&filter), &ids, opts); err != nil {
+		return nil, err
+	}
+	return mapVins(ids), nil
+}
+
+func (c *crud) ReadVinsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int) (
+	[]carTypes.Vin, error) {
+
+	// a nil *Vin must not end up as a typed nil in the interface
+	var afterId interface{}
+	if after != nil {
+		afterId = *after
+	}
+
+	var ids []bson.M
+	if err := c.db.GetIDsPage(ctx, c.collection, mapCarFilter(&filter), afterId, int64(limit), &ids); err != nil {
+		return nil, err
+	}
+	return mapVins(ids), nil
+}
+
+func (c *crud) ReadVinsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64,
+	filter model.CarFilter) ([]carTypes.Vin, error) {
+
+	var ids []bson.M
+	opts := options.Find().SetProjection(bson.D{{"_id", 1}})
+	if err := c.db.Find(ctx, c.collection, nearFilter(position, radius, &filter), &ids, opts); err != nil {
+		return nil, err
 	}
-	return vins, nil
+	return mapVins(ids), nil
+}
+
+func (c *crud) ReadCarsMatching(ctx context.Context, filter model.CarFilter, fields []string) ([]carTypes.Car,
+	error) {
+
+	return c.findCars(ctx, mapCarFilter(&filter), fields, options.Find())
+}
+
+func (c *crud) ReadCarsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int,
+	fields []string) ([]carTypes.Car, error) {
+
+	pageFilter := mapCarFilter(&filter)
+	if after != nil {
+		pageFilter = append(pageFilter, bson.E{"_id", bson.D{{"$gt", *after}}})
+	}
+
+	opts := options.Find().SetSort(bson.D{{"_id", 1}}).SetLimit(int64(limit))
+	return c.findCars(ctx, pageFilter, fields, opts)
+}
+
+func (c *crud) ReadCarsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64,
+	filter model.CarFilter, fields []string) ([]carTypes.Car, error) {
+
+	return c.findCars(ctx, nearFilter(position, radius, &filter), fields, options.Find())
+}
+
+func (c *crud) ForEachCar(ctx context.Context, each func(car *carTypes.Car) error) error {
+	opts := options.Find().SetSort(bson.D{{"_id", 1}})
+	return c.db.FindEach(ctx, c.collection, bson.D{notArchived}, func(document bson.Raw) error {
+		var car entities.Car
+		if err := bson.Unmarshal(document, &car); err != nil {
+			return err
+		}
+		modelCar := mappers.MapCarFromDb(&car)
+		return each(&modelCar)
+	}, opts)
 }
 
-func (c *crud) DeleteCar(ctx context.Context, vin carTypes.Vin) (bool, error) {
+func (c *crud) ArchiveCar(ctx context.Context, vin carTypes.Vin, revision *int64) (bool, error) {
+	err := c.updateCar(ctx, vin, bson.D{{"deletedAt", time.Now().UTC()}}, revision)
+	if IsNotFoundError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *crud) RestoreCar(ctx context.Context, vin carTypes.Vin) (bool, error) {
+	filter := bson.D{{"_id", vin}, {"deletedAt", bson.D{{"$ne", nil}}}}
+	res, err := c.db.UpdateOneAndIncrement(ctx, c.collection, filter, bson.D{{"deletedAt", nil}}, "revision")
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// isArchived checks if there is an archived car with the given VIN. If this cannot be checked, false is returned.
+func (c *crud) isArchived(ctx context.Context, vin carTypes.Vin) bool {
+	return c.db.FindOne(ctx, c.collection, bson.D{{"_id", vin}, {"deletedAt", bson.D{{"$ne", nil}}}}).Err() == nil
+}
+
+func (c *crud) PurgeCar(ctx context.Context, vin carTypes.Vin) (bool, error) {
 	res, err := c.db.DeleteOne(ctx, c.collection, bson.D{{"_id", vin}})
 	if err != nil {
 		return false, err
 	}
-	return res.DeletedCount > 0, nil
+	if res.DeletedCount == 0 {
+		return false, nil
+	}
+
+	if _, err := c.db.DeleteMany(ctx, c.positionsCollection, bson.D{{"vin", vin}}); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (c *crud) ReadCar(ctx context.Context, vin carTypes.Vin) (carTypes.Car, error) {
-	res := c.db.FindOne(ctx, c.collection, bson.D{{"_id", vin}})
+	car, _, err := c.ReadCarWithRevision(ctx, vin)
+	return car, err
+}
+
+func (c *crud) ReadCarWithRevision(ctx context.Context, vin carTypes.Vin) (carTypes.Car, int64, error) {
+	car, err := c.ReadStoredCar(ctx, vin)
+	return car.Car, car.Revision, err
+}
+
+func (c *crud) ReadStoredCar(ctx context.Context, vin carTypes.Vin) (model.StoredCar, error) {
+	res := c.db.FindOne(ctx, c.collection, bson.D{{"_id", vin}, notArchived})
 	var car entities.Car
 	err := res.Decode(&car)
 	if err != nil {
-		return carTypes.Car{}, err
+		return model.StoredCar{}, err
 	}
-	return mappers.MapCarFromDb(&car), nil
+	return mappers.MapStoredCarFromDb(&car), nil
 }
 
-func (c *crud) SetTrunkLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState) error {
-	res, err := c.db.UpdateOne(ctx, c.collection, bson.D{{"_id", vin}}, bson.D{{"mockData_trunkLockState",
-		state}})
+func (c *crud) UpdateStaticData(ctx context.Context, vin carTypes.Vin, original *carTypes.Car,
+	updated *carTypes.Car, revision *int64) (bool, error) {
 
+	changes, err := staticDataChanges(original, updated)
 	if err != nil {
+		return false, err
+	}
+
+	if len(changes) == 0 {
+		// nothing to write, but the caller expects to learn about a missing car or a mismatching revision
+		_, currentRevision, err := c.ReadCarWithRevision(ctx, vin)
+		if err == nil && revision != nil && *revision != currentRevision {
+			return false, &RevisionMismatchError{Vin: vin}
+		}
+		return false, err
+	}
+
+	if err := c.updateCar(ctx, vin, changes, revision); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *crud) SetTrunkLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState,
+	revision *int64) error {
+
+	return c.updateCar(ctx, vin, bson.D{{"mockData_trunkLockState", state}}, revision)
+}
+
+func (c *crud) SetDoorsLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState) error {
+	return c.updateCar(ctx, vin, bson.D{{"mockData_doorsLockState", state}}, nil)
+}
+
+func (c *crud) SetEngineState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataEngineState,
+	revision *int64) error {
+
+	return c.updateCar(ctx, vin, bson.D{{"mockData_engineState", state}}, revision)
+}
+
+func (c *crud) SetDynamicData(ctx context.Context, vin carTypes.Vin, dynamicData *carTypes.DynamicData) error {
+	return c.updateCar(ctx, vin, mappers.MapDynamicDataToDb(dynamicData), nil)
+}
+
+func (c *crud) ReadCarsWithRunningEngine(ctx context.Context) ([]model.CarWithRevision, error) {
+	var cars []entities.Car
+	if err := c.db.Find(ctx, c.collection, bson.D{{"mockData_engineState", entities.ON}, notArchived},
+		&cars); err != nil {
+		return nil, err
+	}
+	result := make([]model.CarWithRevision, len(cars))
+	for i := range cars {
+		result[i] = model.CarWithRevision{Car: mappers.MapCarFromDb(&cars[i]), Revision: cars[i].Revision}
+	}
+	return result, nil
+}
+
+func (c *crud) SetPositionAndFuelLevel(ctx context.Context, vin carTypes.Vin, position carTypes.DynamicDataPosition,
+	fuelLevelPercentage int, revision *int64) error {
+
+	return c.updateCar(ctx, vin, bson.D{
+		{"mockData_position", mappers.MapPositionToDb(&position)},
+		{"mockData_fuelLevelPercentage", fuelLevelPercentage},
+	}, revision)
+}
+
+func (c *crud) AddPositionRecord(ctx context.Context, vin carTypes.Vin, record *model.PositionRecord) error {
+	_, err := c.db.Insert(ctx, c.positionsCollection, mappers.MapPositionRecordToDb(vin, record))
+	return err
+}
+
+func (c *crud) ReadPositionRecords(ctx context.Context, vin carTypes.Vin, from *time.Time, to *time.Time) (
+	[]model.PositionRecord, error) {
+
+	filter := bson.D{{"vin", vin}}
+	timeRange := bson.D{}
+	if from != nil {
+		timeRange = append(timeRange, bson.E{"$gte", *from})
+	}
+	if to != nil {
+		timeRange = append(timeRange, bson.E{"$lte", *to})
+	}
+	if len(timeRange) > 0 {
+		filter = append(filter, bson.E{"timestamp", timeRange})
+	}
+
+	var records []entities.PositionRecord
+	opts := options.Find().SetSort(bson.D{{"timestamp", 1}})
+	if err := c.db.Find(ctx, c.positionsCollection, filter, &records, opts); err != nil {
+		return nil, err
+	}
+
+	result := make([]model.PositionRecord, len(records))
+	for i := range records {
+		result[i] = mappers.MapPositionRecordFromDb(&records[i])
+	}
+	return result, nil
+}
+
+func (c *crud) CreateIdempotencyRecord(ctx context.Context, key string, requestHash string) error {
+	now := time.Now().UTC()
+	_, err := c.db.Insert(ctx, c.idempotencyCollection, entities.IdempotencyRecord{
+		Key:            key,
+		RequestHash:    requestHash,
+		CreatedAt:      now,
+		LeaseExpiresAt: now.Add(idempotencyLease),
+	})
+	if !IsDuplicateKeyError(err) {
 		return err
 	}
 
+	// a record without a response whose lease has expired (or that has no lease) belongs to an abandoned request
+	res, updateErr := c.db.UpdateOne(ctx, c.idempotencyCollection,
+		bson.D{{"_id", key}, {"response", nil}, {"leaseExpiresAt", bson.D{{"$not", bson.D{{"$gte", now}}}}}},
+		bson.D{{"requestHash", requestHash}, {"createdAt", now}, {"leaseExpiresAt", now.Add(idempotencyLease)}})
+	if updateErr != nil {
+		return updateErr
+	}
 	if res.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+		return err
+	}
+	return nil
+}
+
+func (c *crud) ReadIdempotencyRecord(ctx context.Context, key string) (model.IdempotencyRecord, error) {
+	var record entities.IdempotencyRecord
+	if err := c.db.FindOne(ctx, c.idempotencyCollection, bson.D{{"_id", key}}).Decode(&record); err != nil {
+		return model.IdempotencyRecord{}, err
+	}
+	return mappers.MapIdempotencyRecordFromDb(&record), nil
+}
+
+func (c *crud) SetIdempotentResponse(ctx context.Context, key string, response *model.IdempotentResponse) error {
+	_, err := c.db.UpdateOne(ctx, c.idempotencyCollection, bson.D{{"_id", key}},
+		bson.D{{"response", mappers.MapIdempotentResponseToDb(response)}})
+	return err
+}
+
+func (c *crud) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	_, err := c.db.DeleteOne(ctx, c.idempotencyCollection, bson.D{{"_id", key}})
+	return err
+}
+
+// findCars returns the cars matching the given filter. If fields is not nil, only these fields are read from the
+// database.
+func (c *crud) findCars(ctx context.Context, filter bson.D, fields []string, opts *options.FindOptions) (
+	[]carTypes.Car, error) {
+
+	if fields != nil {
+		projection := bson.D{}
+		for _, key := range mappers.MapFieldsToDb(fields) {
+			projection = append(projection, bson.E{key, 1})
+		}
+		opts.SetProjection(projection)
+	}
+
+	var cars []entities.Car
+	if err := c.db.Find(ctx, c.collection, filter, &cars, opts); err != nil {
+		return nil, err
+	}
+	result := make([]carTypes.Car, len(cars))
+	for i := range cars {
+		result[i] = mappers.MapCarFromDb(&cars[i])
+	}
+	return result, nil
+}
+
+// nearFilter returns the conditions of a database query for cars matching the given filter within the given radius
+// in meters around the given position. $nearSphere already sorts the cars by distance.
+func nearFilter(position carTypes.DynamicDataPosition, radius float64, filter *model.CarFilter) bson.D {
+	conditions := bson.D{{"mockData_position", bson.D{{"$nearSphere", bson.D{
+		{"$geometry", mappers.MapPositionToDb(&position)},
+		{"$maxDistance", radius},
+	}}}}}
+	return append(conditions, mapCarFilter(filter)...)
+}
+
+// mapCarFilter maps the filter to the conditions of a database query. Text attributes are compared ignoring case.
+// Unless archived cars are included, an empty filter results in the single condition notArchived.
+func mapCarFilter(filter *model.CarFilter) bson.D {
+	conditions := bson.D{}
+	addText := func(key string, value *string) {
+		if value != nil {
+			pattern := "^" + regexp.QuoteMeta(*value) + "$"
+			conditions = append(conditions, bson.E{key, primitive.Regex{Pattern: pattern, Options: "i"}})
+		}
+	}
+	addMin := func(key string, value *int) {
+		if value != nil {
+			conditions = append(conditions, bson.E{key, bson.D{{"$gte", *value}}})
+		}
+	}
+
+	addText("brand", filter.Brand)
+	addText("model", filter.Model)
+	addText("technicalSpecification_color", filter.Color)
+	if filter.Fuel != nil {
+		conditions = append(conditions, bson.E{"technicalSpecification_fuel", entities.Fuel(*filter.Fuel)})
+	}
+	if filter.Transmission != nil {
+		conditions = append(conditions,
+			bson.E{"technicalSpecification_transmission", entities.Transmission(*filter.Transmission)})
+	}
+	addMin("technicalSpecification_numberOfSeats", filter.MinSeats)
+	addMin("technicalSpecification_trunkVolume", filter.MinTrunkVolume)
+	if !filter.IncludeArchived {
+		conditions = append(conditions, notArchived)
+	}
+
+	return conditions
+}
+
+// staticDataChanges returns the flattened database fields of the static data that differ between both cars.
+func staticDataChanges(original *carTypes.Car, updated *carTypes.Car) (bson.D, error) {
+	originalDocument, err := bson.Marshal(mappers.MapCarToDb(original))
+	if err != nil {
+		return nil, err
+	}
+	updatedDocument, err := bson.Marshal(mappers.MapCarToDb(updated))
+	if err != nil {
+		return nil, err
+	}
+
+	updatedElements, err := bson.Raw(updatedDocument).Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := bson.D{}
+	for _, element := range updatedElements {
+		key := element.Key()
+		// the VIN identifies the car, the revision is maintained by updateCar and the dynamic data is set by
+		// MapCarToDb to initial values
+		if key == "_id" || key == "revision" || strings.HasPrefix(key, "mockData_") {
+			continue
+		}
+		if !bson.Raw(originalDocument).Lookup(key).Equal(element.Value()) {
+			changes = append(changes, bson.E{key, element.Value()})
+		}
+	}
+	return changes, nil
+}
+
+// mapVins extracts the VINs from documents that only consist of an _id field.
+func mapVins(ids []bson.M) []carTypes.Vin {
+	vins := make([]carTypes.Vin, len(ids))
+	for i, id := range ids {
+		vins[i] = id["_id"].(carTypes.Vin)
+	}
+	return vins
+}
+
+// updateCar sets the given fields of the car with the given VIN and increases its revision. If revision is not nil,
+// the car is only updated if it still has this revision. If the car does not exist, mongo.ErrNoDocuments is returned,
+// if the revision does not match, a RevisionMismatchError is returned.
+func (c *crud) updateCar(ctx context.Context, vin carTypes.Vin, update interface{}, revision *int64) error {
+	res, err := c.db.UpdateOneAndIncrement(ctx, c.collection, carFilter(vin, revision), update, "revision")
+
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return c.noMatchError(ctx, vin, revision)
 	}
 
 	return nil
 }
+
+// noMatchError determines why a write with the filter of carFilter did not match any car. Without an expected
+// revision, the car cannot exist. Otherwise, the car is read to distinguish a missing car from a mismatching revision.
+func (c *crud) noMatchError(ctx context.Context, vin carTypes.Vin, revision *int64) error {
+	if revision == nil {
+		return mongo.ErrNoDocuments
+	}
+	if _, err := c.ReadCar(ctx, vin); err != nil {
+		return err
+	}
+	return &RevisionMismatchError{Vin: vin}
+}
+
+// carFilter returns the conditions of a database query for the car with the given VIN that is not archived. If
+// revision is not nil, the car must also have this revision. Cars stored before revisions were introduced have no
+// revision field, which is read as revision 0.
+func carFilter(vin carTypes.Vin, revision *int64) bson.D {
+	filter := bson.D{{"_id", vin}, notArchived}
+	if revision == nil {
+		return filter
+	}
+	if *revision == 0 {
+		return append(filter, bson.E{"revision", bson.D{{"$exists", false}}})
+	}
+	return append(filter, bson.E{"revision", *revision})
+}
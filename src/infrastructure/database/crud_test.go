@@ -1,7 +1,9 @@
 package database
 
 import (
+	"DCar/infrastructure/database/entities"
 	"DCar/infrastructure/database/mappers"
+	"DCar/logic/model"
 	"DCar/mocks"
 	"context"
 	"errors"
@@ -13,10 +15,14 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const collectionName = "cars"
+const positionsCollectionName = "positions"
+const idempotencyCollectionName = "idempotencyKeys"
 
 type TestCrudConfig struct{}
 
@@ -29,9 +35,9 @@ var config = &TestCrudConfig{}
 var exampleModelCar = carTypes.Car{
 	Brand: "Volkswagen",
 	DynamicData: carTypes.DynamicData{
-		DoorsLockState:      carTypes.UNLOCKED,
+		DoorsLockState:      carTypes.LOCKED,
 		EngineState:         carTypes.OFF,
-		FuelLevelPercentage: 23,
+		FuelLevelPercentage: 100,
 		Position: carTypes.DynamicDataPosition{
 			Latitude:  49.0069,
 			Longitude: 8.4037,
@@ -115,6 +121,128 @@ func TestCrud_CreateCar_dbError(t *testing.T) {
 	assert.Equal(t, "", vin)
 }
 
+func TestCrud_CreateCar_duplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	duplicateError := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Insert(ctx, collectionName, mappers.MapCarToDb(&exampleModelCar)).
+		Return(nil, duplicateError)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", exampleModelCar.Vin}, {"deletedAt", bson.D{{"$ne", nil}}}}).
+		Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	_, err := crud.CreateCar(ctx, &exampleModelCar)
+
+	assert.True(t, IsDuplicateKeyError(err))
+	assert.False(t, IsArchivedCarError(err))
+}
+
+func TestCrud_CreateCar_archived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	duplicateError := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Insert(ctx, collectionName, mappers.MapCarToDb(&exampleModelCar)).
+		Return(nil, duplicateError)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", exampleModelCar.Vin}, {"deletedAt", bson.D{{"$ne", nil}}}}).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	_, err := crud.CreateCar(ctx, &exampleModelCar)
+
+	// the error is still a duplicate key error for callers that do not care about archived cars
+	assert.True(t, IsArchivedCarError(err))
+	assert.True(t, IsDuplicateKeyError(err))
+}
+
+func TestCrud_CreateCars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	otherCar := exampleModelCar
+	otherCar.Vin = "12345678901234568"
+
+	duplicateError := mongo.WriteError{Index: 1, Code: 11000}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		InsertMany(ctx, collectionName, []interface{}{
+			mappers.MapCarToDb(&exampleModelCar),
+			mappers.MapCarToDb(&otherCar),
+			mappers.MapCarToDb(&exampleModelCar),
+		}).
+		Return(nil, mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: duplicateError}}})
+
+	crud := NewICRUD(mockConnection, config)
+	carErrors, err := crud.CreateCars(ctx, []carTypes.Car{exampleModelCar, otherCar, exampleModelCar})
+
+	assert.Nil(t, err)
+	assert.Len(t, carErrors, 3)
+	assert.Nil(t, carErrors[0])
+	assert.True(t, IsDuplicateKeyError(carErrors[1]))
+	assert.Nil(t, carErrors[2])
+}
+
+func TestCrud_CreateCars_empty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the database rejects empty inserts
+	mockConnection := mocks.NewMockIConnection(ctrl)
+
+	crud := NewICRUD(mockConnection, config)
+	carErrors, err := crud.CreateCars(context.Background(), []carTypes.Car{})
+
+	assert.Nil(t, err)
+	assert.Empty(t, carErrors)
+}
+
+func TestCrud_CreateCars_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	for _, insertError := range []error{
+		dbError,
+		mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{Code: 64}},
+	} {
+		mockConnection := mocks.NewMockIConnection(ctrl)
+		mockConnection.
+			EXPECT().
+			InsertMany(ctx, collectionName, gomock.Any()).
+			Return(nil, insertError)
+
+		crud := NewICRUD(mockConnection, config)
+		carErrors, err := crud.CreateCars(ctx, []carTypes.Car{exampleModelCar})
+
+		assert.Equal(t, insertError, err)
+		assert.Nil(t, carErrors)
+	}
+}
+
 func TestCrud_CreateCar_conversionError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -157,7 +285,7 @@ func TestCrud_ReadAllVins_NoErrors(t *testing.T) {
 
 	mockConnection := mocks.NewMockIConnection(ctrl)
 
-	mockGetIds := func(ctx context.Context, collection string, resultIds *[]bson.M) error {
+	mockGetIds := func(ctx context.Context, collection string, filter bson.D, resultIds *[]bson.M) error {
 		// increase slice length by 2 to provide space for the result
 		*resultIds = append(*resultIds, nil, nil)
 		copy(*resultIds, returnArray[:])
@@ -166,7 +294,7 @@ func TestCrud_ReadAllVins_NoErrors(t *testing.T) {
 
 	mockConnection.
 		EXPECT().
-		GetIDs(ctx, collectionName, gomock.Any()).
+		GetIDs(ctx, collectionName, bson.D{notArchived}, gomock.Any()).
 		DoAndReturn(mockGetIds)
 
 	crud := NewICRUD(mockConnection, config)
@@ -188,7 +316,7 @@ func TestCrud_ReadAllVins_dbError(t *testing.T) {
 
 	mockConnection.
 		EXPECT().
-		GetIDs(ctx, collectionName, gomock.Any()).
+		GetIDs(ctx, collectionName, bson.D{notArchived}, gomock.Any()).
 		Return(dbError)
 
 	crud := NewICRUD(mockConnection, config)
@@ -198,28 +326,63 @@ func TestCrud_ReadAllVins_dbError(t *testing.T) {
 	assert.Nil(t, vins)
 }
 
-func TestCrud_DeleteCarSuccess(t *testing.T) {
+func TestCrud_ReadVinsMatching(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
+	brand := "Volkswagen"
+	carModel := "Golf (Mk 8)"
+	color := "black"
+	fuel := carTypes.ELECTRIC
+	transmission := carTypes.AUTOMATIC
+	minSeats := 7
+	minTrunkVolume := 400
+
+	filter := model.CarFilter{
+		Brand:          &brand,
+		Model:          &carModel,
+		Color:          &color,
+		Fuel:           &fuel,
+		Transmission:   &transmission,
+		MinSeats:       &minSeats,
+		MinTrunkVolume: &minTrunkVolume,
+	}
+
+	expectedFilter := bson.D{
+		{"brand", primitive.Regex{Pattern: "^Volkswagen$", Options: "i"}},
+		{"model", primitive.Regex{Pattern: `^Golf \(Mk 8\)$`, Options: "i"}},
+		{"technicalSpecification_color", primitive.Regex{Pattern: "^black$", Options: "i"}},
+		{"technicalSpecification_fuel", entities.ELECTRIC},
+		{"technicalSpecification_transmission", entities.AUTOMATIC},
+		{"technicalSpecification_numberOfSeats", bson.D{{"$gte", 7}}},
+		{"technicalSpecification_trunkVolume", bson.D{{"$gte", 400}}},
+		notArchived,
+	}
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, bson.D{{"_id", 1}}, opts[0].Projection)
+		*results.(*[]bson.M) = []bson.M{{"_id": "WV2YB0257EH008533"}}
+		return nil
+	}
+
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
-		Return(&mongo.DeleteResult{
-			DeletedCount: 1,
-		}, nil)
+		Find(ctx, collectionName, expectedFilter, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
 
 	crud := NewICRUD(mockConnection, config)
-	success, err := crud.DeleteCar(ctx, "12345678901234567")
+	vins, err := crud.ReadVinsMatching(ctx, filter)
 
 	assert.Nil(t, err)
-	assert.True(t, success)
+	assert.Equal(t, []carTypes.Vin{"WV2YB0257EH008533"}, vins)
 }
 
-func TestCrud_DeleteCar_dbError(t *testing.T) {
+func TestCrud_ReadVinsMatching_dbError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -230,17 +393,17 @@ func TestCrud_DeleteCar_dbError(t *testing.T) {
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
-		Return(nil, dbError)
+		Find(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(dbError)
 
 	crud := NewICRUD(mockConnection, config)
-	success, err := crud.DeleteCar(ctx, "12345678901234567")
+	vins, err := crud.ReadVinsMatching(ctx, model.CarFilter{})
 
 	assert.ErrorIs(t, err, dbError)
-	assert.False(t, success)
+	assert.Nil(t, vins)
 }
 
-func TestCrud_DeleteCar_notFound(t *testing.T) {
+func TestCrud_ReadVinsMatching_includeArchived(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -249,115 +412,1809 @@ func TestCrud_DeleteCar_notFound(t *testing.T) {
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
-		Return(&mongo.DeleteResult{
-			DeletedCount: 0,
-		}, nil)
+		Find(ctx, collectionName, bson.D{}, gomock.Any(), gomock.Any()).
+		Return(nil)
 
 	crud := NewICRUD(mockConnection, config)
-	success, err := crud.DeleteCar(ctx, "12345678901234567")
+	vins, err := crud.ReadVinsMatching(ctx, model.CarFilter{IncludeArchived: true})
 
 	assert.Nil(t, err)
-	assert.False(t, success)
+	assert.Empty(t, vins)
 }
 
-func TestCrud_ReadCar_success(t *testing.T) {
+func TestCrud_ReadVinsPage_filter(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
+	minSeats := 7
+
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
-		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+		GetIDsPage(ctx, collectionName, bson.D{{"technicalSpecification_numberOfSeats", bson.D{{"$gte", 7}}}, notArchived},
+			nil, int64(10), gomock.Any()).
+		Return(nil)
 
 	crud := NewICRUD(mockConnection, config)
-	car, err := crud.ReadCar(ctx, "12345678901234567")
+	_, err := crud.ReadVinsPage(ctx, model.CarFilter{MinSeats: &minSeats}, nil, 10)
 
 	assert.Nil(t, err)
-	assert.Equal(t, exampleModelCar, car)
 }
 
-func TestCrud_ReadCar_decodeError(t *testing.T) {
+func TestCrud_ReadVinsPage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
+	mockGetIDsPage := func(ctx context.Context, collection string, filter bson.D, after interface{}, limit int64,
+		resultIds *[]bson.M) error {
+
+		*resultIds = []bson.M{{"_id": "JH4DA1840KS004941"}, {"_id": "WV2YB0257EH008533"}}
+		return nil
+	}
+
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
-		Return(mongo.NewSingleResultFromDocument(nil, errors.New("error"), nil))
+		GetIDsPage(ctx, collectionName, bson.D{notArchived}, nil, int64(2), gomock.Any()).
+		DoAndReturn(mockGetIDsPage)
 
 	crud := NewICRUD(mockConnection, config)
-	car, err := crud.ReadCar(ctx, "12345678901234567")
+	vins, err := crud.ReadVinsPage(ctx, model.CarFilter{}, nil, 2)
 
-	assert.NotNil(t, err)
-	assert.Equal(t, carTypes.Car{}, car)
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Vin{"JH4DA1840KS004941", "WV2YB0257EH008533"}, vins)
 }
 
-func TestCrud_SetTrunkLockState_successChanged(t *testing.T) {
+func TestCrud_ReadVinsPage_after(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
+	after := "JH4DA1840KS004941"
+
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		UpdateOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}},
-			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}).
-		Return(&mongo.UpdateResult{
-			MatchedCount: 1,
-		}, nil)
+		GetIDsPage(ctx, collectionName, bson.D{notArchived}, after, int64(10), gomock.Any()).
+		Return(nil)
 
 	crud := NewICRUD(mockConnection, config)
-	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED)
+	vins, err := crud.ReadVinsPage(ctx, model.CarFilter{}, &after, 10)
 
 	assert.Nil(t, err)
+	assert.Empty(t, vins)
 }
 
-func TestCrud_SetTrunkLockState_errorCarNotFound(t *testing.T) {
+func TestCrud_ReadVinsPage_dbError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
+	dbError := errors.New("db error")
+
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		UpdateOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}},
-			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}).
-		Return(&mongo.UpdateResult{
-			MatchedCount: 0,
-		}, nil)
+		GetIDsPage(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(dbError)
 
 	crud := NewICRUD(mockConnection, config)
-	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED)
+	vins, err := crud.ReadVinsPage(ctx, model.CarFilter{}, nil, 10)
 
-	assert.True(t, IsNotFoundError(err))
+	assert.ErrorIs(t, err, dbError)
+	assert.Nil(t, vins)
 }
 
-func TestCrud_SetTrunkLockState_databaseError(t *testing.T) {
+func TestCrud_ReadVinsNear(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 
-	databaseError := errors.New("database error")
+	position := carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+
+	expectedFilter := bson.D{{"mockData_position", bson.D{{"$nearSphere", bson.D{
+		{"$geometry", entities.Position{
+			Type:        entities.POINT,
+			Coordinates: []float64{float64(float32(8.4037)), float64(float32(49.0069))},
+		}},
+		{"$maxDistance", 1500.0},
+	}}}}, notArchived}
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, bson.D{{"_id", 1}}, opts[0].Projection)
+		*results.(*[]bson.M) = []bson.M{{"_id": "WV2YB0257EH008533"}, {"_id": "JH4DA1840KS004941"}}
+		return nil
+	}
 
 	mockConnection := mocks.NewMockIConnection(ctrl)
 	mockConnection.
 		EXPECT().
-		UpdateOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}},
-			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}).
-		Return(nil, databaseError)
+		Find(ctx, collectionName, expectedFilter, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
 
 	crud := NewICRUD(mockConnection, config)
-	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED)
+	vins, err := crud.ReadVinsNear(ctx, position, 1500, model.CarFilter{})
 
-	assert.ErrorIs(t, err, databaseError)
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Vin{"WV2YB0257EH008533", "JH4DA1840KS004941"}, vins)
+}
+
+func TestCrud_ReadVinsNear_filter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	fuel := carTypes.ELECTRIC
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		conditions := filter.(bson.D)
+		assert.Len(t, conditions, 3)
+		assert.Equal(t, "mockData_position", conditions[0].Key)
+		assert.Equal(t, bson.E{"technicalSpecification_fuel", entities.ELECTRIC}, conditions[1])
+		assert.Equal(t, notArchived, conditions[2])
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	_, err := crud.ReadVinsNear(ctx, carTypes.DynamicDataPosition{}, 1500, model.CarFilter{Fuel: &fuel})
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_ReadVinsNear_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	vins, err := crud.ReadVinsNear(ctx, carTypes.DynamicDataPosition{}, 1500, model.CarFilter{})
+
+	assert.ErrorIs(t, err, dbError)
+	assert.Nil(t, vins)
+}
+
+func TestCrud_ReadCarsMatching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		// all fields are read if no fields are given
+		assert.Nil(t, opts[0].Projection)
+		*results.(*[]entities.Car) = []entities.Car{mappers.MapCarToDb(&exampleModelCar)}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, bson.D{notArchived}, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsMatching(ctx, model.CarFilter{}, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Car{exampleModelCar}, cars)
+}
+
+func TestCrud_ReadCarsMatching_fields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	minSeats := 7
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, bson.D{{"_id", 1}, {"brand", 1}, {"mockData_position", 1}}, opts[0].Projection)
+		*results.(*[]entities.Car) = []entities.Car{{Vin: exampleModelCar.Vin, Brand: exampleModelCar.Brand}}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, bson.D{{"technicalSpecification_numberOfSeats", bson.D{{"$gte", 7}}}, notArchived},
+			gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsMatching(ctx, model.CarFilter{MinSeats: &minSeats},
+		[]string{"vin", "brand", "dynamicData.position"})
+
+	assert.Nil(t, err)
+	assert.Len(t, cars, 1)
+	assert.Equal(t, exampleModelCar.Vin, cars[0].Vin)
+	assert.Equal(t, exampleModelCar.Brand, cars[0].Brand)
+}
+
+func TestCrud_ReadCarsMatching_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsMatching(ctx, model.CarFilter{}, nil)
+
+	assert.ErrorIs(t, err, dbError)
+	assert.Nil(t, cars)
+}
+
+func TestCrud_ReadCarsPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	after := "JH4DA1840KS004941"
+	fuel := carTypes.ELECTRIC
+
+	expectedFilter := bson.D{
+		{"technicalSpecification_fuel", entities.ELECTRIC},
+		notArchived,
+		{"_id", bson.D{{"$gt", after}}},
+	}
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, bson.D{{"_id", 1}}, opts[0].Sort)
+		assert.Equal(t, int64(10), *opts[0].Limit)
+		assert.Equal(t, bson.D{{"_id", 1}, {"model", 1}}, opts[0].Projection)
+		*results.(*[]entities.Car) = []entities.Car{{Vin: exampleModelCar.Vin, Model: exampleModelCar.Model}}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, expectedFilter, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsPage(ctx, model.CarFilter{Fuel: &fuel}, &after, 10, []string{"vin", "model"})
+
+	assert.Nil(t, err)
+	assert.Len(t, cars, 1)
+	assert.Equal(t, exampleModelCar.Model, cars[0].Model)
+}
+
+func TestCrud_ReadCarsNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	position := carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, "mockData_position", filter.(bson.D)[0].Key)
+		assert.Nil(t, opts[0].Projection)
+		*results.(*[]entities.Car) = []entities.Car{mappers.MapCarToDb(&exampleModelCar)}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsNear(ctx, position, 1500, model.CarFilter{}, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Car{exampleModelCar}, cars)
+}
+
+func TestCrud_ForEachCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	otherCar := exampleModelCar
+	otherCar.Vin = "12345678901234568"
+
+	mockFindEach := func(ctx context.Context, collection string, filter interface{}, each func(bson.Raw) error,
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, bson.D{{"_id", 1}}, opts[0].Sort)
+		for _, car := range []carTypes.Car{exampleModelCar, otherCar} {
+			document, err := bson.Marshal(mappers.MapCarToDb(&car))
+			assert.Nil(t, err)
+			if err := each(document); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindEach(ctx, collectionName, bson.D{notArchived}, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFindEach)
+
+	crud := NewICRUD(mockConnection, config)
+
+	var cars []carTypes.Car
+	err := crud.ForEachCar(ctx, func(car *carTypes.Car) error {
+		cars = append(cars, *car)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Car{exampleModelCar, otherCar}, cars)
+}
+
+func TestCrud_ForEachCar_eachError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	eachError := errors.New("each error")
+
+	mockFindEach := func(ctx context.Context, collection string, filter interface{}, each func(bson.Raw) error,
+		opts ...*options.FindOptions) error {
+
+		document, err := bson.Marshal(mappers.MapCarToDb(&exampleModelCar))
+		assert.Nil(t, err)
+		return each(document)
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindEach(ctx, collectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFindEach)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.ForEachCar(ctx, func(car *carTypes.Car) error {
+		return eachError
+	})
+
+	assert.ErrorIs(t, err, eachError)
+}
+
+func TestCrud_PurgeCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
+		Return(&mongo.DeleteResult{
+			DeletedCount: 1,
+		}, nil)
+	mockConnection.
+		EXPECT().
+		DeleteMany(ctx, positionsCollectionName, bson.D{{"vin", "12345678901234567"}}).
+		Return(&mongo.DeleteResult{
+			DeletedCount: 3,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.PurgeCar(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.True(t, success)
+}
+
+func TestCrud_PurgeCar_historyDbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
+		Return(&mongo.DeleteResult{
+			DeletedCount: 1,
+		}, nil)
+	mockConnection.
+		EXPECT().
+		DeleteMany(ctx, positionsCollectionName, bson.D{{"vin", "12345678901234567"}}).
+		Return(nil, dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.PurgeCar(ctx, "12345678901234567")
+
+	assert.ErrorIs(t, err, dbError)
+	assert.False(t, success)
+}
+
+func TestCrud_PurgeCar_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
+		Return(nil, dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.PurgeCar(ctx, "12345678901234567")
+
+	assert.ErrorIs(t, err, dbError)
+	assert.False(t, success)
+}
+
+func TestCrud_PurgeCar_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		DeleteOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}}).
+		Return(&mongo.DeleteResult{
+			DeletedCount: 0,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.PurgeCar(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.False(t, success)
+}
+
+func TestCrud_ArchiveCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockUpdate := func(ctx context.Context, collection string, filter interface{}, update interface{},
+		counter string) (*mongo.UpdateResult, error) {
+
+		// the position history is kept, only the deletion time is set
+		updateDocument := update.(bson.D)
+		assert.Len(t, updateDocument, 1)
+		assert.Equal(t, "deletedAt", updateDocument[0].Key)
+		assert.WithinDuration(t, time.Now(), updateDocument[0].Value.(time.Time), time.Minute)
+		return &mongo.UpdateResult{MatchedCount: 1}, nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}, gomock.Any(),
+			"revision").
+		DoAndReturn(mockUpdate)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.ArchiveCar(ctx, "12345678901234567", nil)
+
+	assert.Nil(t, err)
+	assert.True(t, success)
+}
+
+func TestCrud_ArchiveCar_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.ArchiveCar(ctx, "12345678901234567", nil)
+
+	assert.Nil(t, err)
+	assert.False(t, success)
+}
+
+func TestCrud_ArchiveCar_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(nil, dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.ArchiveCar(ctx, "12345678901234567", nil)
+
+	assert.ErrorIs(t, err, dbError)
+	assert.False(t, success)
+}
+
+func TestCrud_ArchiveCar_revisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName,
+			bson.D{{"_id", "12345678901234567"}, notArchived, {"revision", int64(3)}}, gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.ArchiveCar(ctx, "12345678901234567", &revision)
+
+	assert.True(t, IsRevisionMismatchError(err))
+	assert.False(t, success)
+}
+
+func TestCrud_ArchiveCar_revisionCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, gomock.Any()).
+		Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.ArchiveCar(ctx, "12345678901234567", &revision)
+
+	assert.Nil(t, err)
+	assert.False(t, success)
+}
+
+func TestCrud_RestoreCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName,
+			bson.D{{"_id", "12345678901234567"}, {"deletedAt", bson.D{{"$ne", nil}}}}, bson.D{{"deletedAt", nil}},
+			"revision").
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.RestoreCar(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.True(t, success)
+}
+
+func TestCrud_RestoreCar_notArchived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.RestoreCar(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.False(t, success)
+}
+
+func TestCrud_RestoreCar_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(nil, dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	success, err := crud.RestoreCar(ctx, "12345678901234567")
+
+	assert.ErrorIs(t, err, dbError)
+	assert.False(t, success)
+}
+
+func TestCrud_ReadCar_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	car, err := crud.ReadCar(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.Equal(t, exampleModelCar, car)
+}
+
+func TestCrud_ReadCarWithRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	databaseCar := mappers.MapCarToDb(&exampleModelCar)
+	databaseCar.Revision = 42
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(databaseCar, nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	car, revision, err := crud.ReadCarWithRevision(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.Equal(t, exampleModelCar, car)
+	assert.Equal(t, int64(42), revision)
+}
+
+func TestCrud_ReadStoredCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	databaseCar := mappers.MapCarToDb(&exampleModelCar)
+	databaseCar.Revision = 42
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(databaseCar, nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	car, err := crud.ReadStoredCar(ctx, "12345678901234567")
+
+	assert.Nil(t, err)
+	assert.Equal(t, mappers.MapStoredCarFromDb(&databaseCar), car)
+	assert.NotNil(t, car.TireSpecification)
+}
+
+func TestCrud_ReadCar_decodeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(nil, errors.New("error"), nil))
+
+	crud := NewICRUD(mockConnection, config)
+	car, err := crud.ReadCar(ctx, "12345678901234567")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, carTypes.Car{}, car)
+}
+
+func TestCrud_UpdateStaticData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	updated := exampleModelCar
+	updated.Brand = "Audi"
+	updated.TechnicalSpecification.Color = "red"
+	// the dynamic data is never written
+	updated.DynamicData.EngineState = carTypes.ON
+
+	mockUpdateOne := func(ctx context.Context, collection string, filter interface{}, update interface{},
+		counter string) (*mongo.UpdateResult, error) {
+
+		changes := update.(bson.D)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "brand", changes[0].Key)
+		assert.Equal(t, "Audi", changes[0].Value.(bson.RawValue).StringValue())
+		assert.Equal(t, "technicalSpecification_color", changes[1].Key)
+		assert.Equal(t, "red", changes[1].Value.(bson.RawValue).StringValue())
+		return &mongo.UpdateResult{MatchedCount: 1}, nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", exampleModelCar.Vin}, notArchived}, gomock.Any(),
+			"revision").
+		DoAndReturn(mockUpdateOne)
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &updated, nil)
+
+	assert.Nil(t, err)
+	assert.True(t, changed)
+}
+
+func TestCrud_UpdateStaticData_nestedField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	updated := exampleModelCar
+	updated.TechnicalSpecification.Tire.Type = "205/55R16"
+
+	mockUpdateOne := func(ctx context.Context, collection string, filter interface{}, update interface{},
+		counter string) (*mongo.UpdateResult, error) {
+
+		// the flattened field is written as a whole
+		changes := update.(bson.D)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, "technicalSpecification_tire", changes[0].Key)
+
+		var tire entities.Tire
+		assert.Nil(t, changes[0].Value.(bson.RawValue).Unmarshal(&tire))
+		assert.Equal(t, entities.Tire{Manufacturer: exampleModelCar.TechnicalSpecification.Tire.Manufacturer,
+			Type: "205/55R16", Specification: &entities.TireSpecification{Width: 205, AspectRatio: 55,
+				Construction: "R", RimDiameter: 16}}, tire)
+		return &mongo.UpdateResult{MatchedCount: 1}, nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		DoAndReturn(mockUpdateOne)
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &updated, nil)
+
+	assert.Nil(t, err)
+	assert.True(t, changed)
+}
+
+func TestCrud_UpdateStaticData_fuelCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	updated := exampleModelCar
+	updated.TechnicalSpecification.FuelCapacity = "85.2kWh"
+
+	mockUpdateOne := func(ctx context.Context, collection string, filter interface{}, update interface{},
+		counter string) (*mongo.UpdateResult, error) {
+
+		// the parsed capacities are written with the fuel capacity, the missing liters are written as null
+		changes := update.(bson.D)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "technicalSpecification_fuelCapacity", changes[0].Key)
+		assert.Equal(t, "technicalSpecification_fuelCapacityValues", changes[1].Key)
+
+		values := changes[1].Value.(bson.RawValue).Document()
+		assert.Equal(t, bson.TypeNull, values.Lookup("liters").Type)
+		assert.Equal(t, 85.2, values.Lookup("kiloWattHours").Double())
+		return &mongo.UpdateResult{MatchedCount: 1}, nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		DoAndReturn(mockUpdateOne)
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &updated, nil)
+
+	assert.Nil(t, err)
+	assert.True(t, changed)
+}
+
+func TestCrud_UpdateStaticData_unchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// nothing is written, but the existence of the car is checked
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", exampleModelCar.Vin}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &exampleModelCar, nil)
+
+	assert.Nil(t, err)
+	assert.False(t, changed)
+}
+
+func TestCrud_UpdateStaticData_unchangedCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, gomock.Any()).
+		Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &exampleModelCar, nil)
+
+	assert.True(t, IsNotFoundError(err))
+	assert.False(t, changed)
+}
+
+func TestCrud_UpdateStaticData_carNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	updated := exampleModelCar
+	updated.Model = "Passat"
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &updated, nil)
+
+	assert.True(t, IsNotFoundError(err))
+	assert.False(t, changed)
+}
+
+func TestCrud_UpdateStaticData_unchangedRevisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(2)
+
+	// MapCarToDb creates the first revision
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", exampleModelCar.Vin}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	changed, err := crud.UpdateStaticData(ctx, exampleModelCar.Vin, &exampleModelCar, &exampleModelCar, &revision)
+
+	assert.True(t, IsRevisionMismatchError(err))
+	assert.False(t, changed)
+}
+
+func TestCrud_SetTrunkLockState_successChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, nil)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetTrunkLockState_errorCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, nil)
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetTrunkLockState_databaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	databaseError := errors.New("database error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}, "revision").
+		Return(nil, databaseError)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, nil)
+
+	assert.ErrorIs(t, err, databaseError)
+}
+
+func TestCrud_SetTrunkLockState_revision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(5)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived, {"revision", int64(5)}},
+			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, &revision)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetTrunkLockState_revisionOfLegacyCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(0)
+
+	// cars stored before revisions were introduced have no revision field
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName,
+			bson.D{{"_id", "12345678901234567"}, notArchived, {"revision", bson.D{{"$exists", false}}}},
+			bson.D{{"mockData_trunkLockState", carTypes.UNLOCKED}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, &revision)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetTrunkLockState_revisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(5)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, &revision)
+
+	assert.True(t, IsRevisionMismatchError(err))
+}
+
+func TestCrud_SetTrunkLockState_revisionCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(5)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, gomock.Any(), gomock.Any(), "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, gomock.Any()).
+		Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetTrunkLockState(ctx, "12345678901234567", carTypes.UNLOCKED, &revision)
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetDoorsLockState_successChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_doorsLockState", carTypes.UNLOCKED}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetDoorsLockState(ctx, "12345678901234567", carTypes.UNLOCKED)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetDoorsLockState_errorCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_doorsLockState", carTypes.UNLOCKED}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetDoorsLockState(ctx, "12345678901234567", carTypes.UNLOCKED)
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetDoorsLockState_databaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	databaseError := errors.New("database error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_doorsLockState", carTypes.UNLOCKED}}, "revision").
+		Return(nil, databaseError)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetDoorsLockState(ctx, "12345678901234567", carTypes.UNLOCKED)
+
+	assert.ErrorIs(t, err, databaseError)
+}
+
+func TestCrud_SetEngineState_successChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_engineState", carTypes.ON}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetEngineState(ctx, "12345678901234567", carTypes.ON, nil)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetEngineState_revision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, carFilter("12345678901234567", &revision),
+			bson.D{{"mockData_engineState", carTypes.ON}}, "revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, gomock.Any()).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetEngineState(ctx, "12345678901234567", carTypes.ON, &revision)
+
+	assert.True(t, IsRevisionMismatchError(err))
+}
+
+func TestCrud_SetEngineState_errorCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_engineState", carTypes.ON}}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetEngineState(ctx, "12345678901234567", carTypes.ON, nil)
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetEngineState_databaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	databaseError := errors.New("database error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{{"mockData_engineState", carTypes.ON}}, "revision").
+		Return(nil, databaseError)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetEngineState(ctx, "12345678901234567", carTypes.ON, nil)
+
+	assert.ErrorIs(t, err, databaseError)
+}
+
+func TestCrud_SetDynamicData_successChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			mappers.MapDynamicDataToDb(&exampleModelCar.DynamicData), "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetDynamicData(ctx, "12345678901234567", &exampleModelCar.DynamicData)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetDynamicData_errorCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			mappers.MapDynamicDataToDb(&exampleModelCar.DynamicData), "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetDynamicData(ctx, "12345678901234567", &exampleModelCar.DynamicData)
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetDynamicData_databaseError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	databaseError := errors.New("database error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			mappers.MapDynamicDataToDb(&exampleModelCar.DynamicData), "revision").
+		Return(nil, databaseError)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetDynamicData(ctx, "12345678901234567", &exampleModelCar.DynamicData)
+
+	assert.ErrorIs(t, err, databaseError)
+}
+
+func TestCrud_ReadCarsWithRunningEngine_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	runningCar := exampleModelCar
+	runningCar.DynamicData.EngineState = carTypes.ON
+
+	runningDatabaseCar := mappers.MapCarToDb(&exampleModelCar)
+	runningDatabaseCar.DynamicData.EngineState = entities.ON
+	runningDatabaseCar.Revision = 4
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		*results.(*[]entities.Car) = []entities.Car{runningDatabaseCar}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, bson.D{{"mockData_engineState", entities.ON}, notArchived}, gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsWithRunningEngine(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.CarWithRevision{{Car: runningCar, Revision: 4}}, cars)
+}
+
+func TestCrud_ReadCarsWithRunningEngine_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, collectionName, bson.D{{"mockData_engineState", entities.ON}, notArchived}, gomock.Any()).
+		Return(dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	cars, err := crud.ReadCarsWithRunningEngine(ctx)
+
+	assert.ErrorIs(t, err, dbError)
+	assert.Nil(t, cars)
+}
+
+func TestCrud_SetPositionAndFuelLevel_success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	position := carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived},
+			bson.D{
+				{"mockData_position", entities.Position{
+					Type:        entities.POINT,
+					Coordinates: []float64{float64(float32(13.405)), float64(float32(52.52))},
+				}},
+				{"mockData_fuelLevelPercentage", 42},
+			}, "revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 1,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetPositionAndFuelLevel(ctx, "12345678901234567", position, 42, nil)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_SetPositionAndFuelLevel_errorCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, bson.D{{"_id", "12345678901234567"}, notArchived}, gomock.Any(),
+			"revision").
+		Return(&mongo.UpdateResult{
+			MatchedCount: 0,
+		}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetPositionAndFuelLevel(ctx, "12345678901234567", carTypes.DynamicDataPosition{}, 42, nil)
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetPositionAndFuelLevel_revision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOneAndIncrement(ctx, collectionName, carFilter("12345678901234567", &revision), gomock.Any(),
+			"revision").
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, collectionName, gomock.Any()).
+		Return(mongo.NewSingleResultFromDocument(mappers.MapCarToDb(&exampleModelCar), nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.SetPositionAndFuelLevel(ctx, "12345678901234567", carTypes.DynamicDataPosition{}, 42, &revision)
+
+	assert.True(t, IsRevisionMismatchError(err))
+}
+
+func TestCrud_CreateIndexes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		CreateIndex(ctx, collectionName, mongo.IndexModel{Keys: bson.D{{"mockData_position", "2dsphere"}}}).
+		Return(nil)
+	mockConnection.
+		EXPECT().
+		CreateIndex(ctx, positionsCollectionName, mongo.IndexModel{Keys: bson.D{{"vin", 1}, {"timestamp", 1}}}).
+		Return(nil)
+	mockConnection.
+		EXPECT().
+		CreateIndex(ctx, idempotencyCollectionName, mongo.IndexModel{
+			Keys:    bson.D{{"createdAt", 1}},
+			Options: options.Index().SetExpireAfterSeconds(86400),
+		}).
+		Return(nil)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.Nil(t, crud.CreateIndexes(ctx))
+}
+
+func TestCrud_CreateIndexes_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		CreateIndex(ctx, collectionName, gomock.Any()).
+		Return(dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.ErrorIs(t, crud.CreateIndexes(ctx), dbError)
+}
+
+func TestCrud_CreateIdempotencyRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockInsert := func(ctx context.Context, collection string, document interface{}) (*mongo.InsertOneResult,
+		error) {
+
+		record := document.(entities.IdempotencyRecord)
+		assert.Equal(t, "key", record.Key)
+		assert.Equal(t, "hash", record.RequestHash)
+		assert.WithinDuration(t, time.Now(), record.CreatedAt, time.Minute)
+		assert.Equal(t, record.CreatedAt.Add(idempotencyLease), record.LeaseExpiresAt)
+		assert.Nil(t, record.Response)
+		return &mongo.InsertOneResult{InsertedID: "key"}, nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Insert(ctx, idempotencyCollectionName, gomock.Any()).
+		DoAndReturn(mockInsert)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.Nil(t, crud.CreateIdempotencyRecord(ctx, "key", "hash"))
+}
+
+func TestCrud_CreateIdempotencyRecord_duplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	duplicateError := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Insert(ctx, idempotencyCollectionName, gomock.Any()).
+		Return(nil, duplicateError)
+	// the key is still claimed or already has a response
+	mockConnection.
+		EXPECT().
+		UpdateOne(ctx, idempotencyCollectionName, gomock.Any(), gomock.Any()).
+		Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.True(t, IsDuplicateKeyError(crud.CreateIdempotencyRecord(ctx, "key", "hash")))
+}
+
+func TestCrud_CreateIdempotencyRecord_expiredLease(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	duplicateError := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+	mockUpdate := func(ctx context.Context, collection string, filter interface{}, update interface{}) (
+		*mongo.UpdateResult, error) {
+
+		// only a record without a response whose lease has expired is claimed again
+		filterDoc := filter.(bson.D)
+		assert.Equal(t, bson.E{"_id", "key"}, filterDoc[0])
+		assert.Equal(t, bson.E{"response", nil}, filterDoc[1])
+		assert.Equal(t, "leaseExpiresAt", filterDoc[2].Key)
+
+		updateDoc := update.(bson.D)
+		assert.Equal(t, bson.E{"requestHash", "hash"}, updateDoc[0])
+		assert.WithinDuration(t, time.Now().Add(idempotencyLease), updateDoc[2].Value.(time.Time), time.Minute)
+		return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Insert(ctx, idempotencyCollectionName, gomock.Any()).
+		Return(nil, duplicateError)
+	mockConnection.
+		EXPECT().
+		UpdateOne(ctx, idempotencyCollectionName, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockUpdate)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.Nil(t, crud.CreateIdempotencyRecord(ctx, "key", "hash"))
+}
+
+func TestCrud_ReadIdempotencyRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	record := entities.IdempotencyRecord{
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		Response: &entities.IdempotentResponse{
+			StatusCode: 201,
+			Header:     map[string][]string{"Content-Type": {"application/json"}},
+			Body:       []byte(`"12345678901234567"`),
+		},
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, idempotencyCollectionName, bson.D{{"_id", "key"}}).
+		Return(mongo.NewSingleResultFromDocument(record, nil, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	result, err := crud.ReadIdempotencyRecord(ctx, "key")
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.IdempotencyRecord{
+		RequestHash: "hash",
+		Response: &model.IdempotentResponse{
+			StatusCode: 201,
+			Header:     map[string][]string{"Content-Type": {"application/json"}},
+			Body:       []byte(`"12345678901234567"`),
+		},
+	}, result)
+}
+
+func TestCrud_ReadIdempotencyRecord_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		FindOne(ctx, idempotencyCollectionName, gomock.Any()).
+		Return(mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil))
+
+	crud := NewICRUD(mockConnection, config)
+	_, err := crud.ReadIdempotencyRecord(ctx, "key")
+
+	assert.True(t, IsNotFoundError(err))
+}
+
+func TestCrud_SetIdempotentResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		UpdateOne(ctx, idempotencyCollectionName, bson.D{{"_id", "key"}},
+			bson.D{{"response", entities.IdempotentResponse{StatusCode: 204}}}).
+		Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.Nil(t, crud.SetIdempotentResponse(ctx, "key", &model.IdempotentResponse{StatusCode: 204}))
+}
+
+func TestCrud_DeleteIdempotencyRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		DeleteOne(ctx, idempotencyCollectionName, bson.D{{"_id", "key"}}).
+		Return(nil, dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	assert.ErrorIs(t, crud.DeleteIdempotencyRecord(ctx, "key"), dbError)
+}
+
+var examplePositionRecord = model.PositionRecord{
+	Timestamp:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+	Position:    carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405},
+	EngineState: carTypes.ON,
+}
+
+var exampleDatabasePositionRecord = entities.PositionRecord{
+	Vin:       "12345678901234567",
+	Timestamp: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+	Position: entities.Position{
+		Type:        entities.POINT,
+		Coordinates: []float64{float64(float32(13.405)), float64(float32(52.52))},
+	},
+	EngineState: entities.ON,
+}
+
+func TestCrud_AddPositionRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Insert(ctx, positionsCollectionName, exampleDatabasePositionRecord).
+		Return(&mongo.InsertOneResult{}, nil)
+
+	crud := NewICRUD(mockConnection, config)
+	err := crud.AddPositionRecord(ctx, "12345678901234567", &examplePositionRecord)
+
+	assert.Nil(t, err)
+}
+
+func TestCrud_ReadPositionRecords_timeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	from := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	expectedFilter := bson.D{
+		{"vin", "12345678901234567"},
+		{"timestamp", bson.D{{"$gte", from}, {"$lte", to}}},
+	}
+
+	mockFind := func(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error {
+
+		assert.Equal(t, bson.D{{"timestamp", 1}}, opts[0].Sort)
+		*results.(*[]entities.PositionRecord) = []entities.PositionRecord{exampleDatabasePositionRecord}
+		return nil
+	}
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, positionsCollectionName, expectedFilter, gomock.Any(), gomock.Any()).
+		DoAndReturn(mockFind)
+
+	crud := NewICRUD(mockConnection, config)
+	records, err := crud.ReadPositionRecords(ctx, "12345678901234567", &from, &to)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PositionRecord{examplePositionRecord}, records)
+}
+
+func TestCrud_ReadPositionRecords_noTimeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, positionsCollectionName, bson.D{{"vin", "12345678901234567"}}, gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	crud := NewICRUD(mockConnection, config)
+	records, err := crud.ReadPositionRecords(ctx, "12345678901234567", nil, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PositionRecord{}, records)
+}
+
+func TestCrud_ReadPositionRecords_dbError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	dbError := errors.New("db error")
+
+	mockConnection := mocks.NewMockIConnection(ctrl)
+	mockConnection.
+		EXPECT().
+		Find(ctx, positionsCollectionName, gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(dbError)
+
+	crud := NewICRUD(mockConnection, config)
+	records, err := crud.ReadPositionRecords(ctx, "12345678901234567", nil, nil)
+
+	assert.ErrorIs(t, err, dbError)
+	assert.Nil(t, records)
 }
@@ -22,10 +22,36 @@ type IConnection interface {
 	// to check for this error. Any other errors are unexpected.
 	Insert(ctx context.Context, collection string, document interface{}) (*mongo.InsertOneResult, error)
 
-	// GetIDs returns the IDs of all documents in the specified collection. The IDs are returned as a slice of
-	// bson.M objects, where each object has a single key "_id" with the ID as the value.
+	// InsertMany inserts the documents into the database at the specified collection with a single unordered bulk
+	// write, so a failing document does not prevent the insertion of the others. If any documents fail, a
+	// mongo.BulkWriteException is returned whose write errors contain the index of the failed document. Use
+	// mongo.IsDuplicateKeyError on a write error to check if the ID already exists. Any other errors are unexpected.
+	InsertMany(ctx context.Context, collection string, documents []interface{}) (*mongo.InsertManyResult, error)
+
+	// GetIDs returns the IDs of all documents in the specified collection that match the given filter. The IDs are
+	// returned as a slice of bson.M objects, where each object has a single key "_id" with the ID as the value.
 	// If the collection does not exist, an empty slice is returned. Any errors are unexpected.
-	GetIDs(ctx context.Context, collection string, resultIds *[]bson.M) error
+	GetIDs(ctx context.Context, collection string, filter bson.D, resultIds *[]bson.M) error
+
+	// GetIDsPage returns at most limit IDs of the documents in the specified collection that match the given filter
+	// in ascending order. If after is not nil, only IDs greater than after are returned, so the last ID of a page can
+	// be used to request the next one. The IDs are returned like in GetIDs. If there are no more documents, an empty
+	// slice is returned. Any errors are unexpected.
+	GetIDsPage(ctx context.Context, collection string, filter bson.D, after interface{}, limit int64,
+		resultIds *[]bson.M) error
+
+	// Find decodes all documents from the specified collection that match the given filter into results, which
+	// must be a pointer to a slice. The filter should be a bson object. Options like sorting can be passed optionally.
+	// If no document matches, the slice is empty. Any errors are unexpected.
+	Find(ctx context.Context, collection string, filter interface{}, results interface{},
+		opts ...*options.FindOptions) error
+
+	// FindEach passes the documents from the specified collection that match the given filter to each, one after
+	// another as they are read from the database, so the documents never have to fit into memory at once. The filter
+	// and the options work like in Find. If each returns an error, no further documents are read and the error is
+	// returned. Any other errors are unexpected.
+	FindEach(ctx context.Context, collection string, filter interface{}, each func(document bson.Raw) error,
+		opts ...*options.FindOptions) error
 
 	// FindOne returns a single document from the specified collection that matches the given filter. The filter
 	// should be a bson object. If no document is found, calling the Decode method on the returned SingleResult
@@ -39,11 +65,24 @@ type IConnection interface {
 	UpdateOne(ctx context.Context, collection string, filter interface{}, update interface{}) (*mongo.UpdateResult,
 		error)
 
+	// UpdateOneAndIncrement works like UpdateOne, but additionally increments the given counter field of the document
+	// by one in the same atomic operation. A missing counter field is treated as 0.
+	UpdateOneAndIncrement(ctx context.Context, collection string, filter interface{}, update interface{},
+		counter string) (*mongo.UpdateResult, error)
+
 	// DeleteOne deletes a single document from the specified collection that matches the given filter. The filter
 	// should be a bson object. The result of the delete operation is returned. If no matching document is found, the
 	// DeletedCount field of the result will be 0, and no error will be returned.
 	DeleteOne(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error)
 
+	// DeleteMany deletes all documents from the specified collection that match the given filter. The filter
+	// should be a bson object. The result of the delete operation is returned. Any errors are unexpected.
+	DeleteMany(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error)
+
+	// CreateIndex creates the given index on the specified collection. If an identical index already exists,
+	// nothing happens. Any errors are unexpected.
+	CreateIndex(ctx context.Context, collection string, index mongo.IndexModel) error
+
 	// DropCollection drops a given collection. This is a destructive operation and should only be used for testing.
 	DropCollection(ctx context.Context, collection string) error
 }
@@ -93,11 +132,17 @@ func (m *connection) Insert(ctx context.Context, collection string, document int
 	return m.database.Collection(collection).InsertOne(ctx, document)
 }
 
-func (m *connection) GetIDs(ctx context.Context, collection string, resultIds *[]bson.M) error {
+func (m *connection) InsertMany(ctx context.Context, collection string, documents []interface{}) (
+	*mongo.InsertManyResult, error) {
+
+	return m.database.Collection(collection).InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+}
+
+func (m *connection) GetIDs(ctx context.Context, collection string, filter bson.D, resultIds *[]bson.M) error {
 	// we are only interested in the _id field
 	opts := options.Find().SetProjection(bson.D{{"_id", 1}})
 
-	cursor, err := m.database.Collection(collection).Find(ctx, bson.D{}, opts)
+	cursor, err := m.database.Collection(collection).Find(ctx, filter, opts)
 	if err != nil {
 		return err
 	}
@@ -105,6 +150,58 @@ func (m *connection) GetIDs(ctx context.Context, collection string, resultIds *[
 	return cursor.All(ctx, resultIds)
 }
 
+func (m *connection) GetIDsPage(ctx context.Context, collection string, filter bson.D, after interface{},
+	limit int64, resultIds *[]bson.M) error {
+
+	// copy the filter so the caller's slice is not modified
+	pageFilter := append(bson.D{}, filter...)
+	if after != nil {
+		pageFilter = append(pageFilter, bson.E{"_id", bson.D{{"$gt", after}}})
+	}
+
+	// the _id index makes sorting by _id cheap and keeps the pages stable while cars are added
+	opts := options.Find().
+		SetProjection(bson.D{{"_id", 1}}).
+		SetSort(bson.D{{"_id", 1}}).
+		SetLimit(limit)
+
+	cursor, err := m.database.Collection(collection).Find(ctx, pageFilter, opts)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, resultIds)
+}
+
+func (m *connection) Find(ctx context.Context, collection string, filter interface{}, results interface{},
+	opts ...*options.FindOptions) error {
+
+	cursor, err := m.database.Collection(collection).Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, results)
+}
+
+func (m *connection) FindEach(ctx context.Context, collection string, filter interface{},
+	each func(document bson.Raw) error, opts ...*options.FindOptions) error {
+
+	cursor, err := m.database.Collection(collection).Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+	// errors of closing the cursor do not affect the documents that were already passed on
+	defer func() { _ = cursor.Close(ctx) }()
+
+	for cursor.Next(ctx) {
+		if err := each(cursor.Current); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (m *connection) FindOne(ctx context.Context, collection string, filter interface{}) *mongo.SingleResult {
 	return m.database.Collection(collection).FindOne(ctx, filter)
 }
@@ -115,12 +212,32 @@ func (m *connection) UpdateOne(ctx context.Context, collection string, filter in
 	return m.database.Collection(collection).UpdateOne(ctx, filter, bson.D{{"$set", update}})
 }
 
+func (m *connection) UpdateOneAndIncrement(ctx context.Context, collection string, filter interface{},
+	update interface{}, counter string) (*mongo.UpdateResult, error) {
+
+	return m.database.Collection(collection).UpdateOne(ctx, filter, bson.D{
+		{"$set", update},
+		{"$inc", bson.D{{counter, 1}}},
+	})
+}
+
 func (m *connection) DeleteOne(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult,
 	error) {
 
 	return m.database.Collection(collection).DeleteOne(ctx, filter)
 }
 
+func (m *connection) DeleteMany(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult,
+	error) {
+
+	return m.database.Collection(collection).DeleteMany(ctx, filter)
+}
+
+func (m *connection) CreateIndex(ctx context.Context, collection string, index mongo.IndexModel) error {
+	_, err := m.database.Collection(collection).Indexes().CreateOne(ctx, index)
+	return err
+}
+
 func (m *connection) DropCollection(ctx context.Context, collection string) error {
 	return m.database.Collection(collection).Drop(ctx)
 }
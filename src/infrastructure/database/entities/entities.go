@@ -25,6 +25,17 @@ const (
 	LOCKED   LockState = "LOCKED"
 )
 
+// Defines values for EngineState.
+const (
+	ON  EngineState = "ON"
+	OFF EngineState = "OFF"
+)
+
+// Defines values for GeoJsonType.
+const (
+	POINT GeoJsonType = "Point"
+)
+
 // Car A specific type of vehicle
 type Car struct {
 	// Vin A Vehicle Identification Number (VIN) which uniquely identifies a car
@@ -57,6 +68,10 @@ type Car struct {
 	// FuelCapacity Data that specifies the amount of fuel that can be carried with the car
 	FuelCapacity string `bson:"technicalSpecification_fuelCapacity"`
 
+	// FuelCapacityValues The capacities parsed from the fuel capacity, they are always stored so that an update
+	// removes a capacity that is no longer part of the fuel capacity
+	FuelCapacityValues FuelCapacityValues `bson:"technicalSpecification_fuelCapacityValues"`
+
 	// NumberOfDoors Data that defines the number of doors that are built into a car
 	NumberOfDoors int `bson:"technicalSpecification_numberOfDoors"`
 
@@ -75,9 +90,34 @@ type Car struct {
 	// Weight Data that specifies the total weight of a car when empty in kilograms (kg)
 	Weight int `bson:"technicalSpecification_weight"`
 
-	// TrunkLockState Indicates the state of the trunk lock - this is stored in the database to simulate
+	// DynamicData Data that changes during a car's operation - this is stored in the database to simulate
 	// a real car.
+	DynamicData DynamicData `bson:",inline"`
+
+	// Revision Counts the changes of the car, it is increased with every update to detect concurrent changes
+	Revision int64 `bson:"revision"`
+
+	// DeletedAt The time the car was archived, nil if the car is not archived
+	DeletedAt *time.Time `bson:"deletedAt,omitempty"`
+}
+
+// DynamicData Data that changes during a car's operation
+type DynamicData struct {
+	// TrunkLockState Indicates the state of the trunk lock
 	TrunkLockState LockState `bson:"mockData_trunkLockState"`
+
+	// DoorsLockState Indicates the state of the door locks
+	DoorsLockState LockState `bson:"mockData_doorsLockState"`
+
+	// EngineState Indicates whether the engine is running
+	EngineState EngineState `bson:"mockData_engineState"`
+
+	// FuelLevelPercentage Indicates the relation of remaining fuel to the fuel capacity in percentage, nil if not
+	// stored
+	FuelLevelPercentage *int `bson:"mockData_fuelLevelPercentage,omitempty"`
+
+	// Position Indicates the current GeoCoordinate of the car, it is indexed for geospatial queries, nil if not stored
+	Position *Position `bson:"mockData_position,omitempty"`
 }
 
 type Consumption struct {
@@ -110,12 +150,57 @@ type Engine struct {
 	Type string `bson:"type"`
 }
 
+// Position A GeoCoordinate stored as GeoJSON point
+type Position struct {
+	// Type The type of the GeoJSON object, always POINT
+	Type GeoJsonType `bson:"type"`
+
+	// Coordinates The longitude and the latitude in this order as required by GeoJSON
+	Coordinates []float64 `bson:"coordinates"`
+}
+
 type Tire struct {
 	// Manufacturer Data denoting the company responsible for the creation of a physical unit
 	Manufacturer string `bson:"manufacturer"`
 
 	// Type Data that contains the manufacturer-given type description of the tire
 	Type string `bson:"type"`
+
+	// Specification The attributes parsed from the type, missing if the type cannot be parsed
+	Specification *TireSpecification `bson:"specification,omitempty"`
+}
+
+// TireSpecification The attributes of a tire as encoded in its type description
+type TireSpecification struct {
+	// Width The width of the tire in millimeters
+	Width int `bson:"width"`
+
+	// AspectRatio The height of the sidewall in percent of the width
+	AspectRatio int `bson:"aspectRatio"`
+
+	// Construction The construction of the carcass, R (radial) or D (diagonal)
+	Construction string `bson:"construction"`
+
+	// RunFlat Whether the tire can be driven on after a puncture
+	RunFlat bool `bson:"runFlat"`
+
+	// RimDiameter The diameter of the rim in inches
+	RimDiameter int `bson:"rimDiameter"`
+
+	// LoadIndex The code of the maximum load the tire can carry, missing if not part of the type
+	LoadIndex *int `bson:"loadIndex,omitempty"`
+
+	// SpeedRating The code of the maximum speed the tire is designed for, missing if not part of the type
+	SpeedRating *string `bson:"speedRating,omitempty"`
+}
+
+// FuelCapacityValues The capacities encoded in the fuel capacity of a car
+type FuelCapacityValues struct {
+	// Liters The capacity of the tank in liters, null if not part of the fuel capacity
+	Liters *float64 `bson:"liters"`
+
+	// KiloWattHours The capacity of the battery in kWh, null if not part of the fuel capacity
+	KiloWattHours *float64 `bson:"kiloWattHours"`
 }
 
 // Fuel Data that defines the source of energy that powers the car
@@ -124,8 +209,59 @@ type Fuel string
 // Transmission A physical unit responsible for managing the conversion rate of the engine (can be automated or manually operated)
 type Transmission string
 
+// GeoJsonType The type of a GeoJSON object
+type GeoJsonType string
+
 // Vin A Vehicle Identification Number (VIN) which uniquely identifies a car
 type Vin = string
 
 // LockState Indicates the state of a lock
 type LockState = string
+
+// EngineState Indicates whether an engine is running
+type EngineState = string
+
+// PositionRecord A position of a car recorded at a specific time
+type PositionRecord struct {
+	// Vin The VIN of the car the position belongs to
+	Vin Vin `bson:"vin"`
+
+	// Timestamp The time the position was recorded
+	Timestamp time.Time `bson:"timestamp"`
+
+	// Position The GeoCoordinate of the car at the time of the record
+	Position Position `bson:"position"`
+
+	// EngineState Indicates whether the engine was running at the time of the record
+	EngineState EngineState `bson:"engineState"`
+}
+
+// IdempotencyRecord The state of an idempotency key, it is removed by a TTL index some time after its creation
+type IdempotencyRecord struct {
+	// Key The idempotency key chosen by the client
+	Key string `bson:"_id"`
+
+	// RequestHash Identifies the request the key was first used for
+	RequestHash string `bson:"requestHash"`
+
+	// CreatedAt The time the key was first used
+	CreatedAt time.Time `bson:"createdAt"`
+
+	// LeaseExpiresAt The time until which the key is claimed by its request while the response is still missing
+	LeaseExpiresAt time.Time `bson:"leaseExpiresAt"`
+
+	// Response The response to the request, nil while the request is still handled
+	Response *IdempotentResponse `bson:"response"`
+}
+
+// IdempotentResponse The response to a request with an idempotency key
+type IdempotentResponse struct {
+	// StatusCode The HTTP status code of the response
+	StatusCode int `bson:"statusCode"`
+
+	// Header The header fields of the response
+	Header map[string][]string `bson:"header"`
+
+	// Body The body of the response
+	Body []byte `bson:"body"`
+}
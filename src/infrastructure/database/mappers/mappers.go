@@ -5,10 +5,20 @@ import (
 	"DCar/logic/model"
 	carTypes "github.com/ccsapp/cargotypes"
 	openapiTypes "github.com/deepmap/oapi-codegen/pkg/types"
+	"sort"
+	"strings"
+)
+
+// Initial dynamic data of a newly added car. New cars are parked in Karlsruhe with a full tank.
+const (
+	initialFuelLevelPercentage = 100
+	initialLatitude            = 49.0069
+	initialLongitude           = 8.4037
 )
 
 // MapCarToDb maps a car from the domain to a car in the database.
-// The trunk lock state is not mapped and set to LOCKED.
+// The dynamic data is not mapped: the trunk and the doors are LOCKED, the engine is OFF, the tank is full and
+// the car is located at an initial position.
 func MapCarToDb(car *carTypes.Car) entities.Car {
 	return entities.Car{
 		Vin:            car.Vin,
@@ -30,18 +40,81 @@ func MapCarToDb(car *carTypes.Car) entities.Car {
 			Power: car.TechnicalSpecification.Engine.Power,
 			Type:  car.TechnicalSpecification.Engine.Type,
 		},
-		Fuel:          entities.Fuel(car.TechnicalSpecification.Fuel),
-		FuelCapacity:  car.TechnicalSpecification.FuelCapacity,
-		NumberOfDoors: car.TechnicalSpecification.NumberOfDoors,
-		NumberOfSeats: car.TechnicalSpecification.NumberOfSeats,
-		Tire: entities.Tire{
-			Manufacturer: car.TechnicalSpecification.Tire.Manufacturer,
-			Type:         car.TechnicalSpecification.Tire.Type,
-		},
-		Transmission:   entities.Transmission(car.TechnicalSpecification.Transmission),
-		TrunkVolume:    car.TechnicalSpecification.TrunkVolume,
-		Weight:         car.TechnicalSpecification.Weight,
-		TrunkLockState: entities.LOCKED,
+		Fuel:               entities.Fuel(car.TechnicalSpecification.Fuel),
+		FuelCapacity:       car.TechnicalSpecification.FuelCapacity,
+		FuelCapacityValues: mapFuelCapacityToDb(car.TechnicalSpecification.FuelCapacity),
+		NumberOfDoors:      car.TechnicalSpecification.NumberOfDoors,
+		NumberOfSeats:      car.TechnicalSpecification.NumberOfSeats,
+		Tire:               mapTireToDb(&car.TechnicalSpecification.Tire),
+		Transmission:       entities.Transmission(car.TechnicalSpecification.Transmission),
+		TrunkVolume:        car.TechnicalSpecification.TrunkVolume,
+		Weight:             car.TechnicalSpecification.Weight,
+		DynamicData:        initialDynamicData(),
+		Revision:           1,
+	}
+}
+
+// mapTireToDb maps a tire from the domain to a tire in the database. The specification parsed from the type is
+// stored next to the type, it is missing if the type cannot be parsed.
+func mapTireToDb(tire *carTypes.TechnicalSpecificationTire) entities.Tire {
+	result := entities.Tire{
+		Manufacturer: tire.Manufacturer,
+		Type:         tire.Type,
+	}
+
+	if specification, err := model.ParseTireType(tire.Type); err == nil {
+		result.Specification = &entities.TireSpecification{
+			Width:        specification.Width,
+			AspectRatio:  specification.AspectRatio,
+			Construction: string(specification.Construction),
+			RunFlat:      specification.RunFlat,
+			RimDiameter:  specification.RimDiameter,
+			LoadIndex:    specification.LoadIndex,
+			SpeedRating:  specification.SpeedRating,
+		}
+	}
+	return result
+}
+
+// mapFuelCapacityToDb maps the fuel capacity of a car from the domain to the capacities in the database. Both
+// capacities are nil if the fuel capacity cannot be parsed.
+func mapFuelCapacityToDb(fuelCapacity string) entities.FuelCapacityValues {
+	capacity, _ := model.ParseFuelCapacity(fuelCapacity)
+	return entities.FuelCapacityValues{
+		Liters:        capacity.Liters,
+		KiloWattHours: capacity.KiloWattHours,
+	}
+}
+
+// MapDynamicDataToDb maps the dynamic data of a car from the domain to the dynamic data in the database.
+func MapDynamicDataToDb(dynamicData *carTypes.DynamicData) entities.DynamicData {
+	fuelLevelPercentage := dynamicData.FuelLevelPercentage
+	position := MapPositionToDb(&dynamicData.Position)
+	return entities.DynamicData{
+		TrunkLockState:      entities.LockState(dynamicData.TrunkLockState),
+		DoorsLockState:      entities.LockState(dynamicData.DoorsLockState),
+		EngineState:         entities.EngineState(dynamicData.EngineState),
+		FuelLevelPercentage: &fuelLevelPercentage,
+		Position:            &position,
+	}
+}
+
+// MapPositionToDb maps the position of a car from the domain to a GeoJSON point in the database.
+func MapPositionToDb(position *carTypes.DynamicDataPosition) entities.Position {
+	return entities.Position{
+		Type:        entities.POINT,
+		Coordinates: []float64{float64(position.Longitude), float64(position.Latitude)},
+	}
+}
+
+func mapPositionFromDb(position *entities.Position) carTypes.DynamicDataPosition {
+	// GeoJSON points always have two coordinates, anything else is treated as missing position
+	if len(position.Coordinates) != 2 {
+		return carTypes.DynamicDataPosition{}
+	}
+	return carTypes.DynamicDataPosition{
+		Latitude:  float32(position.Coordinates[1]),
+		Longitude: float32(position.Coordinates[0]),
 	}
 }
 
@@ -49,13 +122,89 @@ func MapCarFromDb(car *entities.Car) carTypes.Car {
 	return carTypes.Car{
 		Vin:                    car.Vin,
 		Brand:                  car.Brand,
-		DynamicData:            model.ExampleDynamicData(car.TrunkLockState), // TODO: do not use example data
+		DynamicData:            mapDynamicDataFromDb(&car.DynamicData),
 		Model:                  car.Model,
 		ProductionDate:         openapiTypes.Date{Time: car.ProductionDate},
 		TechnicalSpecification: mapTechnicalSpecificationFromDb(car),
 	}
 }
 
+// MapStoredCarFromDb maps a car in the database to a car in the domain together with its revision and the tire
+// specification stored with the car.
+func MapStoredCarFromDb(car *entities.Car) model.StoredCar {
+	return model.StoredCar{
+		CarWithRevision:   model.CarWithRevision{Car: MapCarFromDb(car), Revision: car.Revision},
+		TireSpecification: mapTireSpecificationFromDb(car.Tire.Specification),
+	}
+}
+
+// mapTireSpecificationFromDb maps a tire specification in the database to a tire specification in the domain. If
+// no specification is stored, nil is returned.
+func mapTireSpecificationFromDb(specification *entities.TireSpecification) *model.TireSpecification {
+	if specification == nil {
+		return nil
+	}
+	return &model.TireSpecification{
+		Width:        specification.Width,
+		AspectRatio:  specification.AspectRatio,
+		Construction: model.TireConstruction(specification.Construction),
+		RunFlat:      specification.RunFlat,
+		RimDiameter:  specification.RimDiameter,
+		LoadIndex:    specification.LoadIndex,
+		SpeedRating:  specification.SpeedRating,
+	}
+}
+
+// initialDynamicData returns the dynamic data of a newly added car.
+func initialDynamicData() entities.DynamicData {
+	fuelLevelPercentage := initialFuelLevelPercentage
+	position := MapPositionToDb(&carTypes.DynamicDataPosition{
+		Latitude:  initialLatitude,
+		Longitude: initialLongitude,
+	})
+	return entities.DynamicData{
+		TrunkLockState:      entities.LOCKED,
+		DoorsLockState:      entities.LOCKED,
+		EngineState:         entities.OFF,
+		FuelLevelPercentage: &fuelLevelPercentage,
+		Position:            &position,
+	}
+}
+
+// mapDynamicDataFromDb maps the dynamic data of a car from the database to the domain. Each field that is not
+// stored, e.g. because the car was stored before the field was introduced, is read with its initial value of a new
+// car. A stored fuel level of 0 and a stored position without coordinates are valid states of a car.
+func mapDynamicDataFromDb(dynamicData *entities.DynamicData) carTypes.DynamicData {
+	initial := initialDynamicData()
+
+	// the stored dynamic data is not changed
+	withDefaults := *dynamicData
+	dynamicData = &withDefaults
+	if dynamicData.TrunkLockState == "" {
+		dynamicData.TrunkLockState = initial.TrunkLockState
+	}
+	if dynamicData.DoorsLockState == "" {
+		dynamicData.DoorsLockState = initial.DoorsLockState
+	}
+	if dynamicData.EngineState == "" {
+		dynamicData.EngineState = initial.EngineState
+	}
+	if dynamicData.FuelLevelPercentage == nil {
+		dynamicData.FuelLevelPercentage = initial.FuelLevelPercentage
+	}
+	if dynamicData.Position == nil {
+		dynamicData.Position = initial.Position
+	}
+
+	return carTypes.DynamicData{
+		DoorsLockState:      carTypes.DynamicDataLockState(dynamicData.DoorsLockState),
+		EngineState:         carTypes.DynamicDataEngineState(dynamicData.EngineState),
+		FuelLevelPercentage: *dynamicData.FuelLevelPercentage,
+		Position:            mapPositionFromDb(dynamicData.Position),
+		TrunkLockState:      carTypes.DynamicDataLockState(dynamicData.TrunkLockState),
+	}
+}
+
 func mapTechnicalSpecificationFromDb(car *entities.Car) carTypes.TechnicalSpecification {
 	return carTypes.TechnicalSpecification{
 		Color:         car.Color,
@@ -102,3 +251,86 @@ func mapTechnicalSpecificationTireFromDb(tire *entities.Tire) carTypes.Technical
 		Type:         tire.Type,
 	}
 }
+
+// MapPositionRecordToDb maps a position record of the car with the given VIN from the domain to a position record
+// in the database.
+func MapPositionRecordToDb(vin carTypes.Vin, record *model.PositionRecord) entities.PositionRecord {
+	return entities.PositionRecord{
+		Vin:         vin,
+		Timestamp:   record.Timestamp,
+		Position:    MapPositionToDb(&record.Position),
+		EngineState: entities.EngineState(record.EngineState),
+	}
+}
+
+func MapPositionRecordFromDb(record *entities.PositionRecord) model.PositionRecord {
+	return model.PositionRecord{
+		Timestamp:   record.Timestamp,
+		Position:    mapPositionFromDb(&record.Position),
+		EngineState: carTypes.DynamicDataEngineState(record.EngineState),
+	}
+}
+
+func MapIdempotentResponseToDb(response *model.IdempotentResponse) entities.IdempotentResponse {
+	return entities.IdempotentResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       response.Body,
+	}
+}
+
+func MapIdempotencyRecordFromDb(record *entities.IdempotencyRecord) model.IdempotencyRecord {
+	result := model.IdempotencyRecord{RequestHash: record.RequestHash}
+	if record.Response != nil {
+		result.Response = &model.IdempotentResponse{
+			StatusCode: record.Response.StatusCode,
+			Header:     record.Response.Header,
+			Body:       record.Response.Body,
+		}
+	}
+	return result
+}
+
+// carFieldKeys maps the fields of a car in the domain (see model.CarFields) to the keys in the database. Fields
+// with nested fields are not listed, they are mapped to the keys of their nested fields.
+var carFieldKeys = map[string]string{
+	"vin":                                  "_id",
+	"brand":                                "brand",
+	"model":                                "model",
+	"productionDate":                       "productionDate",
+	"technicalSpecification.color":         "technicalSpecification_color",
+	"technicalSpecification.consumption":   "technicalSpecification_consumption",
+	"technicalSpecification.emissions":     "technicalSpecification_emissions",
+	"technicalSpecification.engine":        "technicalSpecification_engine",
+	"technicalSpecification.fuel":          "technicalSpecification_fuel",
+	"technicalSpecification.fuelCapacity":  "technicalSpecification_fuelCapacity",
+	"technicalSpecification.numberOfDoors": "technicalSpecification_numberOfDoors",
+	"technicalSpecification.numberOfSeats": "technicalSpecification_numberOfSeats",
+	"technicalSpecification.tire":          "technicalSpecification_tire",
+	"technicalSpecification.transmission":  "technicalSpecification_transmission",
+	"technicalSpecification.trunkVolume":   "technicalSpecification_trunkVolume",
+	"technicalSpecification.weight":        "technicalSpecification_weight",
+	"dynamicData.doorsLockState":           "mockData_doorsLockState",
+	"dynamicData.engineState":              "mockData_engineState",
+	"dynamicData.fuelLevelPercentage":      "mockData_fuelLevelPercentage",
+	"dynamicData.position":                 "mockData_position",
+	"dynamicData.trunkLockState":           "mockData_trunkLockState",
+}
+
+// MapFieldsToDb maps the given fields of a car in the domain (see model.CarFields) to the keys in the database in
+// ascending order. A field like dynamicData is mapped to the keys of all its nested fields. Unknown fields are
+// ignored.
+func MapFieldsToDb(fields []string) []string {
+	var keys []string
+	for carField, key := range carFieldKeys {
+		for _, field := range fields {
+			if carField == field || strings.HasPrefix(carField, field+".") {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	// the order of a map is random, a stable order keeps the database queries comparable
+	sort.Strings(keys)
+	return keys
+}
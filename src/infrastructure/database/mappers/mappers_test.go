@@ -2,9 +2,12 @@ package mappers
 
 import (
 	"DCar/infrastructure/database/entities"
+	"DCar/logic/model"
 	carTypes "github.com/ccsapp/cargotypes"
 	openapiTypes "github.com/deepmap/oapi-codegen/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"strings"
 	"testing"
 	"time"
 )
@@ -12,9 +15,9 @@ import (
 var exampleModelCar = carTypes.Car{
 	Brand: "Volkswagen",
 	DynamicData: carTypes.DynamicData{
-		DoorsLockState:      carTypes.UNLOCKED,
+		DoorsLockState:      carTypes.LOCKED,
 		EngineState:         carTypes.OFF,
-		FuelLevelPercentage: 23,
+		FuelLevelPercentage: 100,
 		Position: carTypes.DynamicDataPosition{
 			Latitude:  49.0069,
 			Longitude: 8.4037,
@@ -57,6 +60,10 @@ var exampleModelCar = carTypes.Car{
 	Vin: "12345678901234567",
 }
 
+var exampleLiters, exampleKiloWattHours = 54.0, 85.2
+
+var exampleFuelLevelPercentage, exampleChangedFuelLevelPercentage = 100, 42
+
 var exampleDatabaseCar = entities.Car{
 	Vin:            "12345678901234567",
 	Brand:          "Volkswagen",
@@ -77,24 +84,274 @@ var exampleDatabaseCar = entities.Car{
 		Power: 110,
 		Type:  "someType",
 	},
-	Fuel:          entities.ELECTRIC,
-	FuelCapacity:  "54.0L;85.2kWh",
+	Fuel:         entities.ELECTRIC,
+	FuelCapacity: "54.0L;85.2kWh",
+	FuelCapacityValues: entities.FuelCapacityValues{
+		Liters:        &exampleLiters,
+		KiloWattHours: &exampleKiloWattHours,
+	},
 	NumberOfDoors: 5,
 	NumberOfSeats: 5,
 	Tire: entities.Tire{
 		Manufacturer: "GOODYEAR",
 		Type:         "185/65R15",
+		Specification: &entities.TireSpecification{
+			Width:        185,
+			AspectRatio:  65,
+			Construction: "R",
+			RimDiameter:  15,
+		},
 	},
-	Transmission:   entities.MANUAL,
-	TrunkVolume:    435,
-	Weight:         1320,
-	TrunkLockState: entities.LOCKED,
+	Transmission: entities.MANUAL,
+	TrunkVolume:  435,
+	Weight:       1320,
+	DynamicData: entities.DynamicData{
+		TrunkLockState:      entities.LOCKED,
+		DoorsLockState:      entities.LOCKED,
+		EngineState:         entities.OFF,
+		FuelLevelPercentage: &exampleFuelLevelPercentage,
+		Position: &entities.Position{
+			Type:        entities.POINT,
+			Coordinates: []float64{float64(float32(8.4037)), float64(float32(49.0069))},
+		},
+	},
+	Revision: 1,
 }
 
 func TestMapCarToDb(t *testing.T) {
 	assert.Equal(t, exampleDatabaseCar, MapCarToDb(&exampleModelCar))
 }
 
+func TestMapTireToDb(t *testing.T) {
+	loadIndex := 91
+	speedRating := "V"
+	assert.Equal(t, entities.Tire{
+		Manufacturer: "GOODYEAR",
+		Type:         "205/55RF1691V",
+		Specification: &entities.TireSpecification{
+			Width:        205,
+			AspectRatio:  55,
+			Construction: "R",
+			RunFlat:      true,
+			RimDiameter:  16,
+			LoadIndex:    &loadIndex,
+			SpeedRating:  &speedRating,
+		},
+	}, mapTireToDb(&carTypes.TechnicalSpecificationTire{Manufacturer: "GOODYEAR", Type: "205/55RF1691V"}))
+}
+
+func TestMapTireToDb_invalidType(t *testing.T) {
+	// the specification is missing if the type cannot be parsed
+	assert.Equal(t, entities.Tire{Manufacturer: "GOODYEAR", Type: "unknown"},
+		mapTireToDb(&carTypes.TechnicalSpecificationTire{Manufacturer: "GOODYEAR", Type: "unknown"}))
+}
+
+func TestMapFuelCapacityToDb(t *testing.T) {
+	liters := 58.0
+	assert.Equal(t, entities.FuelCapacityValues{Liters: &liters}, mapFuelCapacityToDb("58.0L"))
+}
+
+func TestMapFuelCapacityToDb_invalid(t *testing.T) {
+	// both capacities are nil if the fuel capacity cannot be parsed
+	assert.Equal(t, entities.FuelCapacityValues{}, mapFuelCapacityToDb("58.0gal"))
+}
+
 func TestMapCarFromDb(t *testing.T) {
 	assert.Equal(t, exampleModelCar, MapCarFromDb(&exampleDatabaseCar))
 }
+
+func TestMapStoredCarFromDb(t *testing.T) {
+	databaseCar := exampleDatabaseCar
+	databaseCar.Revision = 42
+
+	assert.Equal(t, model.StoredCar{
+		CarWithRevision: model.CarWithRevision{Car: exampleModelCar, Revision: 42},
+		TireSpecification: &model.TireSpecification{
+			Width:        185,
+			AspectRatio:  65,
+			Construction: model.TireConstructionRadial,
+			RimDiameter:  15,
+		},
+	}, MapStoredCarFromDb(&databaseCar))
+}
+
+func TestMapStoredCarFromDb_missingTireSpecification(t *testing.T) {
+	// cars stored before the tire specification was derived have none
+	databaseCar := exampleDatabaseCar
+	databaseCar.Tire.Specification = nil
+
+	assert.Nil(t, MapStoredCarFromDb(&databaseCar).TireSpecification)
+}
+
+var exampleChangedDatabaseDynamicData = entities.DynamicData{
+	TrunkLockState:      entities.UNLOCKED,
+	DoorsLockState:      entities.UNLOCKED,
+	EngineState:         entities.ON,
+	FuelLevelPercentage: &exampleChangedFuelLevelPercentage,
+	Position: &entities.Position{
+		Type:        entities.POINT,
+		Coordinates: []float64{float64(float32(13.4050)), float64(float32(52.5200))},
+	},
+}
+
+var exampleChangedModelDynamicData = carTypes.DynamicData{
+	DoorsLockState:      carTypes.UNLOCKED,
+	EngineState:         carTypes.ON,
+	FuelLevelPercentage: 42,
+	Position: carTypes.DynamicDataPosition{
+		Latitude:  52.5200,
+		Longitude: 13.4050,
+	},
+	TrunkLockState: carTypes.UNLOCKED,
+}
+
+func TestMapCarFromDb_dynamicData(t *testing.T) {
+	databaseCar := exampleDatabaseCar
+	databaseCar.DynamicData = exampleChangedDatabaseDynamicData
+
+	assert.Equal(t, exampleChangedModelDynamicData, MapCarFromDb(&databaseCar).DynamicData)
+}
+
+func TestMapCarFromDb_missingPosition(t *testing.T) {
+	databaseCar := exampleDatabaseCar
+	databaseCar.DynamicData.Position = &entities.Position{}
+
+	assert.Equal(t, carTypes.DynamicDataPosition{}, MapCarFromDb(&databaseCar).DynamicData.Position)
+}
+
+func TestMapCarFromDb_legacyCar(t *testing.T) {
+	// cars stored before the dynamic data was introduced only have the trunk lock state of the dynamic data
+	var document bson.M
+	raw, _ := bson.Marshal(exampleDatabaseCar)
+	assert.Nil(t, bson.Unmarshal(raw, &document))
+	for key := range document {
+		if strings.HasPrefix(key, "mockData_") && key != "mockData_trunkLockState" {
+			delete(document, key)
+		}
+	}
+	document["mockData_trunkLockState"] = entities.UNLOCKED
+	raw, _ = bson.Marshal(document)
+	var legacyCar entities.Car
+	assert.Nil(t, bson.Unmarshal(raw, &legacyCar))
+
+	// each missing field is read with its initial value, the stored trunk lock state is kept
+	assert.Equal(t, carTypes.DynamicData{
+		DoorsLockState:      carTypes.LOCKED,
+		EngineState:         carTypes.OFF,
+		FuelLevelPercentage: 100,
+		Position:            carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037},
+		TrunkLockState:      carTypes.UNLOCKED,
+	}, MapCarFromDb(&legacyCar).DynamicData)
+}
+
+func TestMapCarFromDb_emptyTank(t *testing.T) {
+	emptyTank := 0
+	databaseCar := exampleDatabaseCar
+	databaseCar.DynamicData = exampleChangedDatabaseDynamicData
+	databaseCar.DynamicData.FuelLevelPercentage = &emptyTank
+
+	expected := exampleChangedModelDynamicData
+	expected.FuelLevelPercentage = 0
+
+	assert.Equal(t, expected, MapCarFromDb(&databaseCar).DynamicData)
+}
+
+func TestMapCarFromDb_projectedEmptyTank(t *testing.T) {
+	// a projection only contains the requested fields, the stored fuel level is read although the other fields
+	// are missing
+	raw, _ := bson.Marshal(bson.D{{"_id", "12345678901234567"}, {"mockData_fuelLevelPercentage", 0}})
+	var projectedCar entities.Car
+	assert.Nil(t, bson.Unmarshal(raw, &projectedCar))
+
+	assert.Equal(t, 0, MapCarFromDb(&projectedCar).DynamicData.FuelLevelPercentage)
+}
+
+func TestMapCarFromDb_missingStates(t *testing.T) {
+	databaseCar := exampleDatabaseCar
+	databaseCar.DynamicData = exampleChangedDatabaseDynamicData
+	databaseCar.DynamicData.TrunkLockState = ""
+	databaseCar.DynamicData.EngineState = ""
+
+	// only the missing states are read as their initial state
+	expected := exampleChangedModelDynamicData
+	expected.TrunkLockState = carTypes.LOCKED
+	expected.EngineState = carTypes.OFF
+
+	assert.Equal(t, expected, MapCarFromDb(&databaseCar).DynamicData)
+	assert.Equal(t, entities.LockState(""), databaseCar.DynamicData.TrunkLockState)
+}
+
+func TestMapDynamicDataToDb(t *testing.T) {
+	assert.Equal(t, exampleChangedDatabaseDynamicData, MapDynamicDataToDb(&exampleChangedModelDynamicData))
+}
+
+var exampleModelPositionRecord = model.PositionRecord{
+	Timestamp:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+	Position:    carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405},
+	EngineState: carTypes.ON,
+}
+
+var exampleDatabasePositionRecord = entities.PositionRecord{
+	Vin:       "12345678901234567",
+	Timestamp: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+	Position: entities.Position{
+		Type:        entities.POINT,
+		Coordinates: []float64{float64(float32(13.405)), float64(float32(52.52))},
+	},
+	EngineState: entities.ON,
+}
+
+func TestMapPositionRecordToDb(t *testing.T) {
+	assert.Equal(t, exampleDatabasePositionRecord,
+		MapPositionRecordToDb("12345678901234567", &exampleModelPositionRecord))
+}
+
+func TestMapPositionRecordFromDb(t *testing.T) {
+	assert.Equal(t, exampleModelPositionRecord, MapPositionRecordFromDb(&exampleDatabasePositionRecord))
+}
+
+func TestMapIdempotentResponseToDb(t *testing.T) {
+	assert.Equal(t, entities.IdempotentResponse{
+		StatusCode: 201,
+		Header:     map[string][]string{"Content-Type": {"application/json"}},
+		Body:       []byte(`"12345678901234567"`),
+	}, MapIdempotentResponseToDb(&model.IdempotentResponse{
+		StatusCode: 201,
+		Header:     map[string][]string{"Content-Type": {"application/json"}},
+		Body:       []byte(`"12345678901234567"`),
+	}))
+}
+
+func TestMapIdempotencyRecordFromDb(t *testing.T) {
+	record := entities.IdempotencyRecord{
+		Key:         "key",
+		RequestHash: "hash",
+		CreatedAt:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		Response:    &entities.IdempotentResponse{StatusCode: 204},
+	}
+	assert.Equal(t, model.IdempotencyRecord{
+		RequestHash: "hash",
+		Response:    &model.IdempotentResponse{StatusCode: 204},
+	}, MapIdempotencyRecordFromDb(&record))
+
+	// the request is still handled
+	record.Response = nil
+	assert.Equal(t, model.IdempotencyRecord{RequestHash: "hash"}, MapIdempotencyRecordFromDb(&record))
+}
+
+func TestMapFieldsToDb(t *testing.T) {
+	assert.Equal(t, []string{"_id", "brand", "mockData_position"},
+		MapFieldsToDb([]string{"dynamicData.position", "vin", "brand", "unknown"}))
+
+	assert.Equal(t, []string{"mockData_doorsLockState", "mockData_engineState", "mockData_fuelLevelPercentage",
+		"mockData_position", "mockData_trunkLockState"}, MapFieldsToDb([]string{"dynamicData"}))
+
+	assert.Nil(t, MapFieldsToDb(nil))
+}
+
+func TestMapFieldsToDb_allFields(t *testing.T) {
+	// every field of the domain must be stored somewhere in the database
+	for _, field := range model.CarFields {
+		assert.NotEmpty(t, MapFieldsToDb([]string{field}), field)
+	}
+}
@@ -0,0 +1,89 @@
+// Package events distributes the changes of the dynamic data of the cars to any number of subscribers.
+package events
+
+import (
+	"DCar/logic/model"
+	carTypes "github.com/ccsapp/cargotypes"
+	"sync"
+)
+
+// subscriptionBufferSize is the number of events a subscriber can lag behind before events are dropped.
+const subscriptionBufferSize = 16
+
+type subscription struct {
+	// vin is the VIN of the car the subscription is interested in, the empty VIN matches all cars
+	vin    carTypes.Vin
+	events chan model.CarEvent
+}
+
+// Broker passes published events to all matching subscribers. Use NewBroker to create an instance.
+// A Broker is safe for concurrent use.
+type Broker struct {
+	mutex         sync.RWMutex
+	subscriptions map[*subscription]struct{}
+}
+
+// NewBroker creates a new broker without any subscribers.
+func NewBroker() *Broker {
+	return &Broker{
+		subscriptions: make(map[*subscription]struct{}),
+	}
+}
+
+// Subscribe returns a channel that receives the events of the car with the given VIN. If the VIN is empty, the
+// events of all cars are received. The returned function ends the subscription and closes the channel, it must
+// be called once the events are no longer consumed.
+func (b *Broker) Subscribe(vin carTypes.Vin) (<-chan model.CarEvent, func()) {
+	s := &subscription{
+		vin:    vin,
+		events: make(chan model.CarEvent, subscriptionBufferSize),
+	}
+
+	b.mutex.Lock()
+	b.subscriptions[s] = struct{}{}
+	b.mutex.Unlock()
+
+	var once sync.Once
+	return s.events, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscriptions, s)
+			b.mutex.Unlock()
+			close(s.events)
+		})
+	}
+}
+
+// HasSubscribers checks if any subscriber is interested in the events of the car with the given VIN.
+func (b *Broker) HasSubscribers(vin carTypes.Vin) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for s := range b.subscriptions {
+		if s.matches(vin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish passes the event to all subscribers of the car. Publish never blocks: if a subscriber does not keep up,
+// the event is dropped for this subscriber.
+func (b *Broker) Publish(event model.CarEvent) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for s := range b.subscriptions {
+		if !s.matches(event.Vin) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+		}
+	}
+}
+
+func (s *subscription) matches(vin carTypes.Vin) bool {
+	return s.vin == "" || s.vin == vin
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"DCar/logic/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const exampleVin = "12345678901234567"
+const otherVin = "12345678901234568"
+
+func TestBroker_carSubscription(t *testing.T) {
+	broker := NewBroker()
+
+	carEvents, unsubscribe := broker.Subscribe(exampleVin)
+	defer unsubscribe()
+
+	assert.True(t, broker.HasSubscribers(exampleVin))
+	assert.False(t, broker.HasSubscribers(otherVin))
+
+	broker.Publish(model.CarEvent{Vin: otherVin})
+	broker.Publish(model.CarEvent{Vin: exampleVin})
+
+	assert.Equal(t, model.CarEvent{Vin: exampleVin}, <-carEvents)
+	assert.Empty(t, carEvents)
+}
+
+func TestBroker_fleetSubscription(t *testing.T) {
+	broker := NewBroker()
+
+	fleetEvents, unsubscribe := broker.Subscribe("")
+	defer unsubscribe()
+
+	assert.True(t, broker.HasSubscribers(exampleVin))
+	assert.True(t, broker.HasSubscribers(otherVin))
+
+	broker.Publish(model.CarEvent{Vin: exampleVin})
+	broker.Publish(model.CarEvent{Vin: otherVin})
+
+	assert.Equal(t, model.CarEvent{Vin: exampleVin}, <-fleetEvents)
+	assert.Equal(t, model.CarEvent{Vin: otherVin}, <-fleetEvents)
+}
+
+func TestBroker_unsubscribe(t *testing.T) {
+	broker := NewBroker()
+
+	carEvents, unsubscribe := broker.Subscribe(exampleVin)
+	unsubscribe()
+	// ending a subscription twice has no effect
+	unsubscribe()
+
+	assert.False(t, broker.HasSubscribers(exampleVin))
+
+	broker.Publish(model.CarEvent{Vin: exampleVin})
+	_, open := <-carEvents
+	assert.False(t, open)
+}
+
+func TestBroker_slowSubscriber(t *testing.T) {
+	broker := NewBroker()
+
+	carEvents, unsubscribe := broker.Subscribe(exampleVin)
+	defer unsubscribe()
+
+	// publishing does not block if the subscriber does not consume the events
+	for i := 0; i < 2*subscriptionBufferSize; i++ {
+		broker.Publish(model.CarEvent{Vin: exampleVin})
+	}
+
+	assert.Len(t, carEvents, subscriptionBufferSize)
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"DCar/infrastructure/database"
+	"DCar/logic/model"
+	"context"
+	carTypes "github.com/ccsapp/cargotypes"
+)
+
+// publishingCRUD decorates a high level CRUD interface and publishes the dynamic data of a car after every
+// successful write of it. All other methods are passed through.
+type publishingCRUD struct {
+	database.ICRUD
+	broker *Broker
+}
+
+// NewPublishingCRUD wraps the given high level CRUD interface so that every change of the dynamic data is
+// published to the given broker. This covers the business logic as well as the simulator.
+func NewPublishingCRUD(crud database.ICRUD, broker *Broker) database.ICRUD {
+	return &publishingCRUD{
+		ICRUD:  crud,
+		broker: broker,
+	}
+}
+
+func (p *publishingCRUD) SetTrunkLockState(ctx context.Context, vin carTypes.Vin,
+	state carTypes.DynamicDataLockState, revision *int64) error {
+
+	return p.publishAfter(ctx, vin, p.ICRUD.SetTrunkLockState(ctx, vin, state, revision))
+}
+
+func (p *publishingCRUD) SetDoorsLockState(ctx context.Context, vin carTypes.Vin,
+	state carTypes.DynamicDataLockState) error {
+
+	return p.publishAfter(ctx, vin, p.ICRUD.SetDoorsLockState(ctx, vin, state))
+}
+
+func (p *publishingCRUD) SetEngineState(ctx context.Context, vin carTypes.Vin,
+	state carTypes.DynamicDataEngineState, revision *int64) error {
+
+	return p.publishAfter(ctx, vin, p.ICRUD.SetEngineState(ctx, vin, state, revision))
+}
+
+func (p *publishingCRUD) SetDynamicData(ctx context.Context, vin carTypes.Vin,
+	dynamicData *carTypes.DynamicData) error {
+
+	return p.publishAfter(ctx, vin, p.ICRUD.SetDynamicData(ctx, vin, dynamicData))
+}
+
+func (p *publishingCRUD) SetPositionAndFuelLevel(ctx context.Context, vin carTypes.Vin,
+	position carTypes.DynamicDataPosition, fuelLevelPercentage int, revision *int64) error {
+
+	return p.publishAfter(ctx, vin, p.ICRUD.SetPositionAndFuelLevel(ctx, vin, position, fuelLevelPercentage, revision))
+}
+
+// publishAfter publishes the current dynamic data of the car if the preceding write was successful and anyone
+// is interested in it. The error of the write is returned unchanged. Since the write already succeeded, a failure
+// to read the car only drops the event.
+func (p *publishingCRUD) publishAfter(ctx context.Context, vin carTypes.Vin, writeErr error) error {
+	if writeErr != nil || !p.broker.HasSubscribers(vin) {
+		return writeErr
+	}
+
+	car, err := p.ICRUD.ReadCar(ctx, vin)
+	if err != nil {
+		return nil
+	}
+
+	p.broker.Publish(model.CarEvent{
+		Vin:         vin,
+		DynamicData: car.DynamicData,
+	})
+	return nil
+}
@@ -0,0 +1,125 @@
+package events
+
+import (
+	"DCar/logic/model"
+	"DCar/mocks"
+	"context"
+	"errors"
+	"testing"
+
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var exampleCar = carTypes.Car{
+	Vin: exampleVin,
+	DynamicData: carTypes.DynamicData{
+		DoorsLockState:      carTypes.UNLOCKED,
+		EngineState:         carTypes.OFF,
+		FuelLevelPercentage: 42,
+		TrunkLockState:      carTypes.LOCKED,
+	},
+}
+
+func TestPublishingCRUD_publishesChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	broker := NewBroker()
+	carEvents, unsubscribe := broker.Subscribe(exampleVin)
+	defer unsubscribe()
+
+	position := carTypes.DynamicDataPosition{Latitude: 52.52, Longitude: 13.405}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil).Return(nil)
+	mockCrud.EXPECT().SetDoorsLockState(ctx, exampleVin, carTypes.UNLOCKED).Return(nil)
+	mockCrud.EXPECT().SetEngineState(ctx, exampleVin, carTypes.OFF, nil).Return(nil)
+	mockCrud.EXPECT().SetDynamicData(ctx, exampleVin, &exampleCar.DynamicData).Return(nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, exampleVin, position, 42, nil).Return(nil)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(exampleCar, nil).Times(5)
+
+	crud := NewPublishingCRUD(mockCrud, broker)
+	assert.Nil(t, crud.SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil))
+	assert.Nil(t, crud.SetDoorsLockState(ctx, exampleVin, carTypes.UNLOCKED))
+	assert.Nil(t, crud.SetEngineState(ctx, exampleVin, carTypes.OFF, nil))
+	assert.Nil(t, crud.SetDynamicData(ctx, exampleVin, &exampleCar.DynamicData))
+	assert.Nil(t, crud.SetPositionAndFuelLevel(ctx, exampleVin, position, 42, nil))
+
+	expectedEvent := model.CarEvent{Vin: exampleVin, DynamicData: exampleCar.DynamicData}
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, expectedEvent, <-carEvents)
+	}
+}
+
+func TestPublishingCRUD_noSubscribers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// without any subscribers the car is not read
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil).Return(nil)
+
+	crud := NewPublishingCRUD(mockCrud, NewBroker())
+	assert.Nil(t, crud.SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil))
+}
+
+func TestPublishingCRUD_writeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	broker := NewBroker()
+	carEvents, unsubscribe := broker.Subscribe(exampleVin)
+	defer unsubscribe()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil).Return(mongo.ErrNoDocuments)
+
+	crud := NewPublishingCRUD(mockCrud, broker)
+	assert.ErrorIs(t, crud.SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil), mongo.ErrNoDocuments)
+	assert.Empty(t, carEvents)
+}
+
+func TestPublishingCRUD_readError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	broker := NewBroker()
+	carEvents, unsubscribe := broker.Subscribe(exampleVin)
+	defer unsubscribe()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil).Return(nil)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(carTypes.Car{}, errors.New("db error"))
+
+	// the write succeeded, so only the event is dropped
+	crud := NewPublishingCRUD(mockCrud, broker)
+	assert.Nil(t, crud.SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil))
+	assert.Empty(t, carEvents)
+}
+
+func TestPublishingCRUD_passThrough(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadAllVins(ctx).Return([]carTypes.Vin{exampleVin}, nil)
+
+	crud := NewPublishingCRUD(mockCrud, NewBroker())
+	vins, err := crud.ReadAllVins(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Vin{exampleVin}, vins)
+}
@@ -0,0 +1,75 @@
+// Package geo provides calculations on geographic coordinates. The earth is approximated by a sphere, which is
+// precise enough for the distances a car travels.
+package geo
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"math"
+)
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6371008.8
+
+func toRadians(degrees float32) float64 {
+	return float64(degrees) * math.Pi / 180
+}
+
+func toDegrees(radians float64) float32 {
+	return float32(radians * 180 / math.Pi)
+}
+
+// Distance returns the great-circle distance between two positions in meters.
+func Distance(from, to carTypes.DynamicDataPosition) float64 {
+	fromLatitude, toLatitude := toRadians(from.Latitude), toRadians(to.Latitude)
+	deltaLatitude := toLatitude - fromLatitude
+	deltaLongitude := toRadians(to.Longitude) - toRadians(from.Longitude)
+
+	// haversine formula
+	a := math.Pow(math.Sin(deltaLatitude/2), 2) +
+		math.Cos(fromLatitude)*math.Cos(toLatitude)*math.Pow(math.Sin(deltaLongitude/2), 2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Destination returns the position that is reached when moving the given distance in meters from a position
+// along the given bearing in degrees (clockwise from north).
+func Destination(from carTypes.DynamicDataPosition, bearing float64, distance float64) carTypes.DynamicDataPosition {
+	latitude, longitude := toRadians(from.Latitude), toRadians(from.Longitude)
+	angularDistance := distance / EarthRadius
+	bearingRadians := bearing * math.Pi / 180
+
+	destinationLatitude := math.Asin(math.Sin(latitude)*math.Cos(angularDistance) +
+		math.Cos(latitude)*math.Sin(angularDistance)*math.Cos(bearingRadians))
+	destinationLongitude := longitude + math.Atan2(
+		math.Sin(bearingRadians)*math.Sin(angularDistance)*math.Cos(latitude),
+		math.Cos(angularDistance)-math.Sin(latitude)*math.Sin(destinationLatitude))
+
+	// normalize the longitude to [-180, 180)
+	destinationLongitude = math.Mod(destinationLongitude+3*math.Pi, 2*math.Pi) - math.Pi
+
+	return carTypes.DynamicDataPosition{
+		Latitude:  toDegrees(destinationLatitude),
+		Longitude: toDegrees(destinationLongitude),
+	}
+}
+
+// MoveTowards returns the position that is reached when moving the given distance in meters from a position
+// towards a target. If the target is closer than the distance, the target is returned.
+func MoveTowards(from, to carTypes.DynamicDataPosition, distance float64) carTypes.DynamicDataPosition {
+	totalDistance := Distance(from, to)
+	if totalDistance <= distance {
+		return to
+	}
+
+	return Destination(from, Bearing(from, to), distance)
+}
+
+// Bearing returns the initial bearing in degrees (clockwise from north) of the great-circle path between two
+// positions.
+func Bearing(from, to carTypes.DynamicDataPosition) float64 {
+	fromLatitude, toLatitude := toRadians(from.Latitude), toRadians(to.Latitude)
+	deltaLongitude := toRadians(to.Longitude) - toRadians(from.Longitude)
+
+	bearing := math.Atan2(math.Sin(deltaLongitude)*math.Cos(toLatitude),
+		math.Cos(fromLatitude)*math.Sin(toLatitude)-math.Sin(fromLatitude)*math.Cos(toLatitude)*math.Cos(deltaLongitude))
+	return math.Mod(bearing*180/math.Pi+360, 360)
+}
@@ -0,0 +1,51 @@
+package geo
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var karlsruhe = carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+var berlin = carTypes.DynamicDataPosition{Latitude: 52.5200, Longitude: 13.4050}
+
+func TestDistance(t *testing.T) {
+	// the great-circle distance between Karlsruhe and Berlin is about 525 km
+	assert.InDelta(t, 525_000, Distance(karlsruhe, berlin), 2_000)
+	assert.InDelta(t, Distance(karlsruhe, berlin), Distance(berlin, karlsruhe), 0.001)
+	assert.Equal(t, 0.0, Distance(karlsruhe, karlsruhe))
+}
+
+func TestDestination(t *testing.T) {
+	for _, bearing := range []float64{0, 45, 90, 180, 270} {
+		destination := Destination(karlsruhe, bearing, 1_000)
+		assert.InDelta(t, 1_000, Distance(karlsruhe, destination), 1, bearing)
+	}
+
+	north := Destination(karlsruhe, 0, 1_000)
+	assert.Greater(t, north.Latitude, karlsruhe.Latitude)
+	assert.InDelta(t, karlsruhe.Longitude, north.Longitude, 0.00001)
+}
+
+func TestDestination_antimeridian(t *testing.T) {
+	destination := Destination(carTypes.DynamicDataPosition{Latitude: 0, Longitude: 179.999}, 90, 1_000)
+
+	assert.Less(t, destination.Longitude, float32(-179))
+}
+
+func TestMoveTowards(t *testing.T) {
+	position := MoveTowards(karlsruhe, berlin, 10_000)
+
+	assert.InDelta(t, 10_000, Distance(karlsruhe, position), 50)
+	assert.InDelta(t, Distance(karlsruhe, berlin)-10_000, Distance(position, berlin), 50)
+}
+
+func TestBearing(t *testing.T) {
+	assert.InDelta(t, 0, Bearing(karlsruhe, Destination(karlsruhe, 0, 1_000)), 0.01)
+	assert.InDelta(t, 90, Bearing(karlsruhe, Destination(karlsruhe, 90, 1_000)), 0.01)
+	assert.InDelta(t, 225, Bearing(karlsruhe, Destination(karlsruhe, 225, 1_000)), 0.01)
+}
+
+func TestMoveTowards_targetReached(t *testing.T) {
+	assert.Equal(t, berlin, MoveTowards(karlsruhe, berlin, 1_000_000))
+}
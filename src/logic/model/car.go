@@ -0,0 +1,10 @@
+package model
+
+// StoredCar is a car as it is stored, together with the values derived from the car when it was stored.
+type StoredCar struct {
+	CarWithRevision
+
+	// TireSpecification is the specification parsed from the tire type, it is nil if the tire type cannot be parsed
+	// or the car was stored before the specification was derived
+	TireSpecification *TireSpecification
+}
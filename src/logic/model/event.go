@@ -0,0 +1,14 @@
+package model
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+)
+
+// CarEvent notifies about the dynamic data of a car after it has been changed.
+type CarEvent struct {
+	// Vin is the VIN of the changed car
+	Vin carTypes.Vin `json:"vin"`
+
+	// DynamicData is the dynamic data of the car after the change
+	DynamicData carTypes.DynamicData `json:"dynamicData"`
+}
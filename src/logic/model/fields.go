@@ -0,0 +1,38 @@
+package model
+
+// CarFields are the fields of a car that can be selected for a projection. Nested fields are separated by a dot.
+var CarFields = []string{
+	"vin",
+	"brand",
+	"model",
+	"productionDate",
+	"technicalSpecification",
+	"technicalSpecification.color",
+	"technicalSpecification.consumption",
+	"technicalSpecification.emissions",
+	"technicalSpecification.engine",
+	"technicalSpecification.fuel",
+	"technicalSpecification.fuelCapacity",
+	"technicalSpecification.numberOfDoors",
+	"technicalSpecification.numberOfSeats",
+	"technicalSpecification.tire",
+	"technicalSpecification.transmission",
+	"technicalSpecification.trunkVolume",
+	"technicalSpecification.weight",
+	"dynamicData",
+	"dynamicData.doorsLockState",
+	"dynamicData.engineState",
+	"dynamicData.fuelLevelPercentage",
+	"dynamicData.position",
+	"dynamicData.trunkLockState",
+}
+
+// IsCarField checks if the given field is one of the CarFields.
+func IsCarField(field string) bool {
+	for _, carField := range CarFields {
+		if carField == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package model
+
+import carTypes "github.com/ccsapp/cargotypes"
+
+// CarFilter restricts a list of cars to those matching all given static attributes. Attributes that are nil are
+// not used for filtering, so the zero value matches all cars.
+type CarFilter struct {
+	// Brand matches the brand ignoring case
+	Brand *string
+
+	// Model matches the model ignoring case
+	Model *string
+
+	// Color matches the color ignoring case
+	Color *string
+
+	// Fuel matches the fuel exactly
+	Fuel *carTypes.TechnicalSpecificationFuel
+
+	// Transmission matches the transmission exactly
+	Transmission *carTypes.TechnicalSpecificationTransmission
+
+	// MinSeats is the minimum number of seats
+	MinSeats *int
+
+	// MinTrunkVolume is the minimum trunk volume in liters
+	MinTrunkVolume *int
+
+	// IncludeArchived also matches archived cars, which are left out otherwise
+	IncludeArchived bool
+}
+
+// IsEmpty returns true if no attribute is set and the filter matches all cars that are not archived.
+func (f *CarFilter) IsEmpty() bool {
+	return *f == CarFilter{}
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"errors"
+	carTypes "github.com/ccsapp/cargotypes"
+	"strconv"
+	"strings"
+)
+
+const (
+	litersSuffix        = "L"
+	kiloWattHoursSuffix = "kWh"
+	capacitySeparator   = ";"
+)
+
+var invalidFuelCapacityError = errors.New("invalid fuel capacity")
+
+// FuelCapacityError is returned if the fuel capacity of a car contradicts its fuel. The reason describes the
+// contradiction and can be presented to the client.
+type FuelCapacityError struct {
+	Fuel   carTypes.TechnicalSpecificationFuel
+	Reason string
+}
+
+func (e *FuelCapacityError) Error() string {
+	return e.Reason
+}
+
+// IsFuelCapacityError checks if the error is a FuelCapacityError.
+func IsFuelCapacityError(err error) bool {
+	var fuelCapacityError *FuelCapacityError
+	return errors.As(err, &fuelCapacityError)
+}
+
+// FuelCapacity is the parsed representation of the fuelCapacity of a car's technical specification, e.g.
+// "54.0L;85.2kWh". A capacity that is not part of the specification is nil.
+type FuelCapacity struct {
+	// Liters is the capacity of the tank in liters
+	Liters *float64 `json:"liters,omitempty"`
+
+	// KiloWattHours is the capacity of the battery in kWh
+	KiloWattHours *float64 `json:"kiloWattHours,omitempty"`
+}
+
+// ParseFuelCapacity parses a fuel capacity in the format of the API specification, i.e. a capacity in liters
+// (e.g. "54.0L"), a capacity in kWh (e.g. "85.2kWh") or both separated by a semicolon (e.g. "54.0L;85.2kWh").
+// If the fuel capacity has an invalid format, an error is returned.
+func ParseFuelCapacity(fuelCapacity string) (FuelCapacity, error) {
+	var result FuelCapacity
+
+	for _, part := range strings.Split(fuelCapacity, capacitySeparator) {
+		var err error
+		switch {
+		case strings.HasSuffix(part, kiloWattHoursSuffix) && result.KiloWattHours == nil:
+			result.KiloWattHours, err = parseCapacity(strings.TrimSuffix(part, kiloWattHoursSuffix))
+		case strings.HasSuffix(part, litersSuffix) && result.Liters == nil && result.KiloWattHours == nil:
+			result.Liters, err = parseCapacity(strings.TrimSuffix(part, litersSuffix))
+		default:
+			err = invalidFuelCapacityError
+		}
+		if err != nil {
+			return FuelCapacity{}, err
+		}
+	}
+
+	return result, nil
+}
+
+func parseCapacity(value string) (*float64, error) {
+	capacity, err := strconv.ParseFloat(value, 64)
+	if err != nil || capacity < 0 {
+		return nil, invalidFuelCapacityError
+	}
+	return &capacity, nil
+}
+
+// CheckFuel checks whether the fuel capacity matches the given fuel. An electric car needs a capacity in kWh, a car
+// with a combustion engine only has a capacity in liters and a hybrid car needs both. If the fuel capacity
+// contradicts the fuel, a FuelCapacityError is returned.
+func (c FuelCapacity) CheckFuel(fuel carTypes.TechnicalSpecificationFuel) error {
+	var reason string
+	switch fuel {
+	case carTypes.ELECTRIC:
+		if c.KiloWattHours == nil {
+			reason = "An electric car needs a fuel capacity in kWh."
+		}
+	case carTypes.DIESEL, carTypes.PETROL:
+		if c.Liters == nil || c.KiloWattHours != nil {
+			reason = "A car with a combustion engine needs a fuel capacity in liters only."
+		}
+	case carTypes.HYBRIDDIESEL, carTypes.HYBRIDPETROL:
+		if c.Liters == nil || c.KiloWattHours == nil {
+			reason = "A hybrid car needs a fuel capacity in liters and in kWh."
+		}
+	}
+
+	if reason != "" {
+		return &FuelCapacityError{Fuel: fuel, Reason: reason}
+	}
+	return nil
+}
+
+// ParseCarFuelCapacity parses the fuel capacity of the car and checks whether it matches the fuel of the car. If the
+// fuel capacity has an invalid format or contradicts the fuel, a FuelCapacityError is returned.
+func ParseCarFuelCapacity(car *carTypes.Car) (FuelCapacity, error) {
+	capacity, err := ParseFuelCapacity(car.TechnicalSpecification.FuelCapacity)
+	if err != nil {
+		return FuelCapacity{}, &FuelCapacityError{
+			Fuel:   car.TechnicalSpecification.Fuel,
+			Reason: "The fuel capacity has an invalid format.",
+		}
+	}
+	if err := capacity.CheckFuel(car.TechnicalSpecification.Fuel); err != nil {
+		return FuelCapacity{}, err
+	}
+	return capacity, nil
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestParseFuelCapacity_liters(t *testing.T) {
+	capacity, err := ParseFuelCapacity("54.0L")
+
+	assert.Nil(t, err)
+	assert.Equal(t, FuelCapacity{Liters: ptr(54.0)}, capacity)
+}
+
+func TestParseFuelCapacity_kiloWattHours(t *testing.T) {
+	capacity, err := ParseFuelCapacity("85.2kWh")
+
+	assert.Nil(t, err)
+	assert.Equal(t, FuelCapacity{KiloWattHours: ptr(85.2)}, capacity)
+}
+
+func TestParseFuelCapacity_both(t *testing.T) {
+	capacity, err := ParseFuelCapacity("54.0L;85.2kWh")
+
+	assert.Nil(t, err)
+	assert.Equal(t, FuelCapacity{Liters: ptr(54.0), KiloWattHours: ptr(85.2)}, capacity)
+}
+
+func TestParseFuelCapacity_invalid(t *testing.T) {
+	for _, fuelCapacity := range []string{"", "54.0", "54.0gal", "L", "-1.0L", "85.2kWh;54.0L", "54.0L;54.0L",
+		"54.0L;85.2kWh;1.0kWh"} {
+
+		_, err := ParseFuelCapacity(fuelCapacity)
+		assert.ErrorIs(t, err, invalidFuelCapacityError, fuelCapacity)
+	}
+}
+
+func TestFuelCapacity_CheckFuel_valid(t *testing.T) {
+	liters := FuelCapacity{Liters: ptr(54.0)}
+	kiloWattHours := FuelCapacity{KiloWattHours: ptr(85.2)}
+	both := FuelCapacity{Liters: ptr(54.0), KiloWattHours: ptr(85.2)}
+
+	assert.Nil(t, liters.CheckFuel(carTypes.DIESEL))
+	assert.Nil(t, liters.CheckFuel(carTypes.PETROL))
+	assert.Nil(t, kiloWattHours.CheckFuel(carTypes.ELECTRIC))
+	// an electric car may have a range extender
+	assert.Nil(t, both.CheckFuel(carTypes.ELECTRIC))
+	assert.Nil(t, both.CheckFuel(carTypes.HYBRIDDIESEL))
+	assert.Nil(t, both.CheckFuel(carTypes.HYBRIDPETROL))
+}
+
+func TestFuelCapacity_CheckFuel_invalid(t *testing.T) {
+	liters := FuelCapacity{Liters: ptr(54.0)}
+	kiloWattHours := FuelCapacity{KiloWattHours: ptr(85.2)}
+	both := FuelCapacity{Liters: ptr(54.0), KiloWattHours: ptr(85.2)}
+
+	for _, check := range []struct {
+		capacity FuelCapacity
+		fuel     carTypes.TechnicalSpecificationFuel
+	}{
+		{liters, carTypes.ELECTRIC},
+		{kiloWattHours, carTypes.PETROL},
+		{both, carTypes.PETROL},
+		{both, carTypes.DIESEL},
+		{liters, carTypes.HYBRIDPETROL},
+		{kiloWattHours, carTypes.HYBRIDDIESEL},
+	} {
+		err := check.capacity.CheckFuel(check.fuel)
+		assert.True(t, IsFuelCapacityError(err), check.fuel)
+	}
+}
+
+func TestParseCarFuelCapacity(t *testing.T) {
+	car := carTypes.Car{TechnicalSpecification: carTypes.TechnicalSpecification{
+		Fuel:         carTypes.HYBRIDPETROL,
+		FuelCapacity: "54.0L;85.2kWh",
+	}}
+
+	capacity, err := ParseCarFuelCapacity(&car)
+
+	assert.Nil(t, err)
+	assert.Equal(t, FuelCapacity{Liters: ptr(54.0), KiloWattHours: ptr(85.2)}, capacity)
+}
+
+func TestParseCarFuelCapacity_contradiction(t *testing.T) {
+	car := carTypes.Car{TechnicalSpecification: carTypes.TechnicalSpecification{
+		Fuel:         carTypes.DIESEL,
+		FuelCapacity: "58.0L;85.2kWh",
+	}}
+
+	_, err := ParseCarFuelCapacity(&car)
+
+	assert.Equal(t, &FuelCapacityError{
+		Fuel:   carTypes.DIESEL,
+		Reason: "A car with a combustion engine needs a fuel capacity in liters only.",
+	}, err)
+}
+
+func TestParseCarFuelCapacity_invalidFormat(t *testing.T) {
+	car := carTypes.Car{TechnicalSpecification: carTypes.TechnicalSpecification{
+		Fuel:         carTypes.DIESEL,
+		FuelCapacity: "58.0gal",
+	}}
+
+	_, err := ParseCarFuelCapacity(&car)
+
+	assert.True(t, IsFuelCapacityError(err))
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"time"
+)
+
+// PositionRecord is a position of a car recorded at a specific time. A record is created whenever the position
+// or the engine state of a car changes.
+type PositionRecord struct {
+	// Timestamp is the time the position was recorded
+	Timestamp time.Time `json:"timestamp"`
+
+	// Position is the GeoCoordinate of the car at the time of the record
+	Position carTypes.DynamicDataPosition `json:"position"`
+
+	// EngineState indicates whether the engine was running at the time of the record
+	EngineState carTypes.DynamicDataEngineState `json:"engineState"`
+}
+
+// TripEndpoint is the start or the end of a trip.
+type TripEndpoint struct {
+	// Timestamp is the time the engine was started or stopped
+	Timestamp time.Time `json:"timestamp"`
+
+	// Position is the GeoCoordinate of the car when the engine was started or stopped
+	Position carTypes.DynamicDataPosition `json:"position"`
+}
+
+// Trip is a drive of a car that starts when the engine is started and ends when the engine is stopped.
+type Trip struct {
+	Start TripEndpoint `json:"start"`
+	End   TripEndpoint `json:"end"`
+
+	// Distance is the driven distance in meters
+	Distance float64 `json:"distance"`
+
+	// Duration is the duration of the trip in seconds
+	Duration float64 `json:"duration"`
+}
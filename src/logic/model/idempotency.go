@@ -0,0 +1,23 @@
+package model
+
+// IdempotencyRecord is the state of an idempotency key chosen by a client to make the repetition of a request safe.
+type IdempotencyRecord struct {
+	// RequestHash identifies the request the key was first used for
+	RequestHash string
+
+	// Response is the response to the request, it is nil while the request is still handled
+	Response *IdempotentResponse
+}
+
+// IdempotentResponse is the response to a request with an idempotency key that is replayed for every repetition of
+// the request.
+type IdempotentResponse struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Header contains the header fields of the response
+	Header map[string][]string
+
+	// Body is the body of the response, it is empty if the response has no body
+	Body []byte
+}
@@ -0,0 +1,29 @@
+package model
+
+import carTypes "github.com/ccsapp/cargotypes"
+
+// VinPage is a page of VINs in ascending order.
+type VinPage struct {
+	// Vins are the VINs on this page
+	Vins []carTypes.Vin
+
+	// Next is the VIN after which the next page starts, it is nil if this is the last page
+	Next *carTypes.Vin
+}
+
+// CarPage is a page of cars ordered by their VIN.
+type CarPage struct {
+	// Cars are the cars on this page
+	Cars []carTypes.Car
+
+	// Next is the VIN after which the next page starts, it is nil if this is the last page
+	Next *carTypes.Vin
+}
+
+// CarWithRevision is a car together with its revision, the revision is increased with every change of the car.
+type CarWithRevision struct {
+	Car carTypes.Car
+
+	// Revision is the revision of the car when it was read
+	Revision int64
+}
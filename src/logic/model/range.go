@@ -0,0 +1,45 @@
+package model
+
+import "errors"
+
+// RangeProfile is the driving profile whose consumption is used to estimate the range of a car.
+type RangeProfile string
+
+const (
+	RangeProfileCity     RangeProfile = "city"
+	RangeProfileOverland RangeProfile = "overland"
+	RangeProfileCombined RangeProfile = "combined"
+)
+
+// RangeEstimate is the estimated remaining driving range of a car. The range of an energy source the car does not
+// use is nil, so a hybrid car has both ranges, an electric car only the electric one and any other car only the
+// combustion one.
+type RangeEstimate struct {
+	// Profile is the driving profile the estimate is based on
+	Profile RangeProfile `json:"profile"`
+
+	// Total is the range in km the car can drive with all of its energy sources
+	Total float64 `json:"total"`
+
+	// Electric is the range in km the car can drive with its battery
+	Electric *float64 `json:"electric,omitempty"`
+
+	// Combustion is the range in km the car can drive with its tank
+	Combustion *float64 `json:"combustion,omitempty"`
+}
+
+// RangeUnknownError is returned if the range of a car cannot be estimated from its data. The reason can be
+// presented to the client.
+type RangeUnknownError struct {
+	Reason string
+}
+
+func (e *RangeUnknownError) Error() string {
+	return e.Reason
+}
+
+// IsRangeUnknownError checks if the error is a RangeUnknownError.
+func IsRangeUnknownError(err error) bool {
+	var rangeUnknownError *RangeUnknownError
+	return errors.As(err, &rangeUnknownError)
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+// TireConstruction is the construction of the carcass of a tire.
+type TireConstruction string
+
+const (
+	TireConstructionRadial   TireConstruction = "R"
+	TireConstructionDiagonal TireConstruction = "D"
+)
+
+// tireTypePattern is the pattern of a tire type of the API specification, e.g. "205/55R16" or "225/45RF1791W".
+// The groups are the width, the aspect ratio, the construction, the run-flat marker, the rim diameter, the load
+// index and the speed rating.
+var tireTypePattern = regexp.MustCompile(`^(\d{3})/(\d{2})([RD])(F?)(\d{2})(\d{2,3})?(A[1-8]|[B-H]|[J-N]|[P-W]|Y)?$`)
+
+var invalidTireTypeError = errors.New("invalid tire type")
+
+// TireSpecification is the parsed representation of the type of a car's tires, e.g. "205/55R1691V". The load
+// index and the speed rating are nil if they are not part of the type.
+type TireSpecification struct {
+	// Width is the width of the tire in millimeters
+	Width int `json:"width"`
+
+	// AspectRatio is the height of the sidewall in percent of the width
+	AspectRatio int `json:"aspectRatio"`
+
+	// Construction is the construction of the carcass
+	Construction TireConstruction `json:"construction"`
+
+	// RunFlat indicates whether the tire can be driven on after a puncture
+	RunFlat bool `json:"runFlat"`
+
+	// RimDiameter is the diameter of the rim in inches
+	RimDiameter int `json:"rimDiameter"`
+
+	// LoadIndex is the code of the maximum load the tire can carry
+	LoadIndex *int `json:"loadIndex,omitempty"`
+
+	// SpeedRating is the code of the maximum speed the tire is designed for
+	SpeedRating *string `json:"speedRating,omitempty"`
+}
+
+// ParseTireType parses a tire type in the format of the API specification, i.e. the width, the aspect ratio, the
+// construction (R or D, followed by F for run-flat tires) and the rim diameter, optionally followed by the load index
+// and the speed rating (e.g. "205/55R16" or "205/55RF1691V"). If the tire type has an invalid format, an error is
+// returned.
+func ParseTireType(tireType string) (TireSpecification, error) {
+	groups := tireTypePattern.FindStringSubmatch(tireType)
+	if groups == nil {
+		return TireSpecification{}, invalidTireTypeError
+	}
+
+	// the pattern guarantees that the numbers can be converted
+	result := TireSpecification{
+		Width:        atoi(groups[1]),
+		AspectRatio:  atoi(groups[2]),
+		Construction: TireConstruction(groups[3]),
+		RunFlat:      groups[4] != "",
+		RimDiameter:  atoi(groups[5]),
+	}
+	if groups[6] != "" {
+		loadIndex := atoi(groups[6])
+		result.LoadIndex = &loadIndex
+	}
+	if groups[7] != "" {
+		result.SpeedRating = &groups[7]
+	}
+	return result, nil
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseTireType(t *testing.T) {
+	specification, err := ParseTireType("205/55R16")
+
+	assert.Nil(t, err)
+	assert.Equal(t, TireSpecification{
+		Width:        205,
+		AspectRatio:  55,
+		Construction: TireConstructionRadial,
+		RimDiameter:  16,
+	}, specification)
+}
+
+func TestParseTireType_full(t *testing.T) {
+	specification, err := ParseTireType("225/45DF17101A8")
+
+	loadIndex := 101
+	speedRating := "A8"
+	assert.Nil(t, err)
+	assert.Equal(t, TireSpecification{
+		Width:        225,
+		AspectRatio:  45,
+		Construction: TireConstructionDiagonal,
+		RunFlat:      true,
+		RimDiameter:  17,
+		LoadIndex:    &loadIndex,
+		SpeedRating:  &speedRating,
+	}, specification)
+}
+
+func TestParseTireType_optionalParts(t *testing.T) {
+	specification, err := ParseTireType("185/65R1588")
+	assert.Nil(t, err)
+	assert.Equal(t, 88, *specification.LoadIndex)
+	assert.Nil(t, specification.SpeedRating)
+
+	specification, err = ParseTireType("185/65R15H")
+	assert.Nil(t, err)
+	assert.Nil(t, specification.LoadIndex)
+	assert.Equal(t, "H", *specification.SpeedRating)
+}
+
+func TestParseTireType_invalid(t *testing.T) {
+	for _, tireType := range []string{"", "205/55", "205/55X16", "20/55R16", "205/55R16 91V", "205/55R1691I",
+		"205/55R1691A9", "205/55R161234"} {
+
+		_, err := ParseTireType(tireType)
+		assert.ErrorIs(t, err, invalidTireTypeError, tireType)
+	}
+}
@@ -0,0 +1,48 @@
+package operations
+
+import "errors"
+
+// RuleViolationError is returned if a requested change of a car's state violates a domain rule. The reason
+// describes the violated rule and can be presented to the client.
+type RuleViolationError struct {
+	Reason string
+}
+
+func (e *RuleViolationError) Error() string {
+	return e.Reason
+}
+
+// IsRuleViolationError checks if the error is a RuleViolationError.
+func IsRuleViolationError(err error) bool {
+	var ruleViolationError *RuleViolationError
+	return errors.As(err, &ruleViolationError)
+}
+
+// IdempotencyKeyError is returned if a request cannot be handled because its idempotency key is already used. If
+// InProgress is true, the first request with the key is still handled, otherwise the key was used for a different
+// request.
+type IdempotencyKeyError struct {
+	InProgress bool
+}
+
+func (e *IdempotencyKeyError) Error() string {
+	if e.InProgress {
+		return "a request with this idempotency key is still in progress"
+	}
+	return "the idempotency key has already been used for a different request"
+}
+
+var (
+	emptyTankError = &RuleViolationError{Reason: "The engine cannot be started because the tank is empty."}
+
+	engineStartWithUnlockedTrunkError = &RuleViolationError{
+		Reason: "The engine cannot be started while the trunk is unlocked."}
+
+	trunkUnlockWithRunningEngineError = &RuleViolationError{
+		Reason: "The trunk cannot be unlocked while the engine is running."}
+
+	vinChangeError = &RuleViolationError{Reason: "The VIN of a car cannot be changed."}
+
+	concurrentChangeError = &RuleViolationError{
+		Reason: "The car was changed while the rule was checked, please try again."}
+)
@@ -0,0 +1,606 @@
+package operations
+
+//go:generate mockgen -source=./operations.go -package=mocks -destination=../../mocks/mock_operations.go
+
+import (
+	"DCar/infrastructure/database"
+	"DCar/logic/events"
+	"DCar/logic/model"
+	"DCar/logic/validation"
+	"DCar/logic/vin"
+	"context"
+	carTypes "github.com/ccsapp/cargotypes"
+	"log"
+	"time"
+)
+
+// IOperations is the business logic layer of Car. It enforces the domain rules of a car and uses the high level
+// CRUD interface to access the database. Errors of the CRUD interface are passed through, so you can check them
+// with database.IsNotFoundError, database.IsDuplicateKeyError and database.IsRevisionMismatchError.
+//
+// Methods that accept a revision only change the car if it still has this revision (see ReadStoredCar),
+// otherwise a revision mismatch error is returned. A nil revision changes the car unconditionally.
+type IOperations interface {
+	// CreateCar creates a new car and returns its VIN and the warnings about the car, e.g. an invalid VIN that is
+	// accepted in warn mode. If the VIN already exists, a duplicate key error is returned, which is also a
+	// database.ArchivedCarError if the existing car is archived.
+	// If the car is implausible, a validation.Error is returned. Depending on the configured check mode, a
+	// vin.CheckDigitError is returned if the check digit of the VIN is invalid, and a vin.InconsistencyError if the
+	// VIN contradicts the brand or the production date of the car. Any other errors are unexpected.
+	CreateCar(ctx context.Context, car *carTypes.Car) (carTypes.Vin, []string, error)
+
+	// CreateCars creates all given cars at once and returns the error for every car at the same index, or nil if the
+	// car was created, and the warnings about every car at the same index as for CreateCar. A car that cannot be
+	// created does not prevent the creation of the others. If the VIN of a car already exists, its error is a
+	// duplicate key error. If the car is rejected, its error is a validation.Error, a vin.CheckDigitError or a
+	// vin.InconsistencyError as for CreateCar. The last return value is for unexpected errors.
+	CreateCars(ctx context.Context, cars []carTypes.Car) ([]error, [][]string, error)
+
+	// ReadAllVins returns the VINs of all cars. Any errors are unexpected.
+	ReadAllVins(ctx context.Context) ([]carTypes.Vin, error)
+
+	// ReadVinsMatching returns the VINs of all cars matching the given filter. Any errors are unexpected.
+	ReadVinsMatching(ctx context.Context, filter model.CarFilter) ([]carTypes.Vin, error)
+
+	// ReadVinsPage returns a page of at most limit VINs of the cars matching the given filter in ascending order. If
+	// after is not nil, the page starts after this VIN, which does not need to exist. Any errors are unexpected.
+	ReadVinsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int) (model.VinPage, error)
+
+	// ReadVinsNear returns the VINs of all cars matching the given filter within the given radius in meters around
+	// the given position, sorted by their distance to the position. Any errors are unexpected.
+	ReadVinsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64,
+		filter model.CarFilter) ([]carTypes.Vin, error)
+
+	// ReadCarsMatching returns all cars matching the given filter. If fields is not nil, only the given fields
+	// (see model.CarFields) of the cars are read and all other fields are empty. Any errors are unexpected.
+	ReadCarsMatching(ctx context.Context, filter model.CarFilter, fields []string) ([]carTypes.Car, error)
+
+	// ReadCarsPage returns a page of at most limit cars matching the given filter ordered by their VIN. If after is
+	// not nil, the page starts after this VIN, which does not need to exist. The fields are handled like in
+	// ReadCarsMatching. Any errors are unexpected.
+	ReadCarsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int, fields []string) (
+		model.CarPage, error)
+
+	// ReadCarsNear returns all cars matching the given filter within the given radius in meters around the given
+	// position, sorted by their distance to the position. The fields are handled like in ReadCarsMatching.
+	// Any errors are unexpected.
+	ReadCarsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64, filter model.CarFilter,
+		fields []string) ([]carTypes.Car, error)
+
+	// ForEachCar passes all cars ordered by their VIN to each, one after another, without holding all cars in memory.
+	// If each returns an error, no further cars are read and the error is returned. Any other errors are unexpected.
+	ForEachCar(ctx context.Context, each func(car *carTypes.Car) error) error
+
+	// ArchiveCar archives the car with the given VIN and returns true. Archived cars are hidden until they are
+	// restored. If the car does not exist or is already archived, false is returned. Any errors besides a revision
+	// mismatch are unexpected.
+	ArchiveCar(ctx context.Context, vin carTypes.Vin, revision *int64) (bool, error)
+
+	// RestoreCar restores the archived car with the given VIN and returns true. If there is no archived car with
+	// this VIN, false is returned. Any errors are unexpected.
+	RestoreCar(ctx context.Context, vin carTypes.Vin) (bool, error)
+
+	// PurgeCar irreversibly deletes the car with the given VIN, archived or not, and returns true. If the car does
+	// not exist, false is returned. Any errors are unexpected.
+	PurgeCar(ctx context.Context, vin carTypes.Vin) (bool, error)
+
+	// ReadCar returns the car with the given VIN. If the car does not exist, a not found error is returned.
+	// Any other errors are unexpected.
+	ReadCar(ctx context.Context, vin carTypes.Vin) (carTypes.Car, error)
+
+	// ReadStoredCar works like ReadCar, but additionally returns the current revision of the car and the values
+	// derived from the car when it was stored. The revision changes with every change of the car, including changes
+	// of the dynamic data.
+	ReadStoredCar(ctx context.Context, vin carTypes.Vin) (model.StoredCar, error)
+
+	// UpdateStaticData changes the static data of the car with the given VIN and returns the updated car with its new
+	// revision. The update function receives a copy of the current car and modifies it, errors of the update function
+	// are returned unchanged. Changes of the dynamic data are ignored. If the car does not exist, a not found error is
+	// returned. If the VIN should be changed, a RuleViolationError is returned. If the updated car is implausible, a
+	// validation.Error is returned. Without a revision, a RuleViolationError is also returned if the car is changed
+	// while the update is checked. Any errors besides a revision mismatch are unexpected.
+	UpdateStaticData(ctx context.Context, vin carTypes.Vin, revision *int64,
+		update func(car *carTypes.Car) error) (model.CarWithRevision, error)
+
+	// SetTrunkLockState sets the trunk lock state of the car with the given VIN. If the car does not exist, a not
+	// found error is returned. If the trunk should be unlocked while the engine is running, or the engine state
+	// changes while this is checked, a RuleViolationError is returned. Any errors besides a revision mismatch are
+	// unexpected.
+	SetTrunkLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState,
+		revision *int64) error
+
+	// SetDoorsLockState sets the doors lock state of the car with the given VIN. If the car does not exist, a not
+	// found error is returned. Any other errors are unexpected.
+	SetDoorsLockState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataLockState) error
+
+	// SetEngineState starts or stops the engine of the car with the given VIN. If the car does not exist, a not
+	// found error is returned. If the engine should be started while the tank is empty or while the trunk is
+	// unlocked, or the car changes while this is checked, a RuleViolationError is returned. Any other errors are
+	// unexpected.
+	SetEngineState(ctx context.Context, vin carTypes.Vin, state carTypes.DynamicDataEngineState) error
+
+	// SetDynamicData replaces the dynamic data of the car with the given VIN by the data reported by the vehicle.
+	// Since the reported data describes the actual state of the vehicle, no domain rules are enforced. If the car
+	// does not exist, a not found error is returned. Any other errors are unexpected.
+	SetDynamicData(ctx context.Context, vin carTypes.Vin, dynamicData *carTypes.DynamicData) error
+
+	// ReadPositionRecords returns the position history of the car with the given VIN ordered by time. If from or to
+	// are set, only the records in this time range (inclusive) are returned. If the car does not exist, a not found
+	// error is returned. Any other errors are unexpected.
+	ReadPositionRecords(ctx context.Context, vin carTypes.Vin, from *time.Time, to *time.Time) (
+		[]model.PositionRecord, error)
+
+	// ReadTrips returns the completed trips of the car with the given VIN ordered by time. A trip is derived from
+	// the position history between starting and stopping the engine. If the car does not exist, a not found error
+	// is returned. Any other errors are unexpected.
+	ReadTrips(ctx context.Context, vin carTypes.Vin) ([]model.Trip, error)
+
+	// EstimateRange estimates the remaining driving range of the car with the given VIN from its fuel level, its
+	// fuel capacity and the consumption of the given profile. If the car does not exist, a not found error is
+	// returned. If the data of the car does not allow an estimate, a model.RangeUnknownError is returned. Any other
+	// errors are unexpected.
+	EstimateRange(ctx context.Context, vin carTypes.Vin, profile model.RangeProfile) (model.RangeEstimate, error)
+
+	// SubscribeCarEvents subscribes to the changes of the dynamic data of the car with the given VIN. The current
+	// dynamic data is returned as initial event, all later changes are received from the channel. The subscription
+	// ends and the channel is closed when the context is done. If the car does not exist, a not found error is
+	// returned. Any other errors are unexpected.
+	SubscribeCarEvents(ctx context.Context, vin carTypes.Vin) (model.CarEvent, <-chan model.CarEvent, error)
+
+	// SubscribeFleetEvents subscribes to the changes of the dynamic data of all cars. The subscription ends and the
+	// channel is closed when the context is done.
+	SubscribeFleetEvents(ctx context.Context) <-chan model.CarEvent
+
+	// RunIdempotent handles a request with the given idempotency key at most once. If the key is new, execute is
+	// called to handle the request, its response is stored for the key and nil is returned. If execute returns no
+	// response, e.g. because of an unexpected error, the key is released so the request can be retried and the error
+	// of execute is returned. The response is stored and the key is released even if ctx is already done. If the key
+	// was already used for the same request, the stored response is returned to be replayed instead. If the key was
+	// used for a different request or the first request is still handled, an IdempotencyKeyError is returned. Any
+	// other errors are unexpected.
+	RunIdempotent(ctx context.Context, key string, requestHash string,
+		execute func() (*model.IdempotentResponse, error)) (*model.IdempotentResponse, error)
+}
+
+// idempotencyStoreTimeout limits the time for storing the outcome of a request with an idempotency key.
+const idempotencyStoreTimeout = 10 * time.Second
+
+// Config is the configuration of the business logic layer.
+type Config interface {
+	GetVinCheckMode() vin.CheckMode
+}
+
+type operations struct {
+	crud         database.ICRUD
+	broker       *events.Broker
+	vinCheckMode vin.CheckMode
+
+	// now returns the current time, it can be replaced for testing
+	now func() time.Time
+}
+
+// NewOperations creates a new business logic layer instance that uses the given high level CRUD interface.
+// Subscriptions to car events are served by the given broker, which should be fed by the same CRUD interface
+// (see events.NewPublishingCRUD). The check mode for the VINs of new cars is read from the config.
+func NewOperations(crud database.ICRUD, broker *events.Broker, config Config) IOperations {
+	return &operations{
+		crud:         crud,
+		broker:       broker,
+		vinCheckMode: config.GetVinCheckMode(),
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (o *operations) CreateCar(ctx context.Context, car *carTypes.Car) (carTypes.Vin, []string, error) {
+	warnings, err := o.checkNewCar(car)
+	if err != nil {
+		return "", nil, err
+	}
+
+	createdVin, err := o.crud.CreateCar(ctx, car)
+	if err != nil {
+		return "", nil, err
+	}
+	return createdVin, warnings, nil
+}
+
+func (o *operations) CreateCars(ctx context.Context, cars []carTypes.Car) ([]error, [][]string, error) {
+	carErrors := make([]error, len(cars))
+	carWarnings := make([][]string, len(cars))
+
+	// rejected cars are reported right away, the others are created together
+	var validCars []carTypes.Car
+	var validIndices []int
+	for i := range cars {
+		warnings, err := o.checkNewCar(&cars[i])
+		if err != nil {
+			carErrors[i] = err
+			continue
+		}
+		carWarnings[i] = warnings
+		validCars = append(validCars, cars[i])
+		validIndices = append(validIndices, i)
+	}
+
+	validErrors, err := o.crud.CreateCars(ctx, validCars)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, carErr := range validErrors {
+		carErrors[validIndices[i]] = carErr
+	}
+	return carErrors, carWarnings, nil
+}
+
+// checkNewCar checks whether a new car can be created, i.e. whether it is plausible and its VIN passes the
+// configured checks. The warnings about a car that can be created are returned.
+func (o *operations) checkNewCar(car *carTypes.Car) ([]string, error) {
+	if err := validation.ValidateCar(car); err != nil {
+		return nil, err
+	}
+	return o.checkVin(car)
+}
+
+// checkVin checks the check digit of the car's VIN and whether the VIN contradicts the brand or the production date
+// of the car according to the check mode. In warn mode, an invalid VIN is logged and returned as warning.
+func (o *operations) checkVin(car *carTypes.Car) ([]string, error) {
+	if o.vinCheckMode == vin.CheckModeOff {
+		return nil, nil
+	}
+
+	err := vin.Validate(car.Vin)
+	if err == nil {
+		err = vin.CheckConsistency(car.Vin, car.Brand, car.ProductionDate.Year())
+	}
+	if err != nil && o.vinCheckMode == vin.CheckModeWarn {
+		log.Printf("operations: accepting car despite invalid VIN: %s", err.Error())
+		return []string{err.Error()}, nil
+	}
+	return nil, err
+}
+
+func (o *operations) ReadAllVins(ctx context.Context) ([]carTypes.Vin, error) {
+	return o.crud.ReadAllVins(ctx)
+}
+
+func (o *operations) ReadVinsMatching(ctx context.Context, filter model.CarFilter) ([]carTypes.Vin, error) {
+	return o.crud.ReadVinsMatching(ctx, filter)
+}
+
+func (o *operations) ReadVinsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int) (
+	model.VinPage, error) {
+
+	// one additional VIN tells whether there is a next page without a separate count
+	vins, err := o.crud.ReadVinsPage(ctx, filter, after, limit+1)
+	if err != nil {
+		return model.VinPage{}, err
+	}
+
+	if len(vins) <= limit {
+		return model.VinPage{Vins: vins}, nil
+	}
+
+	next := vins[limit-1]
+	return model.VinPage{Vins: vins[:limit], Next: &next}, nil
+}
+
+func (o *operations) ReadVinsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64,
+	filter model.CarFilter) ([]carTypes.Vin, error) {
+
+	return o.crud.ReadVinsNear(ctx, position, radius, filter)
+}
+
+func (o *operations) ReadCarsMatching(ctx context.Context, filter model.CarFilter, fields []string) (
+	[]carTypes.Car, error) {
+
+	return o.crud.ReadCarsMatching(ctx, filter, fields)
+}
+
+func (o *operations) ReadCarsPage(ctx context.Context, filter model.CarFilter, after *carTypes.Vin, limit int,
+	fields []string) (model.CarPage, error) {
+
+	// the VIN is needed for the next page even if it is not among the fields
+	if fields != nil && !containsField(fields, "vin") {
+		fields = append(fields[:len(fields):len(fields)], "vin")
+	}
+
+	// one additional car tells whether there is a next page without a separate count
+	cars, err := o.crud.ReadCarsPage(ctx, filter, after, limit+1, fields)
+	if err != nil {
+		return model.CarPage{}, err
+	}
+
+	if len(cars) <= limit {
+		return model.CarPage{Cars: cars}, nil
+	}
+
+	next := cars[limit-1].Vin
+	return model.CarPage{Cars: cars[:limit], Next: &next}, nil
+}
+
+func (o *operations) ReadCarsNear(ctx context.Context, position carTypes.DynamicDataPosition, radius float64,
+	filter model.CarFilter, fields []string) ([]carTypes.Car, error) {
+
+	return o.crud.ReadCarsNear(ctx, position, radius, filter, fields)
+}
+
+func (o *operations) ForEachCar(ctx context.Context, each func(car *carTypes.Car) error) error {
+	return o.crud.ForEachCar(ctx, each)
+}
+
+func (o *operations) ArchiveCar(ctx context.Context, vin carTypes.Vin, revision *int64) (bool, error) {
+	return o.crud.ArchiveCar(ctx, vin, revision)
+}
+
+func (o *operations) RestoreCar(ctx context.Context, vin carTypes.Vin) (bool, error) {
+	return o.crud.RestoreCar(ctx, vin)
+}
+
+func (o *operations) PurgeCar(ctx context.Context, vin carTypes.Vin) (bool, error) {
+	return o.crud.PurgeCar(ctx, vin)
+}
+
+func (o *operations) ReadCar(ctx context.Context, vin carTypes.Vin) (carTypes.Car, error) {
+	return o.crud.ReadCar(ctx, vin)
+}
+
+func (o *operations) ReadStoredCar(ctx context.Context, vin carTypes.Vin) (model.StoredCar, error) {
+	return o.crud.ReadStoredCar(ctx, vin)
+}
+
+func (o *operations) UpdateStaticData(ctx context.Context, vin carTypes.Vin, revision *int64,
+	update func(car *carTypes.Car) error) (model.CarWithRevision, error) {
+
+	original, currentRevision, err := o.crud.ReadCarWithRevision(ctx, vin)
+	if err != nil {
+		return model.CarWithRevision{}, err
+	}
+
+	updated := original
+	if err := update(&updated); err != nil {
+		return model.CarWithRevision{}, err
+	}
+
+	if updated.Vin != original.Vin {
+		return model.CarWithRevision{}, vinChangeError
+	}
+	if err := validation.ValidateCar(&updated); err != nil {
+		return model.CarWithRevision{}, err
+	}
+	updated.DynamicData = original.DynamicData
+
+	// the update is only written to the checked car, so the returned car and revision are the stored ones
+	if revision != nil && *revision != currentRevision {
+		return model.CarWithRevision{}, &database.RevisionMismatchError{Vin: vin}
+	}
+	changed, err := o.crud.UpdateStaticData(ctx, vin, &original, &updated, &currentRevision)
+	if revision == nil {
+		err = checkedWriteError(err)
+	}
+	if err != nil {
+		return model.CarWithRevision{}, err
+	}
+
+	if changed {
+		currentRevision++
+	}
+	return model.CarWithRevision{Car: updated, Revision: currentRevision}, nil
+}
+
+func (o *operations) SetTrunkLockState(ctx context.Context, vin carTypes.Vin,
+	state carTypes.DynamicDataLockState, revision *int64) error {
+
+	if state != carTypes.UNLOCKED {
+		return o.crud.SetTrunkLockState(ctx, vin, state, revision)
+	}
+
+	car, currentRevision, err := o.crud.ReadCarWithRevision(ctx, vin)
+	if err != nil {
+		return err
+	}
+	if car.DynamicData.EngineState == carTypes.ON {
+		return trunkUnlockWithRunningEngineError
+	}
+
+	// the trunk is only unlocked if the engine has not been started since it was checked
+	if revision != nil {
+		return o.crud.SetTrunkLockState(ctx, vin, state, revision)
+	}
+	return checkedWriteError(o.crud.SetTrunkLockState(ctx, vin, state, &currentRevision))
+}
+
+func (o *operations) SetDoorsLockState(ctx context.Context, vin carTypes.Vin,
+	state carTypes.DynamicDataLockState) error {
+
+	return o.crud.SetDoorsLockState(ctx, vin, state)
+}
+
+func (o *operations) SetEngineState(ctx context.Context, vin carTypes.Vin,
+	state carTypes.DynamicDataEngineState) error {
+
+	car, currentRevision, err := o.crud.ReadCarWithRevision(ctx, vin)
+	if err != nil {
+		return err
+	}
+
+	// stopping the engine is always allowed, so only starting it depends on the state that was checked
+	var revision *int64
+	if state == carTypes.ON {
+		if car.DynamicData.FuelLevelPercentage <= 0 {
+			return emptyTankError
+		}
+		if car.DynamicData.TrunkLockState == carTypes.UNLOCKED {
+			return engineStartWithUnlockedTrunkError
+		}
+		revision = &currentRevision
+	}
+
+	if err := checkedWriteError(o.crud.SetEngineState(ctx, vin, state, revision)); err != nil {
+		return err
+	}
+
+	// starting and stopping the engine is recorded in the position history to derive the trips
+	if car.DynamicData.EngineState != state {
+		o.addPositionRecord(ctx, vin, car.DynamicData.Position, state)
+	}
+	return nil
+}
+
+func (o *operations) SetDynamicData(ctx context.Context, vin carTypes.Vin, dynamicData *carTypes.DynamicData) error {
+	car, err := o.crud.ReadCar(ctx, vin)
+	if err != nil {
+		return err
+	}
+
+	if err := o.crud.SetDynamicData(ctx, vin, dynamicData); err != nil {
+		return err
+	}
+
+	if car.DynamicData.Position != dynamicData.Position || car.DynamicData.EngineState != dynamicData.EngineState {
+		o.addPositionRecord(ctx, vin, dynamicData.Position, dynamicData.EngineState)
+	}
+	return nil
+}
+
+func (o *operations) ReadPositionRecords(ctx context.Context, vin carTypes.Vin, from *time.Time, to *time.Time) (
+	[]model.PositionRecord, error) {
+
+	// the history does not know whether the car exists
+	if _, err := o.crud.ReadCar(ctx, vin); err != nil {
+		return nil, err
+	}
+
+	return o.crud.ReadPositionRecords(ctx, vin, from, to)
+}
+
+func (o *operations) ReadTrips(ctx context.Context, vin carTypes.Vin) ([]model.Trip, error) {
+	records, err := o.ReadPositionRecords(ctx, vin, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveTrips(records), nil
+}
+
+func (o *operations) EstimateRange(ctx context.Context, vin carTypes.Vin, profile model.RangeProfile) (
+	model.RangeEstimate, error) {
+
+	car, err := o.crud.ReadCar(ctx, vin)
+	if err != nil {
+		return model.RangeEstimate{}, err
+	}
+
+	return estimateRange(&car, profile)
+}
+
+func (o *operations) SubscribeCarEvents(ctx context.Context, vin carTypes.Vin) (model.CarEvent,
+	<-chan model.CarEvent, error) {
+
+	// subscribe before reading the car so that no change between both steps is missed
+	carEvents, unsubscribe := o.broker.Subscribe(vin)
+
+	car, err := o.crud.ReadCar(ctx, vin)
+	if err != nil {
+		unsubscribe()
+		return model.CarEvent{}, nil, err
+	}
+
+	unsubscribeWhenDone(ctx, unsubscribe)
+	return model.CarEvent{Vin: vin, DynamicData: car.DynamicData}, carEvents, nil
+}
+
+func (o *operations) SubscribeFleetEvents(ctx context.Context) <-chan model.CarEvent {
+	fleetEvents, unsubscribe := o.broker.Subscribe("")
+	unsubscribeWhenDone(ctx, unsubscribe)
+	return fleetEvents
+}
+
+// unsubscribeWhenDone ends a subscription as soon as the given context is done.
+func unsubscribeWhenDone(ctx context.Context, unsubscribe func()) {
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+}
+
+// addPositionRecord records the given position and engine state of a car at the current time. It is called after
+// the dynamic data has been written, so a failing record is only logged and does not fail the change of the car.
+func (o *operations) addPositionRecord(ctx context.Context, vin carTypes.Vin, position carTypes.DynamicDataPosition,
+	engineState carTypes.DynamicDataEngineState) {
+
+	err := o.crud.AddPositionRecord(ctx, vin, &model.PositionRecord{
+		Timestamp:   o.now(),
+		Position:    position,
+		EngineState: engineState,
+	})
+	if err != nil {
+		log.Printf("operations: recording the position of car %s: %s", vin, err.Error())
+	}
+}
+
+// checkedWriteError returns the error of a write that is conditional on the revision a rule was checked on. If the
+// car has changed in the meantime, the rule might not hold anymore and concurrentChangeError is returned.
+func checkedWriteError(err error) error {
+	if database.IsRevisionMismatchError(err) {
+		return concurrentChangeError
+	}
+	return err
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *operations) RunIdempotent(ctx context.Context, key string, requestHash string,
+	execute func() (*model.IdempotentResponse, error)) (*model.IdempotentResponse, error) {
+
+	// creating the record claims the key, so concurrent repetitions cannot handle the request as well
+	err := o.crud.CreateIdempotencyRecord(ctx, key, requestHash)
+	if database.IsDuplicateKeyError(err) {
+		return o.replay(ctx, key, requestHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := execute()
+
+	// the client may have gone away in the meantime, so the outcome is stored independently of the request
+	storeCtx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+	defer cancel()
+
+	if response == nil {
+		// the lease of the key expires anyway, so a failure to release the key only delays a retry
+		if releaseErr := o.crud.DeleteIdempotencyRecord(storeCtx, key); releaseErr != nil {
+			log.Printf("operations: releasing idempotency key %q: %s", key, releaseErr.Error())
+		}
+		return nil, err
+	}
+	return nil, o.crud.SetIdempotentResponse(storeCtx, key, response)
+}
+
+// replay returns the stored response of the request the given idempotency key was used for, if it is the same
+// request and has already been handled.
+func (o *operations) replay(ctx context.Context, key string, requestHash string) (*model.IdempotentResponse, error) {
+	record, err := o.crud.ReadIdempotencyRecord(ctx, key)
+	if database.IsNotFoundError(err) {
+		// the key has just been released or has expired, the client has to try again
+		return nil, &IdempotencyKeyError{InProgress: true}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, &IdempotencyKeyError{}
+	}
+	if record.Response == nil {
+		return nil, &IdempotencyKeyError{InProgress: true}
+	}
+	return record.Response, nil
+}
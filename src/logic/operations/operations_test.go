@@ -0,0 +1,1469 @@
+package operations
+
+import (
+	"DCar/infrastructure/database"
+	"DCar/logic/events"
+	"DCar/logic/model"
+	"DCar/logic/validation"
+	"DCar/logic/vin"
+	"DCar/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	carTypes "github.com/ccsapp/cargotypes"
+	openapiTypes "github.com/deepmap/oapi-codegen/pkg/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const exampleVin = "12345678901234567"
+
+// dieselSpecification is a plausible technical specification.
+var dieselSpecification = carTypes.TechnicalSpecification{
+	Engine:        carTypes.TechnicalSpecificationEngine{Power: 110},
+	Fuel:          carTypes.DIESEL,
+	FuelCapacity:  "54.0L",
+	NumberOfSeats: 5,
+	Weight:        1320,
+}
+
+// exampleCar is a new car with the example VIN.
+var exampleCar = carTypes.Car{Vin: exampleVin, TechnicalSpecification: dieselSpecification}
+
+func carWithDynamicData(dynamicData carTypes.DynamicData) carTypes.Car {
+	return carTypes.Car{
+		Vin:                    exampleVin,
+		TechnicalSpecification: dieselSpecification,
+		DynamicData:            dynamicData,
+	}
+}
+
+var parkedCar = carWithDynamicData(carTypes.DynamicData{
+	DoorsLockState:      carTypes.LOCKED,
+	EngineState:         carTypes.OFF,
+	FuelLevelPercentage: 50,
+	TrunkLockState:      carTypes.LOCKED,
+})
+
+// checkModeConfig is a Config that only consists of the VIN check mode.
+type checkModeConfig vin.CheckMode
+
+func (c checkModeConfig) GetVinCheckMode() vin.CheckMode {
+	return vin.CheckMode(c)
+}
+
+// hondaCar is a car whose VIN is valid and consistent with the brand and the production date.
+var hondaCar = carTypes.Car{
+	Vin:                    "1HGCM82633A004352",
+	Brand:                  "Honda",
+	ProductionDate:         openapiTypes.Date{Time: time.Date(2003, 1, 1, 0, 0, 0, 0, time.UTC)},
+	TechnicalSpecification: dieselSpecification,
+}
+
+// testConfig does not check the VINs, since the example VIN has no valid check digit.
+var testConfig = checkModeConfig(vin.CheckModeOff)
+
+var exampleTime = time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestOperations creates operations that record all positions at exampleTime.
+func newTestOperations(crud *mocks.MockICRUD) IOperations {
+	return &operations{
+		crud:   crud,
+		broker: events.NewBroker(),
+		now:    func() time.Time { return exampleTime },
+	}
+}
+
+func TestOperations_CreateCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := exampleCar
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCar(ctx, &car).Return(exampleVin, nil)
+
+	vin, warnings, err := NewOperations(mockCrud, events.NewBroker(), testConfig).CreateCar(ctx, &car)
+
+	assert.Nil(t, err)
+	assert.Equal(t, exampleVin, vin)
+	assert.Nil(t, warnings)
+}
+
+func TestOperations_CreateCars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cars := []carTypes.Car{exampleCar}
+	carErrors := []error{nil}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCars(ctx, cars).Return(carErrors, nil)
+
+	result, warnings, err := NewOperations(mockCrud, events.NewBroker(), testConfig).CreateCars(ctx, cars)
+
+	assert.Nil(t, err)
+	assert.Equal(t, carErrors, result)
+	assert.Equal(t, [][]string{nil}, warnings)
+}
+
+func TestOperations_CreateCar_checkDigitStrict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	car := exampleCar
+
+	_, _, err := NewOperations(mocks.NewMockICRUD(ctrl), events.NewBroker(), checkModeConfig(vin.CheckModeStrict)).
+		CreateCar(context.Background(), &car)
+
+	assert.True(t, vin.IsCheckDigitError(err))
+}
+
+func TestOperations_CreateCar_strictValid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := hondaCar
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCar(ctx, &car).Return(car.Vin, nil)
+
+	result, warnings, err := NewOperations(mockCrud, events.NewBroker(), checkModeConfig(vin.CheckModeStrict)).
+		CreateCar(ctx, &car)
+
+	assert.Nil(t, err)
+	assert.Equal(t, car.Vin, result)
+	assert.Nil(t, warnings)
+}
+
+func TestOperations_CreateCar_inconsistentStrict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	car := hondaCar
+	car.ProductionDate = openapiTypes.Date{Time: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	_, _, err := NewOperations(mocks.NewMockICRUD(ctrl), events.NewBroker(), checkModeConfig(vin.CheckModeStrict)).
+		CreateCar(context.Background(), &car)
+
+	assert.True(t, vin.IsInconsistencyError(err))
+}
+
+func TestOperations_CreateCar_checkDigitWarn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := exampleCar
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCar(ctx, &car).Return(exampleVin, nil)
+
+	result, warnings, err := NewOperations(mockCrud, events.NewBroker(), checkModeConfig(vin.CheckModeWarn)).
+		CreateCar(ctx, &car)
+
+	assert.Nil(t, err)
+	assert.Equal(t, exampleVin, result)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, vin.Validate(car.Vin).Error(), warnings[0])
+}
+
+func TestOperations_CreateCars_checkDigitWarn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cars := []carTypes.Car{hondaCar, exampleCar}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCars(ctx, cars).Return([]error{nil, nil}, nil)
+
+	result, warnings, err := NewOperations(mockCrud, events.NewBroker(), checkModeConfig(vin.CheckModeWarn)).
+		CreateCars(ctx, cars)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil, nil}, result)
+	assert.Equal(t, [][]string{nil, {vin.Validate(exampleCar.Vin).Error()}}, warnings)
+}
+
+func TestOperations_CreateCars_checkDigitStrict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	inconsistentCar := hondaCar
+	inconsistentCar.Brand = "Audi"
+
+	volkswagenCar := carTypes.Car{Vin: "WVWAA71K0UW201030", Brand: "Volkswagen",
+		TechnicalSpecification: dieselSpecification}
+
+	validCars := []carTypes.Car{hondaCar, volkswagenCar}
+	cars := []carTypes.Car{validCars[0], exampleCar, validCars[1], inconsistentCar}
+	duplicateError := errors.New("duplicate")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCars(ctx, validCars).Return([]error{nil, duplicateError}, nil)
+
+	result, _, err := NewOperations(mockCrud, events.NewBroker(), checkModeConfig(vin.CheckModeStrict)).
+		CreateCars(ctx, cars)
+
+	assert.Nil(t, err)
+	assert.Len(t, result, 4)
+	assert.Nil(t, result[0])
+	assert.True(t, vin.IsCheckDigitError(result[1]))
+	assert.Equal(t, duplicateError, result[2])
+	assert.True(t, vin.IsInconsistencyError(result[3]))
+}
+
+func TestOperations_CreateCar_implausible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	car := exampleCar
+	car.TechnicalSpecification.Fuel = carTypes.ELECTRIC
+
+	_, _, err := NewOperations(mocks.NewMockICRUD(ctrl), events.NewBroker(), testConfig).
+		CreateCar(context.Background(), &car)
+
+	assert.True(t, validation.IsError(err))
+}
+
+func TestOperations_CreateCars_implausible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	hybridCar := exampleCar
+	hybridCar.Vin = "12345678901234568"
+	hybridCar.TechnicalSpecification.Fuel = carTypes.HYBRIDPETROL
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateCars(ctx, []carTypes.Car{exampleCar}).Return([]error{nil}, nil)
+
+	result, _, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		CreateCars(ctx, []carTypes.Car{hybridCar, exampleCar})
+
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.True(t, validation.IsError(result[0]))
+	assert.Nil(t, result[1])
+}
+
+func TestOperations_ReadAllVins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vins := []carTypes.Vin{exampleVin}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadAllVins(ctx).Return(vins, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadAllVins(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, vins, result)
+}
+
+func TestOperations_ReadVinsMatching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	minSeats := 7
+	filter := model.CarFilter{MinSeats: &minSeats}
+	vins := []carTypes.Vin{exampleVin}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadVinsMatching(ctx, filter).Return(vins, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadVinsMatching(ctx, filter)
+
+	assert.Nil(t, err)
+	assert.Equal(t, vins, result)
+}
+
+func TestOperations_ReadVinsPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	after := "JH4DA1840KS004941"
+	vins := []carTypes.Vin{"JH4DA1840KS004942", "JH4DA1840KS004943", "JH4DA1840KS004944"}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadVinsPage(ctx, model.CarFilter{}, &after, 3).Return(vins, nil)
+
+	page, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadVinsPage(ctx, model.CarFilter{}, &after, 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, vins[:2], page.Vins)
+	assert.Equal(t, &vins[1], page.Next)
+}
+
+func TestOperations_ReadVinsPage_lastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	vins := []carTypes.Vin{"JH4DA1840KS004942", "JH4DA1840KS004943"}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadVinsPage(ctx, model.CarFilter{}, nil, 3).Return(vins, nil)
+
+	page, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadVinsPage(ctx, model.CarFilter{}, nil, 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.VinPage{Vins: vins}, page)
+}
+
+func TestOperations_ReadVinsPage_crudError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	crudError := errors.New("crud error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadVinsPage(ctx, model.CarFilter{}, nil, 3).Return(nil, crudError)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadVinsPage(ctx, model.CarFilter{}, nil, 2)
+
+	assert.ErrorIs(t, err, crudError)
+}
+
+func TestOperations_ReadCarsMatching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cars := []carTypes.Car{parkedCar}
+	fields := []string{"vin", "brand"}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsMatching(ctx, model.CarFilter{}, fields).Return(cars, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadCarsMatching(ctx, model.CarFilter{}, fields)
+
+	assert.Nil(t, err)
+	assert.Equal(t, cars, result)
+}
+
+func TestOperations_ReadCarsPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cars := []carTypes.Car{{Vin: "JH4DA1840KS004942"}, {Vin: "JH4DA1840KS004943"}, {Vin: "JH4DA1840KS004944"}}
+
+	// the VIN is read for the next page even if it is not requested
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsPage(ctx, model.CarFilter{}, nil, 3, []string{"brand", "vin"}).Return(cars, nil)
+
+	page, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		ReadCarsPage(ctx, model.CarFilter{}, nil, 2, []string{"brand"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, cars[:2], page.Cars)
+	assert.Equal(t, &cars[1].Vin, page.Next)
+}
+
+func TestOperations_ReadCarsPage_lastPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	cars := []carTypes.Car{parkedCar}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsPage(ctx, model.CarFilter{}, nil, 3, nil).Return(cars, nil)
+
+	page, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadCarsPage(ctx, model.CarFilter{}, nil, 2, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.CarPage{Cars: cars}, page)
+}
+
+func TestOperations_ReadCarsPage_crudError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	crudError := errors.New("crud error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsPage(ctx, model.CarFilter{}, nil, 3, nil).Return(nil, crudError)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadCarsPage(ctx, model.CarFilter{}, nil, 2, nil)
+
+	assert.ErrorIs(t, err, crudError)
+}
+
+func TestOperations_ReadCarsNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	position := carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+	cars := []carTypes.Car{parkedCar}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsNear(ctx, position, 1500.0, model.CarFilter{}, nil).Return(cars, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		ReadCarsNear(ctx, position, 1500, model.CarFilter{}, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, cars, result)
+}
+
+func TestOperations_ReadVinsNear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	position := carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+	vins := []carTypes.Vin{exampleVin}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadVinsNear(ctx, position, 1500.0, model.CarFilter{}).Return(vins, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		ReadVinsNear(ctx, position, 1500, model.CarFilter{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, vins, result)
+}
+
+func TestOperations_ForEachCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.
+		EXPECT().
+		ForEachCar(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, each func(car *carTypes.Car) error) error {
+			return each(&parkedCar)
+		})
+
+	var cars []carTypes.Car
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).ForEachCar(ctx, func(car *carTypes.Car) error {
+		cars = append(cars, *car)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []carTypes.Car{parkedCar}, cars)
+}
+
+func TestOperations_ArchiveCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ArchiveCar(ctx, exampleVin, nil).Return(true, nil)
+
+	archived, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ArchiveCar(ctx, exampleVin, nil)
+
+	assert.Nil(t, err)
+	assert.True(t, archived)
+}
+
+func TestOperations_RestoreCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().RestoreCar(ctx, exampleVin).Return(true, nil)
+
+	restored, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RestoreCar(ctx, exampleVin)
+
+	assert.Nil(t, err)
+	assert.True(t, restored)
+}
+
+func TestOperations_PurgeCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().PurgeCar(ctx, exampleVin).Return(false, nil)
+
+	purged, err := NewOperations(mockCrud, events.NewBroker(), testConfig).PurgeCar(ctx, exampleVin)
+
+	assert.Nil(t, err)
+	assert.False(t, purged)
+}
+
+func TestOperations_ReadCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(parkedCar, nil)
+
+	car, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadCar(ctx, exampleVin)
+
+	assert.Nil(t, err)
+	assert.Equal(t, parkedCar, car)
+}
+
+func TestOperations_ReadStoredCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	storedCar := model.StoredCar{CarWithRevision: model.CarWithRevision{Car: parkedCar, Revision: 7}}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadStoredCar(ctx, exampleVin).Return(storedCar, nil)
+
+	car, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadStoredCar(ctx, exampleVin)
+
+	assert.Nil(t, err)
+	assert.Equal(t, storedCar, car)
+}
+
+func TestOperations_UpdateStaticData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	updated := parkedCar
+	updated.Brand = "Audi"
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+	mockCrud.EXPECT().UpdateStaticData(ctx, exampleVin, &parkedCar, &updated, &checkedRevision).Return(true, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			car.Brand = "Audi"
+			// changes of the dynamic data are ignored
+			car.DynamicData.EngineState = carTypes.ON
+			return nil
+		})
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.CarWithRevision{Car: updated, Revision: checkedRevision + 1}, result)
+}
+
+func TestOperations_UpdateStaticData_unchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+	mockCrud.EXPECT().UpdateStaticData(ctx, exampleVin, &parkedCar, &parkedCar, &checkedRevision).Return(false, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			return nil
+		})
+
+	// nothing was written, so the revision stays the same
+	assert.Nil(t, err)
+	assert.Equal(t, model.CarWithRevision{Car: parkedCar, Revision: checkedRevision}, result)
+}
+
+func TestOperations_UpdateStaticData_changedVin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			car.Vin = "12345678901234568"
+			return nil
+		})
+
+	assert.ErrorIs(t, err, vinChangeError)
+	assert.True(t, IsRuleViolationError(err))
+}
+
+func TestOperations_UpdateStaticData_implausible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			car.TechnicalSpecification.NumberOfSeats = 0
+			return nil
+		})
+
+	assert.True(t, validation.IsError(err))
+}
+
+func TestOperations_UpdateStaticData_updateError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	updateError := errors.New("update error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			return updateError
+		})
+
+	assert.ErrorIs(t, err, updateError)
+}
+
+func TestOperations_UpdateStaticData_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(carTypes.Car{}, int64(0), mongo.ErrNoDocuments)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			t.Fatal("the update function must not be called")
+			return nil
+		})
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestOperations_UpdateStaticData_crudError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	crudError := errors.New("crud error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+	mockCrud.EXPECT().UpdateStaticData(ctx, exampleVin, gomock.Any(), gomock.Any(), &checkedRevision).
+		Return(false, crudError)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			car.Model = "Passat"
+			return nil
+		})
+
+	assert.ErrorIs(t, err, crudError)
+}
+
+func TestOperations_UpdateStaticData_revision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := checkedRevision
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+	mockCrud.EXPECT().UpdateStaticData(ctx, exampleVin, gomock.Any(), gomock.Any(), &checkedRevision).
+		Return(true, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, &revision, func(car *carTypes.Car) error {
+			car.Model = "Passat"
+			return nil
+		})
+
+	assert.Nil(t, err)
+	assert.Equal(t, checkedRevision+1, result.Revision)
+}
+
+func TestOperations_UpdateStaticData_revisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	// the car has been changed since the expected revision, so nothing is written
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, &revision, func(car *carTypes.Car) error {
+			car.Model = "Passat"
+			return nil
+		})
+
+	assert.True(t, database.IsRevisionMismatchError(err))
+}
+
+func TestOperations_UpdateStaticData_concurrentChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the car was changed after it was checked
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, checkedRevision, nil)
+	mockCrud.EXPECT().UpdateStaticData(ctx, exampleVin, gomock.Any(), gomock.Any(), &checkedRevision).
+		Return(false, &database.RevisionMismatchError{Vin: exampleVin})
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		UpdateStaticData(ctx, exampleVin, nil, func(car *carTypes.Car) error {
+			car.Model = "Passat"
+			return nil
+		})
+
+	assert.ErrorIs(t, err, concurrentChangeError)
+}
+
+// checkedRevision is the revision of the car the domain rules are checked on in the tests of the state changes.
+var checkedRevision = int64(5)
+
+func TestOperations_SetTrunkLockState_lock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// locking the trunk is always allowed, so the car is not read
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil).Return(nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).SetTrunkLockState(ctx, exampleVin, carTypes.LOCKED, nil)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetTrunkLockState_unlockEngineOff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	// the trunk is only unlocked if the car still has the revision that was checked
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, &checkedRevision).Return(nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, nil)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetTrunkLockState_unlockEngineOn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	runningCar := parkedCar
+	runningCar.DynamicData.EngineState = carTypes.ON
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(runningCar, int64(5), nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, nil)
+
+	assert.True(t, IsRuleViolationError(err))
+	assert.Equal(t, trunkUnlockWithRunningEngineError, err)
+}
+
+func TestOperations_SetTrunkLockState_unlockCarNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(carTypes.Car{}, int64(0), mongo.ErrNoDocuments)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, nil)
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestOperations_SetTrunkLockState_revision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(3), nil)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, &revision).Return(nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, &revision)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetTrunkLockState_unlockConcurrentChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the engine is started after the trunk lock state is checked
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, &checkedRevision).
+		Return(&database.RevisionMismatchError{Vin: exampleVin})
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, nil)
+
+	assert.Equal(t, concurrentChangeError, err)
+}
+
+func TestOperations_SetTrunkLockState_revisionMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	revision := int64(3)
+
+	// a mismatch of the revision given by the client is no rule violation
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, &revision).
+		Return(&database.RevisionMismatchError{Vin: exampleVin})
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		SetTrunkLockState(ctx, exampleVin, carTypes.UNLOCKED, &revision)
+
+	assert.True(t, database.IsRevisionMismatchError(err))
+	assert.False(t, IsRuleViolationError(err))
+}
+
+func TestOperations_SetDoorsLockState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().SetDoorsLockState(ctx, exampleVin, carTypes.UNLOCKED).Return(nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).SetDoorsLockState(ctx, exampleVin, carTypes.UNLOCKED)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetEngineState_stop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	runningCar := parkedCar
+	runningCar.DynamicData.EngineState = carTypes.ON
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(runningCar, int64(5), nil)
+	mockCrud.EXPECT().SetEngineState(ctx, exampleVin, carTypes.OFF, nil).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, exampleVin, &model.PositionRecord{
+		Timestamp:   exampleTime,
+		Position:    runningCar.DynamicData.Position,
+		EngineState: carTypes.OFF,
+	}).Return(nil)
+
+	err := newTestOperations(mockCrud).SetEngineState(ctx, exampleVin, carTypes.OFF)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetEngineState_stopStoppedEngine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the state does not change, so nothing is recorded
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetEngineState(ctx, exampleVin, carTypes.OFF, nil).Return(nil)
+
+	err := newTestOperations(mockCrud).SetEngineState(ctx, exampleVin, carTypes.OFF)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetEngineState_start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetEngineState(ctx, exampleVin, carTypes.ON, &checkedRevision).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, exampleVin, &model.PositionRecord{
+		Timestamp:   exampleTime,
+		Position:    parkedCar.DynamicData.Position,
+		EngineState: carTypes.ON,
+	}).Return(nil)
+
+	err := newTestOperations(mockCrud).SetEngineState(ctx, exampleVin, carTypes.ON)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetEngineState_startConcurrentChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the trunk is unlocked after the engine state is checked, so the engine is not started
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetEngineState(ctx, exampleVin, carTypes.ON, &checkedRevision).
+		Return(&database.RevisionMismatchError{Vin: exampleVin})
+
+	err := newTestOperations(mockCrud).SetEngineState(ctx, exampleVin, carTypes.ON)
+
+	assert.Equal(t, concurrentChangeError, err)
+}
+
+func TestOperations_SetEngineState_startEmptyTank(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	emptyCar := parkedCar
+	emptyCar.DynamicData.FuelLevelPercentage = 0
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(emptyCar, int64(5), nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).SetEngineState(ctx, exampleVin, carTypes.ON)
+
+	assert.True(t, IsRuleViolationError(err))
+	assert.Equal(t, emptyTankError, err)
+}
+
+func TestOperations_SetEngineState_startUnlockedTrunk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	openCar := parkedCar
+	openCar.DynamicData.TrunkLockState = carTypes.UNLOCKED
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(openCar, int64(5), nil)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).SetEngineState(ctx, exampleVin, carTypes.ON)
+
+	assert.True(t, IsRuleViolationError(err))
+	assert.Equal(t, engineStartWithUnlockedTrunkError, err)
+}
+
+func TestOperations_SetEngineState_crudError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	crudError := errors.New("crud error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(carTypes.Car{}, int64(0), crudError)
+
+	err := NewOperations(mockCrud, events.NewBroker(), testConfig).SetEngineState(ctx, exampleVin, carTypes.ON)
+
+	assert.ErrorIs(t, err, crudError)
+	assert.False(t, IsRuleViolationError(err))
+}
+
+func TestOperations_SetEngineState_recordError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the engine state is already written, so a failing record does not fail the request
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarWithRevision(ctx, exampleVin).Return(parkedCar, int64(5), nil)
+	mockCrud.EXPECT().SetEngineState(ctx, exampleVin, carTypes.ON, &checkedRevision).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, exampleVin, gomock.Any()).Return(errors.New("crud error"))
+
+	err := newTestOperations(mockCrud).SetEngineState(ctx, exampleVin, carTypes.ON)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetDynamicData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the reported data is stored even though it violates the rules of the commands
+	reportedData := carTypes.DynamicData{
+		DoorsLockState:      carTypes.UNLOCKED,
+		EngineState:         carTypes.ON,
+		FuelLevelPercentage: 0,
+		TrunkLockState:      carTypes.UNLOCKED,
+	}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(parkedCar, nil)
+	mockCrud.EXPECT().SetDynamicData(ctx, exampleVin, &reportedData).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, exampleVin, &model.PositionRecord{
+		Timestamp:   exampleTime,
+		Position:    reportedData.Position,
+		EngineState: carTypes.ON,
+	}).Return(nil)
+
+	err := newTestOperations(mockCrud).SetDynamicData(ctx, exampleVin, &reportedData)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetDynamicData_unchangedPosition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// only the fuel level changes, so nothing is recorded
+	reportedData := parkedCar.DynamicData
+	reportedData.FuelLevelPercentage = 40
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(parkedCar, nil)
+	mockCrud.EXPECT().SetDynamicData(ctx, exampleVin, &reportedData).Return(nil)
+
+	err := newTestOperations(mockCrud).SetDynamicData(ctx, exampleVin, &reportedData)
+
+	assert.Nil(t, err)
+}
+
+func TestOperations_SetDynamicData_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	reportedData := parkedCar.DynamicData
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(carTypes.Car{}, mongo.ErrNoDocuments)
+
+	err := newTestOperations(mockCrud).SetDynamicData(ctx, exampleVin, &reportedData)
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestOperations_ReadPositionRecords(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	from := exampleTime.Add(-time.Hour)
+	records := []model.PositionRecord{{Timestamp: exampleTime, EngineState: carTypes.ON}}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(parkedCar, nil)
+	mockCrud.EXPECT().ReadPositionRecords(ctx, exampleVin, &from, nil).Return(records, nil)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadPositionRecords(ctx, exampleVin, &from, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, records, result)
+}
+
+func TestOperations_ReadPositionRecords_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(carTypes.Car{}, mongo.ErrNoDocuments)
+
+	result, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadPositionRecords(ctx, exampleVin, nil, nil)
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.Nil(t, result)
+}
+
+func TestOperations_ReadTrips(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	start := carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+	end := carTypes.DynamicDataPosition{Latitude: 49.0169, Longitude: 8.4037}
+
+	records := []model.PositionRecord{
+		{Timestamp: exampleTime, Position: start, EngineState: carTypes.ON},
+		{Timestamp: exampleTime.Add(10 * time.Minute), Position: end, EngineState: carTypes.OFF},
+	}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(parkedCar, nil)
+	mockCrud.EXPECT().ReadPositionRecords(ctx, exampleVin, nil, nil).Return(records, nil)
+
+	trips, err := NewOperations(mockCrud, events.NewBroker(), testConfig).ReadTrips(ctx, exampleVin)
+
+	assert.Nil(t, err)
+	assert.Len(t, trips, 1)
+	assert.Equal(t, 600.0, trips[0].Duration)
+}
+
+func TestOperations_EstimateRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// the parked car has a consumption of 0, so a consumption is needed for the estimate
+	car := parkedCar
+	car.TechnicalSpecification.Consumption.Combined = 5
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(car, nil)
+
+	estimate, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		EstimateRange(ctx, exampleVin, model.RangeProfileCombined)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 540.0, estimate.Total)
+}
+
+func TestOperations_EstimateRange_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(carTypes.Car{}, mongo.ErrNoDocuments)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).
+		EstimateRange(ctx, exampleVin, model.RangeProfileCombined)
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestOperations_SubscribeCarEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	broker := events.NewBroker()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(parkedCar, nil)
+
+	current, carEvents, err := NewOperations(mockCrud, broker, testConfig).SubscribeCarEvents(ctx, exampleVin)
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.CarEvent{Vin: exampleVin, DynamicData: parkedCar.DynamicData}, current)
+
+	changedEvent := model.CarEvent{Vin: exampleVin, DynamicData: carTypes.DynamicData{EngineState: carTypes.ON}}
+	broker.Publish(model.CarEvent{Vin: "12345678901234568"})
+	broker.Publish(changedEvent)
+	assert.Equal(t, changedEvent, <-carEvents)
+
+	// the channel is closed once the context is done
+	cancel()
+	for range carEvents {
+	}
+	assert.False(t, broker.HasSubscribers(exampleVin))
+}
+
+func TestOperations_SubscribeCarEvents_notFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	broker := events.NewBroker()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCar(ctx, exampleVin).Return(carTypes.Car{}, mongo.ErrNoDocuments)
+
+	_, carEvents, err := NewOperations(mockCrud, broker, testConfig).SubscribeCarEvents(ctx, exampleVin)
+
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.Nil(t, carEvents)
+	assert.False(t, broker.HasSubscribers(exampleVin))
+}
+
+func TestOperations_SubscribeFleetEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	broker := events.NewBroker()
+
+	fleetEvents := NewOperations(mocks.NewMockICRUD(ctrl), broker, testConfig).SubscribeFleetEvents(ctx)
+
+	event := model.CarEvent{Vin: "12345678901234568"}
+	broker.Publish(event)
+	assert.Equal(t, event, <-fleetEvents)
+
+	cancel()
+	for range fleetEvents {
+	}
+	assert.False(t, broker.HasSubscribers(exampleVin))
+}
+
+var duplicateKeyError = mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+// liveContext matches a context that is not done.
+type liveContext struct{}
+
+func (liveContext) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && ctx.Err() == nil
+}
+
+func (liveContext) String() string {
+	return "is a context that is not done"
+}
+
+func TestOperations_RunIdempotent_newKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	response := &model.IdempotentResponse{StatusCode: 204}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	gomock.InOrder(
+		mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(nil),
+		mockCrud.EXPECT().SetIdempotentResponse(liveContext{}, "key", response).Return(nil),
+	)
+
+	executed := false
+	replay, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash",
+		func() (*model.IdempotentResponse, error) {
+			executed = true
+			return response, nil
+		})
+
+	assert.Nil(t, err)
+	assert.Nil(t, replay)
+	assert.True(t, executed)
+}
+
+func TestOperations_RunIdempotent_executeError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	executeError := errors.New("execute error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	gomock.InOrder(
+		mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(nil),
+		// the key is released even if this fails
+		mockCrud.EXPECT().DeleteIdempotencyRecord(liveContext{}, "key").Return(errors.New("db error")),
+	)
+
+	replay, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash",
+		func() (*model.IdempotentResponse, error) {
+			return nil, executeError
+		})
+
+	assert.ErrorIs(t, err, executeError)
+	assert.Nil(t, replay)
+}
+
+func TestOperations_RunIdempotent_canceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	retryCtx := context.Background()
+
+	response := &model.IdempotentResponse{StatusCode: 204}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	gomock.InOrder(
+		mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(nil),
+		// the key is released although the client has gone away
+		mockCrud.EXPECT().DeleteIdempotencyRecord(liveContext{}, "key").Return(nil),
+		mockCrud.EXPECT().CreateIdempotencyRecord(retryCtx, "key", "hash").Return(nil),
+		mockCrud.EXPECT().SetIdempotentResponse(liveContext{}, "key", response).Return(nil),
+	)
+
+	operations := NewOperations(mockCrud, events.NewBroker(), testConfig)
+	_, err := operations.RunIdempotent(ctx, "key", "hash", func() (*model.IdempotentResponse, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the retry is not locked out
+	replay, err := operations.RunIdempotent(retryCtx, "key", "hash", func() (*model.IdempotentResponse, error) {
+		return response, nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, replay)
+}
+
+func TestOperations_RunIdempotent_canceledAfterResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	response := &model.IdempotentResponse{StatusCode: 204}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	gomock.InOrder(
+		mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(nil),
+		mockCrud.EXPECT().SetIdempotentResponse(liveContext{}, "key", response).Return(nil),
+	)
+
+	// the client disconnects after the response has been written
+	replay, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash",
+		func() (*model.IdempotentResponse, error) {
+			cancel()
+			return response, nil
+		})
+
+	assert.Nil(t, err)
+	assert.Nil(t, replay)
+}
+
+func TestOperations_RunIdempotent_replay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	response := &model.IdempotentResponse{StatusCode: 201, Body: []byte(`"12345678901234567"`)}
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(duplicateKeyError)
+	mockCrud.EXPECT().ReadIdempotencyRecord(ctx, "key").
+		Return(model.IdempotencyRecord{RequestHash: "hash", Response: response}, nil)
+
+	replay, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash",
+		func() (*model.IdempotentResponse, error) {
+			t.Fatal("the request must not be handled again")
+			return nil, nil
+		})
+
+	assert.Nil(t, err)
+	assert.Equal(t, response, replay)
+}
+
+func TestOperations_RunIdempotent_differentRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(duplicateKeyError)
+	mockCrud.EXPECT().ReadIdempotencyRecord(ctx, "key").
+		Return(model.IdempotencyRecord{RequestHash: "other", Response: &model.IdempotentResponse{}}, nil)
+
+	replay, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash", nil)
+
+	assert.Equal(t, &IdempotencyKeyError{}, err)
+	assert.Nil(t, replay)
+}
+
+func TestOperations_RunIdempotent_inProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(duplicateKeyError)
+	mockCrud.EXPECT().ReadIdempotencyRecord(ctx, "key").Return(model.IdempotencyRecord{RequestHash: "hash"}, nil)
+
+	replay, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash", nil)
+
+	assert.Equal(t, &IdempotencyKeyError{InProgress: true}, err)
+	assert.Nil(t, replay)
+}
+
+func TestOperations_RunIdempotent_released(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().CreateIdempotencyRecord(ctx, "key", "hash").Return(duplicateKeyError)
+	mockCrud.EXPECT().ReadIdempotencyRecord(ctx, "key").Return(model.IdempotencyRecord{}, mongo.ErrNoDocuments)
+
+	_, err := NewOperations(mockCrud, events.NewBroker(), testConfig).RunIdempotent(ctx, "key", "hash", nil)
+
+	assert.Equal(t, &IdempotencyKeyError{InProgress: true}, err)
+}
@@ -0,0 +1,73 @@
+package operations
+
+import (
+	"DCar/logic/model"
+	carTypes "github.com/ccsapp/cargotypes"
+	"math"
+)
+
+var (
+	unknownConsumptionError = &model.RangeUnknownError{
+		Reason: "The range cannot be estimated because the consumption of the profile is not positive."}
+
+	unknownCapacityError = &model.RangeUnknownError{
+		Reason: "The range cannot be estimated because the fuel capacity does not match the fuel."}
+)
+
+// estimateRange estimates the remaining range of the car from its fuel level, its fuel capacity and the consumption
+// of the given profile. The consumption is given per 100 km in the unit of the capacity it applies to, so the
+// consumption of a hybrid car is applied to its battery in kWh and to its tank in liters. The fuel level applies to
+// all energy sources of the car. The ranges are rounded to 100 m.
+func estimateRange(car *carTypes.Car, profile model.RangeProfile) (model.RangeEstimate, error) {
+	consumption := profileConsumption(&car.TechnicalSpecification.Consumption, profile)
+	if consumption <= 0 {
+		return model.RangeEstimate{}, unknownConsumptionError
+	}
+
+	capacity, err := model.ParseCarFuelCapacity(car)
+	if err != nil {
+		return model.RangeEstimate{}, unknownCapacityError
+	}
+
+	level := float64(car.DynamicData.FuelLevelPercentage) / 100
+	rangeOf := func(capacity float64) *float64 {
+		distance := roundRange(capacity * level / consumption * 100)
+		return &distance
+	}
+
+	estimate := model.RangeEstimate{Profile: profile}
+	switch car.TechnicalSpecification.Fuel {
+	case carTypes.ELECTRIC:
+		estimate.Electric = rangeOf(*capacity.KiloWattHours)
+	case carTypes.HYBRIDDIESEL, carTypes.HYBRIDPETROL:
+		estimate.Electric = rangeOf(*capacity.KiloWattHours)
+		estimate.Combustion = rangeOf(*capacity.Liters)
+	default:
+		estimate.Combustion = rangeOf(*capacity.Liters)
+	}
+
+	if estimate.Electric != nil {
+		estimate.Total += *estimate.Electric
+	}
+	if estimate.Combustion != nil {
+		estimate.Total += *estimate.Combustion
+	}
+	estimate.Total = roundRange(estimate.Total)
+	return estimate, nil
+}
+
+// profileConsumption returns the consumption of the given profile, unknown profiles use the combined consumption.
+func profileConsumption(consumption *carTypes.TechnicalSpecificationConsumption, profile model.RangeProfile) float64 {
+	switch profile {
+	case model.RangeProfileCity:
+		return float64(consumption.City)
+	case model.RangeProfileOverland:
+		return float64(consumption.Overland)
+	}
+	return float64(consumption.Combined)
+}
+
+// roundRange rounds a range in km to one decimal place.
+func roundRange(distance float64) float64 {
+	return math.Round(distance*10) / 10
+}
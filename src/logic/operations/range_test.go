@@ -0,0 +1,125 @@
+package operations
+
+import (
+	"DCar/logic/model"
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// carWithRange returns a car with the given fuel, fuel capacity and fuel level, whose consumption is 8 in the city,
+// 4 overland and 5 combined.
+func carWithRange(fuel carTypes.TechnicalSpecificationFuel, fuelCapacity string, fuelLevelPercentage int) carTypes.Car {
+	return carTypes.Car{
+		TechnicalSpecification: carTypes.TechnicalSpecification{
+			Consumption:  carTypes.TechnicalSpecificationConsumption{City: 8, Combined: 5, Overland: 4},
+			Fuel:         fuel,
+			FuelCapacity: fuelCapacity,
+		},
+		DynamicData: carTypes.DynamicData{FuelLevelPercentage: fuelLevelPercentage},
+	}
+}
+
+func distance(km float64) *float64 {
+	return &km
+}
+
+func TestEstimateRange_combustion(t *testing.T) {
+	car := carWithRange(carTypes.DIESEL, "54.0L", 50)
+
+	for _, test := range []struct {
+		profile  model.RangeProfile
+		expected float64
+	}{
+		{model.RangeProfileCity, 337.5},
+		{model.RangeProfileOverland, 675},
+		{model.RangeProfileCombined, 540},
+	} {
+		estimate, err := estimateRange(&car, test.profile)
+
+		assert.Nil(t, err)
+		assert.Equal(t, model.RangeEstimate{
+			Profile:    test.profile,
+			Total:      test.expected,
+			Combustion: distance(test.expected),
+		}, estimate)
+	}
+}
+
+func TestEstimateRange_electric(t *testing.T) {
+	car := carWithRange(carTypes.ELECTRIC, "80.0kWh", 25)
+
+	estimate, err := estimateRange(&car, model.RangeProfileCombined)
+
+	assert.Nil(t, err)
+	assert.Equal(t, model.RangeEstimate{
+		Profile:  model.RangeProfileCombined,
+		Total:    400,
+		Electric: distance(400),
+	}, estimate)
+}
+
+func TestEstimateRange_hybrid(t *testing.T) {
+	// the consumption applies to the battery in kWh and to the tank in liters, the total range is their sum
+	for _, test := range []struct {
+		profile             model.RangeProfile
+		fuelLevelPercentage int
+		electric            float64
+		combustion          float64
+	}{
+		{model.RangeProfileCity, 100, 125, 500},    // 10 kWh and 40 l / 8 per 100 km
+		{model.RangeProfileOverland, 50, 125, 500}, // 5 kWh and 20 l / 4 per 100 km
+		{model.RangeProfileCombined, 30, 60, 240},  // 3 kWh and 12 l / 5 per 100 km
+	} {
+		car := carWithRange(carTypes.HYBRIDPETROL, "40.0L;10.0kWh", test.fuelLevelPercentage)
+
+		estimate, err := estimateRange(&car, test.profile)
+
+		assert.Nil(t, err)
+		assert.Equal(t, model.RangeEstimate{
+			Profile:    test.profile,
+			Total:      test.electric + test.combustion,
+			Electric:   distance(test.electric),
+			Combustion: distance(test.combustion),
+		}, estimate)
+	}
+}
+
+func TestEstimateRange_rounded(t *testing.T) {
+	car := carWithRange(carTypes.PETROL, "54.0L", 33)
+	car.TechnicalSpecification.Consumption.Combined = 6.4
+
+	estimate, err := estimateRange(&car, model.RangeProfileCombined)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 278.4, estimate.Total)
+	assert.Equal(t, distance(278.4), estimate.Combustion)
+}
+
+func TestEstimateRange_emptyTank(t *testing.T) {
+	car := carWithRange(carTypes.PETROL, "54.0L", 0)
+
+	estimate, err := estimateRange(&car, model.RangeProfileCombined)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, estimate.Total)
+}
+
+func TestEstimateRange_unknownConsumption(t *testing.T) {
+	car := carWithRange(carTypes.PETROL, "54.0L", 50)
+	car.TechnicalSpecification.Consumption.Overland = 0
+
+	_, err := estimateRange(&car, model.RangeProfileOverland)
+
+	assert.ErrorIs(t, err, unknownConsumptionError)
+	assert.True(t, model.IsRangeUnknownError(err))
+}
+
+func TestEstimateRange_unknownCapacity(t *testing.T) {
+	// cars added before the fuel capacity was checked may contradict their fuel
+	car := carWithRange(carTypes.ELECTRIC, "54.0L", 50)
+
+	_, err := estimateRange(&car, model.RangeProfileCombined)
+
+	assert.ErrorIs(t, err, unknownCapacityError)
+}
@@ -0,0 +1,42 @@
+package operations
+
+import (
+	"DCar/logic/geo"
+	"DCar/logic/model"
+	carTypes "github.com/ccsapp/cargotypes"
+)
+
+// deriveTrips derives the trips from a position history ordered by time. A trip starts with the first record
+// with a running engine and ends with the next record with a stopped engine. A trip that has not ended yet is
+// not returned.
+func deriveTrips(records []model.PositionRecord) []model.Trip {
+	trips := make([]model.Trip, 0)
+
+	var trip *model.Trip
+	var lastPosition carTypes.DynamicDataPosition
+
+	for _, record := range records {
+		if trip == nil && record.EngineState == carTypes.ON {
+			trip = &model.Trip{Start: tripEndpoint(&record)}
+		} else if trip != nil {
+			trip.Distance += geo.Distance(lastPosition, record.Position)
+
+			if record.EngineState == carTypes.OFF {
+				trip.End = tripEndpoint(&record)
+				trip.Duration = trip.End.Timestamp.Sub(trip.Start.Timestamp).Seconds()
+				trips = append(trips, *trip)
+				trip = nil
+			}
+		}
+		lastPosition = record.Position
+	}
+
+	return trips
+}
+
+func tripEndpoint(record *model.PositionRecord) model.TripEndpoint {
+	return model.TripEndpoint{
+		Timestamp: record.Timestamp,
+		Position:  record.Position,
+	}
+}
@@ -0,0 +1,65 @@
+package operations
+
+import (
+	"DCar/logic/model"
+	"testing"
+	"time"
+
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	tripStart  = carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+	tripMiddle = carTypes.DynamicDataPosition{Latitude: 49.0169, Longitude: 8.4037}
+	tripEnd    = carTypes.DynamicDataPosition{Latitude: 49.0269, Longitude: 8.4037}
+)
+
+func TestDeriveTrips_noRecords(t *testing.T) {
+	assert.Equal(t, []model.Trip{}, deriveTrips(nil))
+}
+
+func TestDeriveTrips_completeTrip(t *testing.T) {
+	records := []model.PositionRecord{
+		{Timestamp: exampleTime, Position: tripStart, EngineState: carTypes.ON},
+		{Timestamp: exampleTime.Add(5 * time.Minute), Position: tripMiddle, EngineState: carTypes.ON},
+		{Timestamp: exampleTime.Add(10 * time.Minute), Position: tripEnd, EngineState: carTypes.OFF},
+	}
+
+	trips := deriveTrips(records)
+
+	assert.Len(t, trips, 1)
+	assert.Equal(t, model.TripEndpoint{Timestamp: exampleTime, Position: tripStart}, trips[0].Start)
+	assert.Equal(t, model.TripEndpoint{Timestamp: exampleTime.Add(10 * time.Minute), Position: tripEnd}, trips[0].End)
+	assert.Equal(t, 600.0, trips[0].Duration)
+	// two legs of 0.01 degrees latitude are roughly 2.2 km
+	assert.InDelta(t, 2224, trips[0].Distance, 5)
+}
+
+func TestDeriveTrips_unfinishedTrip(t *testing.T) {
+	records := []model.PositionRecord{
+		{Timestamp: exampleTime, Position: tripStart, EngineState: carTypes.ON},
+		{Timestamp: exampleTime.Add(5 * time.Minute), Position: tripMiddle, EngineState: carTypes.OFF},
+		{Timestamp: exampleTime.Add(10 * time.Minute), Position: tripMiddle, EngineState: carTypes.ON},
+		{Timestamp: exampleTime.Add(15 * time.Minute), Position: tripEnd, EngineState: carTypes.ON},
+	}
+
+	trips := deriveTrips(records)
+
+	assert.Len(t, trips, 1)
+	assert.Equal(t, tripMiddle, trips[0].End.Position)
+}
+
+func TestDeriveTrips_leadingStoppedRecords(t *testing.T) {
+	records := []model.PositionRecord{
+		{Timestamp: exampleTime, Position: tripStart, EngineState: carTypes.OFF},
+		{Timestamp: exampleTime.Add(5 * time.Minute), Position: tripMiddle, EngineState: carTypes.ON},
+		{Timestamp: exampleTime.Add(10 * time.Minute), Position: tripEnd, EngineState: carTypes.OFF},
+	}
+
+	trips := deriveTrips(records)
+
+	assert.Len(t, trips, 1)
+	assert.Equal(t, tripMiddle, trips[0].Start.Position)
+	assert.InDelta(t, 1112, trips[0].Distance, 5)
+}
@@ -0,0 +1,214 @@
+// Package simulator provides a background simulation of a moving fleet. Every car whose engine is running drives
+// along randomly generated routes and consumes fuel according to its technical specification.
+package simulator
+
+import (
+	"DCar/infrastructure/database"
+	"DCar/logic/geo"
+	"DCar/logic/model"
+	"context"
+	carTypes "github.com/ccsapp/cargotypes"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// maxWaypointDistance is the maximum distance in meters between two waypoints of a generated route.
+const maxWaypointDistance = 5000
+
+type Config interface {
+	GetSimulatorInterval() time.Duration
+	GetSimulatorSpeed() int
+}
+
+// carState is the state the simulator keeps in memory for every car it moves.
+type carState struct {
+	// waypoint is the position the car is currently driving to
+	waypoint carTypes.DynamicDataPosition
+
+	// fuelLevelPercentage is the precise fuel level, the database only stores the rounded value
+	fuelLevelPercentage float64
+}
+
+// Simulator moves all cars whose engine is running. Use NewSimulator to create an instance.
+type Simulator struct {
+	crud     database.ICRUD
+	interval time.Duration
+	speed    float64
+	random   *rand.Rand
+	cars     map[carTypes.Vin]*carState
+
+	// now returns the current time, it can be replaced for testing
+	now func() time.Time
+}
+
+// NewSimulator creates a new simulator that reads and writes the cars using the given high level CRUD interface.
+// The interval between two simulation steps and the speed of the cars in km/h are read from the config.
+func NewSimulator(crud database.ICRUD, config Config) *Simulator {
+	return &Simulator{
+		crud:     crud,
+		interval: config.GetSimulatorInterval(),
+		speed:    float64(config.GetSimulatorSpeed()),
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		cars:     make(map[carTypes.Vin]*carState),
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run executes a simulation step in every interval until the context is cancelled. Errors are logged, but do
+// not stop the simulation.
+func (s *Simulator) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.step(ctx); err != nil {
+				log.Printf("simulator: %s", err.Error())
+			}
+		}
+	}
+}
+
+// step moves every car whose engine is running by the distance it travels in one interval.
+func (s *Simulator) step(ctx context.Context) error {
+	cars, err := s.crud.ReadCarsWithRunningEngine(ctx)
+	if err != nil {
+		return err
+	}
+
+	// forget the state of cars that have stopped, they start a new route when they are started again
+	running := make(map[carTypes.Vin]*carState, len(cars))
+	for i := range cars {
+		vin := cars[i].Car.Vin
+		if state := s.stepCar(ctx, &cars[i].Car, cars[i].Revision, s.cars[vin]); state != nil {
+			running[vin] = state
+		}
+	}
+	s.cars = running
+
+	return nil
+}
+
+// stepCar moves a single car that was read with the given revision and returns its new state. Errors are logged
+// since they only affect this car. If the car was changed since it was read, nothing is written and nil is returned,
+// so the car is read again in the next step.
+func (s *Simulator) stepCar(ctx context.Context, car *carTypes.Car, revision int64, state *carState) *carState {
+	position := car.DynamicData.Position
+
+	// the fuel level may have been changed by someone else (e.g. when the car was refueled)
+	if state == nil || int(math.Ceil(state.fuelLevelPercentage)) != car.DynamicData.FuelLevelPercentage {
+		state = &carState{
+			waypoint:            s.nextWaypoint(position),
+			fuelLevelPercentage: float64(car.DynamicData.FuelLevelPercentage),
+		}
+	}
+
+	distance := s.speed / 3.6 * s.interval.Seconds()
+	if fuelRange := rangeOf(car, state.fuelLevelPercentage); fuelRange < distance {
+		distance = fuelRange
+	}
+
+	// follow the route, a new waypoint is generated whenever the current one is reached
+	for remaining := distance; remaining > 0; {
+		toWaypoint := geo.Distance(position, state.waypoint)
+		position = geo.MoveTowards(position, state.waypoint, remaining)
+		if toWaypoint > remaining {
+			break
+		}
+		remaining -= toWaypoint
+		state.waypoint = s.nextWaypoint(position)
+	}
+
+	state.fuelLevelPercentage = math.Max(0, state.fuelLevelPercentage-consumedPercentage(car, distance))
+	fuelLevelPercentage := int(math.Ceil(state.fuelLevelPercentage))
+
+	err := s.updateCar(ctx, car.Vin, revision, position, fuelLevelPercentage)
+	if database.IsRevisionMismatchError(err) {
+		// e.g. the engine was stopped through the API, the step must not continue a trip that has ended
+		return nil
+	}
+	if err != nil && !database.IsNotFoundError(err) {
+		log.Printf("simulator: car %s: %s", car.Vin, err.Error())
+	}
+
+	return state
+}
+
+// updateCar stores the new position and fuel level of a car and records the position in the history. If the tank is
+// empty, the engine is stopped. The car is only changed if it still has the given revision.
+func (s *Simulator) updateCar(ctx context.Context, vin carTypes.Vin, revision int64,
+	position carTypes.DynamicDataPosition, fuelLevelPercentage int) error {
+
+	if err := s.crud.SetPositionAndFuelLevel(ctx, vin, position, fuelLevelPercentage, &revision); err != nil {
+		return err
+	}
+
+	engineState := carTypes.ON
+	if fuelLevelPercentage == 0 {
+		engineState = carTypes.OFF
+
+		// the previous write increased the revision by one
+		revision++
+		if err := s.crud.SetEngineState(ctx, vin, engineState, &revision); err != nil {
+			return err
+		}
+	}
+
+	return s.crud.AddPositionRecord(ctx, vin, &model.PositionRecord{
+		Timestamp:   s.now(),
+		Position:    position,
+		EngineState: engineState,
+	})
+}
+
+// nextWaypoint generates a random waypoint in the area around the given position.
+func (s *Simulator) nextWaypoint(position carTypes.DynamicDataPosition) carTypes.DynamicDataPosition {
+	return geo.Destination(position, s.random.Float64()*360, s.random.Float64()*maxWaypointDistance)
+}
+
+// capacity returns the capacity of the energy source the car consumes in liters or kWh. The consumption of the
+// technical specification is given in the same unit per 100 km. If the capacity is unknown, 0 is returned.
+func capacity(car *carTypes.Car) float64 {
+	fuelCapacity, err := model.ParseFuelCapacity(car.TechnicalSpecification.FuelCapacity)
+	if err != nil {
+		return 0
+	}
+
+	// electric cars use their battery, all other cars (including hybrids) use their tank if they have one
+	preferred, fallback := fuelCapacity.Liters, fuelCapacity.KiloWattHours
+	if car.TechnicalSpecification.Fuel == carTypes.ELECTRIC {
+		preferred, fallback = fallback, preferred
+	}
+	if preferred != nil {
+		return *preferred
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return 0
+}
+
+// consumedPercentage returns the percentage of the capacity that the car consumes on the given distance in meters.
+// If the consumption or the capacity of the car is unknown, the car does not consume any fuel.
+func consumedPercentage(car *carTypes.Car, distance float64) float64 {
+	carCapacity := capacity(car)
+	consumption := float64(car.TechnicalSpecification.Consumption.Combined)
+	if carCapacity <= 0 || consumption <= 0 {
+		return 0
+	}
+	return distance / 100_000 * consumption / carCapacity * 100
+}
+
+// rangeOf returns the distance in meters the car can travel with the given fuel level.
+func rangeOf(car *carTypes.Car, fuelLevelPercentage float64) float64 {
+	perMeter := consumedPercentage(car, 1)
+	if perMeter == 0 {
+		return math.Inf(1)
+	}
+	return fuelLevelPercentage / perMeter
+}
@@ -0,0 +1,269 @@
+package simulator
+
+import (
+	"DCar/infrastructure/database"
+	"DCar/logic/geo"
+	"DCar/logic/model"
+	"DCar/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct{}
+
+func (c *testConfig) GetSimulatorInterval() time.Duration {
+	return time.Minute
+}
+
+func (c *testConfig) GetSimulatorSpeed() int {
+	// 60 km/h in an interval of one minute result in a distance of 1 km per step
+	return 60
+}
+
+var startPosition = carTypes.DynamicDataPosition{Latitude: 49.0069, Longitude: 8.4037}
+
+// readRevision is the revision of the cars read by the simulator, stoppedRevision the one after the first write
+var (
+	readRevision    = int64(7)
+	stoppedRevision = int64(8)
+)
+
+func withRevision(cars ...carTypes.Car) []model.CarWithRevision {
+	result := make([]model.CarWithRevision, len(cars))
+	for i := range cars {
+		result[i] = model.CarWithRevision{Car: cars[i], Revision: readRevision}
+	}
+	return result
+}
+
+func runningCar(fuel carTypes.TechnicalSpecificationFuel, fuelCapacity string, consumption float32,
+	fuelLevelPercentage int) carTypes.Car {
+
+	return carTypes.Car{
+		Vin: "12345678901234567",
+		DynamicData: carTypes.DynamicData{
+			EngineState:         carTypes.ON,
+			FuelLevelPercentage: fuelLevelPercentage,
+			Position:            startPosition,
+		},
+		TechnicalSpecification: carTypes.TechnicalSpecification{
+			Fuel:         fuel,
+			FuelCapacity: fuelCapacity,
+			Consumption: carTypes.TechnicalSpecificationConsumption{
+				Combined: consumption,
+			},
+		},
+	}
+}
+
+func TestSimulator_step_moveCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// 5 l/100km with a 50 l tank: 1 km consumes 0.1 %
+	car := runningCar(carTypes.PETROL, "50.0L", 5, 100)
+
+	var newPosition carTypes.DynamicDataPosition
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 100, &readRevision).
+		DoAndReturn(func(_ context.Context, _ carTypes.Vin, position carTypes.DynamicDataPosition, _ int,
+			_ *int64) error {
+
+			newPosition = position
+			return nil
+		})
+	mockCrud.EXPECT().AddPositionRecord(ctx, car.Vin, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ carTypes.Vin, record *model.PositionRecord) error {
+			assert.Equal(t, newPosition, record.Position)
+			assert.Equal(t, carTypes.ON, record.EngineState)
+			return nil
+		})
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	err := simulator.step(ctx)
+
+	assert.Nil(t, err)
+	// the car may have changed its direction at a waypoint, so it is at most 1 km away from the start
+	assert.LessOrEqual(t, geo.Distance(startPosition, newPosition), 1001.0)
+	assert.Greater(t, geo.Distance(startPosition, newPosition), 0.0)
+	assert.InDelta(t, 99.9, simulator.cars[car.Vin].fuelLevelPercentage, 0.001)
+}
+
+func TestSimulator_step_continueRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := runningCar(carTypes.PETROL, "50.0L", 5, 100)
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil).Times(2)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 100, &readRevision).Return(nil).Times(2)
+	mockCrud.EXPECT().AddPositionRecord(ctx, car.Vin, gomock.Any()).Return(nil).Times(2)
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+	assert.Nil(t, simulator.step(ctx))
+
+	// the precise fuel level is kept between the steps
+	assert.InDelta(t, 99.8, simulator.cars[car.Vin].fuelLevelPercentage, 0.001)
+}
+
+func TestSimulator_step_electricCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// 20 kWh/100km with a 10 kWh battery: 1 km consumes 2 %, the tank in liters is ignored
+	car := runningCar(carTypes.ELECTRIC, "50.0L;10.0kWh", 20, 50)
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 48, &readRevision).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, car.Vin, gomock.Any()).Return(nil)
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+}
+
+func TestSimulator_step_emptyTank(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// 100 l/100km with a 1 l tank: 1 % of the tank is sufficient for 10 m
+	car := runningCar(carTypes.DIESEL, "1.0L", 100, 1)
+
+	var newPosition carTypes.DynamicDataPosition
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 0, &readRevision).
+		DoAndReturn(func(_ context.Context, _ carTypes.Vin, position carTypes.DynamicDataPosition, _ int,
+			_ *int64) error {
+
+			newPosition = position
+			return nil
+		})
+	mockCrud.EXPECT().SetEngineState(ctx, car.Vin, carTypes.OFF, &stoppedRevision).Return(nil)
+	// the end of the trip is recorded with the stopped engine
+	mockCrud.EXPECT().AddPositionRecord(ctx, car.Vin, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ carTypes.Vin, record *model.PositionRecord) error {
+			assert.Equal(t, carTypes.OFF, record.EngineState)
+			return nil
+		})
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+
+	assert.InDelta(t, 10, geo.Distance(startPosition, newPosition), 0.5)
+}
+
+func TestSimulator_step_changedCar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := runningCar(carTypes.PETROL, "50.0L", 5, 100)
+
+	// the engine was stopped through the API after the car was read, so the trip must not be continued
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 100, &readRevision).
+		Return(&database.RevisionMismatchError{Vin: car.Vin})
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+	assert.NotContains(t, simulator.cars, car.Vin)
+}
+
+func TestSimulator_step_changedCarWhileStopping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := runningCar(carTypes.DIESEL, "1.0L", 100, 1)
+
+	// the car was changed between both writes, so the end of the trip is not recorded
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 0, &readRevision).Return(nil)
+	mockCrud.EXPECT().SetEngineState(ctx, car.Vin, carTypes.OFF, &stoppedRevision).
+		Return(&database.RevisionMismatchError{Vin: car.Vin})
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+	assert.NotContains(t, simulator.cars, car.Vin)
+}
+
+func TestSimulator_step_unknownCapacity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	// cars with an invalid capacity keep driving without consuming fuel
+	car := runningCar(carTypes.DIESEL, "invalid", 5, 50)
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 50, &readRevision).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, car.Vin, gomock.Any()).Return(nil)
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+}
+
+func TestSimulator_step_forgetStoppedCars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	car := runningCar(carTypes.PETROL, "50.0L", 5, 100)
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	gomock.InOrder(
+		mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(car), nil),
+		mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(withRevision(), nil),
+	)
+	mockCrud.EXPECT().SetPositionAndFuelLevel(ctx, car.Vin, gomock.Any(), 100, &readRevision).Return(nil)
+	mockCrud.EXPECT().AddPositionRecord(ctx, car.Vin, gomock.Any()).Return(nil)
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.Nil(t, simulator.step(ctx))
+	assert.Contains(t, simulator.cars, car.Vin)
+
+	assert.Nil(t, simulator.step(ctx))
+	assert.NotContains(t, simulator.cars, car.Vin)
+}
+
+func TestSimulator_step_crudError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	crudError := errors.New("crud error")
+
+	mockCrud := mocks.NewMockICRUD(ctrl)
+	mockCrud.EXPECT().ReadCarsWithRunningEngine(ctx).Return(nil, crudError)
+
+	simulator := NewSimulator(mockCrud, &testConfig{})
+	assert.ErrorIs(t, simulator.step(ctx), crudError)
+}
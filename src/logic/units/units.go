@@ -0,0 +1,128 @@
+// Package units converts the values of cars into other unit systems. Cars are stored and processed in metric units,
+// other unit systems are only used to represent cars to clients.
+package units
+
+import (
+	"DCar/logic/model"
+	carTypes "github.com/ccsapp/cargotypes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// System is a unit system.
+type System string
+
+const (
+	// Metric uses kg, kW, liters, l/100 km (kWh/100 km for electric cars) and g CO2/km. It is the unit system cars
+	// are stored in.
+	Metric System = "metric"
+
+	// Imperial uses lb, hp, US gallons, mpg (kWh/100 mi for electric cars) and g CO2/mi.
+	Imperial System = "imperial"
+)
+
+// Units maps the fields of a converted car (see model.CarFields) to the units of their values.
+type Units map[string]string
+
+const (
+	poundsPerKilogram     = 2.20462262185
+	horsepowerPerKiloWatt = 1.34102208959
+	litersPerGallon       = 3.785411784
+	kilometersPerMile     = 1.609344
+
+	// mpgTimesLitersPer100Km is the product of a consumption in mpg and the same consumption in l/100 km
+	mpgTimesLitersPer100Km = 100 * litersPerGallon / kilometersPerMile
+)
+
+const (
+	weightField       = "technicalSpecification.weight"
+	powerField        = "technicalSpecification.engine.power"
+	fuelCapacityField = "technicalSpecification.fuelCapacity"
+	consumptionField  = "technicalSpecification.consumption"
+	emissionsField    = "technicalSpecification.emissions"
+
+	gallonsSuffix         = "gal"
+	fuelCapacitySeparator = ";"
+)
+
+// ConvertCar converts the values of the car into the given unit system and returns the converted car together with
+// the units of the converted fields. In the metric system the car is returned unchanged without units. Values that
+// cannot be converted, like a consumption of 0 in mpg, are kept.
+func ConvertCar(car carTypes.Car, system System) (carTypes.Car, Units) {
+	if system != Imperial {
+		return car, nil
+	}
+
+	specification := &car.TechnicalSpecification
+	units := Units{
+		weightField:    "lb",
+		powerField:     "hp",
+		emissionsField: "g/mi",
+	}
+
+	specification.Weight = int(math.Round(float64(specification.Weight) * poundsPerKilogram))
+	specification.Engine.Power = int(math.Round(float64(specification.Engine.Power) * horsepowerPerKiloWatt))
+
+	if fuelCapacity, ok := convertFuelCapacity(specification.FuelCapacity); ok {
+		specification.FuelCapacity = fuelCapacity
+		units[fuelCapacityField] = gallonsSuffix
+	}
+
+	// the consumption of electric cars is given in kWh, all other cars (including hybrids) consume liters
+	if specification.Fuel == carTypes.ELECTRIC {
+		convertConsumption(&specification.Consumption, perMile)
+		units[consumptionField] = "kWh/100mi"
+	} else {
+		convertConsumption(&specification.Consumption, milesPerGallon)
+		units[consumptionField] = "mpg"
+	}
+
+	specification.Emissions = carTypes.TechnicalSpecificationEmissions{
+		City:     perMile(specification.Emissions.City),
+		Combined: perMile(specification.Emissions.Combined),
+		Overland: perMile(specification.Emissions.Overland),
+	}
+
+	return car, units
+}
+
+// convertFuelCapacity converts the capacity in liters of a fuel capacity into US gallons, the capacity in kWh is
+// kept. If the fuel capacity has no capacity in liters or is invalid, false is returned.
+func convertFuelCapacity(fuelCapacity string) (string, bool) {
+	capacity, err := model.ParseFuelCapacity(fuelCapacity)
+	if err != nil || capacity.Liters == nil {
+		return "", false
+	}
+
+	gallons := strconv.FormatFloat(*capacity.Liters/litersPerGallon, 'f', 1, 64) + gallonsSuffix
+	// the capacity in liters is always the first part
+	parts := strings.SplitN(fuelCapacity, fuelCapacitySeparator, 2)
+	parts[0] = gallons
+	return strings.Join(parts, fuelCapacitySeparator), true
+}
+
+// convertConsumption converts all values of the consumption with the given conversion.
+func convertConsumption(consumption *carTypes.TechnicalSpecificationConsumption, convert func(float32) float32) {
+	consumption.City = convert(consumption.City)
+	consumption.Combined = convert(consumption.Combined)
+	consumption.Overland = convert(consumption.Overland)
+}
+
+// perMile converts a value per km (or per 100 km) into a value per mile (or per 100 miles).
+func perMile(value float32) float32 {
+	return round(float64(value) * kilometersPerMile)
+}
+
+// milesPerGallon converts a consumption in l/100 km into mpg. A consumption of 0 is kept.
+func milesPerGallon(litersPer100Km float32) float32 {
+	if litersPer100Km == 0 {
+		return 0
+	}
+	return round(mpgTimesLitersPer100Km / float64(litersPer100Km))
+}
+
+// round rounds a converted value to one decimal place.
+func round(value float64) float32 {
+	return float32(math.Round(value*10) / 10)
+}
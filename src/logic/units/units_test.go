@@ -0,0 +1,98 @@
+package units
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func carWithFuel(fuel carTypes.TechnicalSpecificationFuel, fuelCapacity string) carTypes.Car {
+	return carTypes.Car{
+		Vin: "WVWAA71K08W201030",
+		TechnicalSpecification: carTypes.TechnicalSpecification{
+			Consumption:  carTypes.TechnicalSpecificationConsumption{City: 6.4, Combined: 5.2, Overland: 4.6},
+			Emissions:    carTypes.TechnicalSpecificationEmissions{City: 120, Combined: 100, Overland: 90},
+			Engine:       carTypes.TechnicalSpecificationEngine{Power: 110, Type: "1.6 TDI"},
+			Fuel:         fuel,
+			FuelCapacity: fuelCapacity,
+			TrunkVolume:  435,
+			Weight:       1320,
+		},
+	}
+}
+
+func TestConvertCar_metric(t *testing.T) {
+	car := carWithFuel(carTypes.DIESEL, "54.0L")
+
+	converted, units := ConvertCar(car, Metric)
+
+	assert.Equal(t, car, converted)
+	assert.Nil(t, units)
+}
+
+func TestConvertCar_imperial(t *testing.T) {
+	car := carWithFuel(carTypes.HYBRIDDIESEL, "54.0L;85.2kWh")
+
+	converted, units := ConvertCar(car, Imperial)
+
+	expected := carWithFuel(carTypes.HYBRIDDIESEL, "14.3gal;85.2kWh")
+	expected.TechnicalSpecification.Weight = 2910
+	expected.TechnicalSpecification.Engine.Power = 148
+	expected.TechnicalSpecification.Consumption = carTypes.TechnicalSpecificationConsumption{
+		City: 36.8, Combined: 45.2, Overland: 51.1}
+	expected.TechnicalSpecification.Emissions = carTypes.TechnicalSpecificationEmissions{
+		City: 193.1, Combined: 160.9, Overland: 144.8}
+
+	assert.Equal(t, expected, converted)
+	assert.Equal(t, Units{
+		"technicalSpecification.weight":       "lb",
+		"technicalSpecification.engine.power": "hp",
+		"technicalSpecification.fuelCapacity": "gal",
+		"technicalSpecification.consumption":  "mpg",
+		"technicalSpecification.emissions":    "g/mi",
+	}, units)
+	// the original car is not changed
+	assert.Equal(t, "54.0L;85.2kWh", car.TechnicalSpecification.FuelCapacity)
+}
+
+func TestConvertCar_imperialElectric(t *testing.T) {
+	car := carWithFuel(carTypes.ELECTRIC, "85.2kWh")
+	car.TechnicalSpecification.Emissions = carTypes.TechnicalSpecificationEmissions{}
+
+	converted, units := ConvertCar(car, Imperial)
+
+	// the consumption in kWh is converted per 100 miles, the capacity in kWh is kept
+	assert.Equal(t, carTypes.TechnicalSpecificationConsumption{City: 10.3, Combined: 8.4, Overland: 7.4},
+		converted.TechnicalSpecification.Consumption)
+	assert.Equal(t, "85.2kWh", converted.TechnicalSpecification.FuelCapacity)
+	assert.Equal(t, carTypes.TechnicalSpecificationEmissions{}, converted.TechnicalSpecification.Emissions)
+	assert.Equal(t, "kWh/100mi", units["technicalSpecification.consumption"])
+	assert.NotContains(t, units, "technicalSpecification.fuelCapacity")
+}
+
+func TestConvertCar_imperialZeroConsumption(t *testing.T) {
+	car := carWithFuel(carTypes.PETROL, "54.0L")
+	car.TechnicalSpecification.Consumption = carTypes.TechnicalSpecificationConsumption{}
+
+	converted, _ := ConvertCar(car, Imperial)
+
+	assert.Equal(t, carTypes.TechnicalSpecificationConsumption{}, converted.TechnicalSpecification.Consumption)
+}
+
+func TestConvertFuelCapacity(t *testing.T) {
+	for _, test := range []struct {
+		fuelCapacity string
+		expected     string
+		ok           bool
+	}{
+		{"54.0L", "14.3gal", true},
+		{"54.0L;85.0kWh", "14.3gal;85.0kWh", true},
+		{"85.2kWh", "", false},
+		{"invalid", "", false},
+	} {
+		converted, ok := convertFuelCapacity(test.fuelCapacity)
+
+		assert.Equal(t, test.expected, converted, test.fuelCapacity)
+		assert.Equal(t, test.ok, ok, test.fuelCapacity)
+	}
+}
@@ -0,0 +1,112 @@
+// Package validation checks the plausibility of cars. The OpenAPI specification only checks the types of the values
+// of a car, the rules of this package check whether the values make sense for a real car.
+package validation
+
+import (
+	"DCar/logic/model"
+	"errors"
+	carTypes "github.com/ccsapp/cargotypes"
+	"strings"
+)
+
+// Violation is a violated rule. Field is the path of the violating field in the API, e.g.
+// "technicalSpecification.numberOfSeats", and Message describes the rule and can be presented to the client.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is returned if a car violates at least one rule. It contains every violated rule in a stable order.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, " ")
+}
+
+// IsError checks if the error is an Error.
+func IsError(err error) bool {
+	var validationError *Error
+	return errors.As(err, &validationError)
+}
+
+// violations collects the violated rules of a car.
+type violations []Violation
+
+// check adds a violation of the given field if the rule is not satisfied.
+func (v *violations) check(satisfied bool, field string, message string) {
+	if !satisfied {
+		*v = append(*v, Violation{Field: field, Message: message})
+	}
+}
+
+// ValidateCar checks the technical specification of the car. If the car violates any rules, an Error listing all
+// violations is returned.
+func ValidateCar(car *carTypes.Car) error {
+	var result violations
+	specification := &car.TechnicalSpecification
+
+	result.check(specification.NumberOfSeats >= 1, "technicalSpecification.numberOfSeats",
+		"A car needs at least one seat.")
+	result.check(specification.NumberOfDoors >= 0, "technicalSpecification.numberOfDoors",
+		"The number of doors cannot be negative.")
+	result.check(specification.Weight > 0, "technicalSpecification.weight", "The weight must be positive.")
+	result.check(specification.TrunkVolume >= 0, "technicalSpecification.trunkVolume",
+		"The trunk volume cannot be negative.")
+	result.check(specification.Engine.Power > 0, "technicalSpecification.engine.power",
+		"The power of the engine must be positive.")
+
+	if _, err := model.ParseCarFuelCapacity(car); err != nil {
+		result.check(false, "technicalSpecification.fuelCapacity", err.Error())
+	}
+
+	result.checkConsumption(&specification.Consumption)
+	result.checkEmissions(&specification.Emissions, specification.Fuel)
+
+	if len(result) > 0 {
+		return &Error{Violations: result}
+	}
+	return nil
+}
+
+// checkConsumption checks that the consumption is not negative and that the combined consumption lies between the
+// consumption in the city and overland.
+func (v *violations) checkConsumption(consumption *carTypes.TechnicalSpecificationConsumption) {
+	v.check(consumption.City >= 0, "technicalSpecification.consumption.city",
+		"The consumption in the city cannot be negative.")
+	v.check(consumption.Overland >= 0, "technicalSpecification.consumption.overland",
+		"The overland consumption cannot be negative.")
+
+	lower, upper := consumption.City, consumption.Overland
+	if lower > upper {
+		lower, upper = upper, lower
+	}
+	v.check(consumption.Combined >= lower && consumption.Combined <= upper,
+		"technicalSpecification.consumption.combined",
+		"The combined consumption must lie between the consumption in the city and overland.")
+}
+
+// checkEmissions checks that the emissions are not negative, and that an electric car has no emissions at all.
+func (v *violations) checkEmissions(emissions *carTypes.TechnicalSpecificationEmissions,
+	fuel carTypes.TechnicalSpecificationFuel) {
+
+	for _, emission := range []struct {
+		field string
+		value float32
+	}{
+		{"technicalSpecification.emissions.city", emissions.City},
+		{"technicalSpecification.emissions.combined", emissions.Combined},
+		{"technicalSpecification.emissions.overland", emissions.Overland},
+	} {
+		if fuel == carTypes.ELECTRIC {
+			v.check(emission.value == 0, emission.field, "An electric car cannot emit CO2.")
+		} else {
+			v.check(emission.value >= 0, emission.field, "The emissions cannot be negative.")
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	carTypes "github.com/ccsapp/cargotypes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// plausibleCar returns a car that satisfies all rules.
+func plausibleCar() carTypes.Car {
+	return carTypes.Car{
+		Vin: "WVWAA71K08W201030",
+		TechnicalSpecification: carTypes.TechnicalSpecification{
+			Consumption:   carTypes.TechnicalSpecificationConsumption{City: 6.4, Combined: 5.2, Overland: 4.6},
+			Emissions:     carTypes.TechnicalSpecificationEmissions{City: 168, Combined: 137, Overland: 122},
+			Engine:        carTypes.TechnicalSpecificationEngine{Power: 110, Type: "1.6 TDI"},
+			Fuel:          carTypes.DIESEL,
+			FuelCapacity:  "54.0L",
+			NumberOfDoors: 5,
+			NumberOfSeats: 5,
+			TrunkVolume:   435,
+			Weight:        1320,
+		},
+	}
+}
+
+func TestValidateCar(t *testing.T) {
+	car := plausibleCar()
+
+	assert.Nil(t, ValidateCar(&car))
+}
+
+func TestValidateCar_electric(t *testing.T) {
+	car := plausibleCar()
+	car.TechnicalSpecification.Fuel = carTypes.ELECTRIC
+	car.TechnicalSpecification.FuelCapacity = "85.2kWh"
+	car.TechnicalSpecification.Emissions = carTypes.TechnicalSpecificationEmissions{}
+
+	assert.Nil(t, ValidateCar(&car))
+}
+
+func TestValidateCar_combinedEqualsCity(t *testing.T) {
+	car := plausibleCar()
+	car.TechnicalSpecification.Consumption = carTypes.TechnicalSpecificationConsumption{
+		City: 4.6, Combined: 4.6, Overland: 6.4}
+
+	assert.Nil(t, ValidateCar(&car))
+}
+
+func TestValidateCar_violations(t *testing.T) {
+	car := plausibleCar()
+	car.TechnicalSpecification.NumberOfSeats = 0
+	car.TechnicalSpecification.NumberOfDoors = -1
+	car.TechnicalSpecification.Weight = -1320
+	car.TechnicalSpecification.TrunkVolume = -1
+	car.TechnicalSpecification.Engine.Power = 0
+	car.TechnicalSpecification.FuelCapacity = "54.0L;85.2kWh"
+	car.TechnicalSpecification.Consumption = carTypes.TechnicalSpecificationConsumption{
+		City: -6.4, Combined: 7.0, Overland: 4.6}
+	car.TechnicalSpecification.Emissions.Overland = -1
+
+	err := ValidateCar(&car)
+
+	assert.Equal(t, &Error{Violations: []Violation{
+		{"technicalSpecification.numberOfSeats", "A car needs at least one seat."},
+		{"technicalSpecification.numberOfDoors", "The number of doors cannot be negative."},
+		{"technicalSpecification.weight", "The weight must be positive."},
+		{"technicalSpecification.trunkVolume", "The trunk volume cannot be negative."},
+		{"technicalSpecification.engine.power", "The power of the engine must be positive."},
+		{"technicalSpecification.fuelCapacity", "A car with a combustion engine needs a fuel capacity in liters only."},
+		{"technicalSpecification.consumption.city", "The consumption in the city cannot be negative."},
+		{"technicalSpecification.consumption.combined",
+			"The combined consumption must lie between the consumption in the city and overland."},
+		{"technicalSpecification.emissions.overland", "The emissions cannot be negative."},
+	}}, err)
+	assert.True(t, IsError(err))
+}
+
+func TestValidateCar_electricEmissions(t *testing.T) {
+	car := plausibleCar()
+	car.TechnicalSpecification.Fuel = carTypes.ELECTRIC
+	car.TechnicalSpecification.FuelCapacity = "85.2kWh"
+	car.TechnicalSpecification.Emissions = carTypes.TechnicalSpecificationEmissions{City: 0, Combined: 12, Overland: 0}
+
+	err := ValidateCar(&car)
+
+	assert.Equal(t, &Error{Violations: []Violation{
+		{"technicalSpecification.emissions.combined", "An electric car cannot emit CO2."},
+	}}, err)
+}
+
+func TestError_Error(t *testing.T) {
+	err := &Error{Violations: []Violation{
+		{"technicalSpecification.numberOfSeats", "A car needs at least one seat."},
+		{"technicalSpecification.weight", "The weight must be positive."},
+	}}
+
+	assert.Equal(t, "A car needs at least one seat. The weight must be positive.", err.Error())
+}
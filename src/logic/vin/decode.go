@@ -0,0 +1,179 @@
+package vin
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// modelYearCodes are the codes of the model years 1980 to 2009 at position 10. The codes repeat every 30 years.
+const modelYearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+const (
+	firstModelYear    = 1980
+	modelYearCycle    = len(modelYearCodes)
+	modelYearCycles   = 2
+	modelYearPosition = 9
+	plantPosition     = 10
+
+	// typePosition is the index of position 7, which tells the model year cycle of North American VINs
+	typePosition = 6
+)
+
+// maxModelYearDeviation is the maximum number of years the model year may differ from the production year.
+const maxModelYearDeviation = 1
+
+//go:embed wmi.csv
+var wmiTable string
+
+// manufacturer is an entry of the WMI table.
+type manufacturer struct {
+	name string
+
+	// brands are the normalized brand names the manufacturer uses for its cars
+	brands []string
+}
+
+// manufacturers maps the world manufacturer identifiers of the WMI table to their manufacturers.
+var manufacturers = readWmiTable(wmiTable)
+
+// Decoded is the information encoded in a VIN.
+type Decoded struct {
+	// Wmi is the world manufacturer identifier (positions 1-3)
+	Wmi string `json:"wmi"`
+
+	// Manufacturer is the manufacturer the WMI is assigned to, it is nil if the WMI is unknown
+	Manufacturer *string `json:"manufacturer,omitempty"`
+
+	// ModelYearCode is the code of the model year (position 10)
+	ModelYearCode string `json:"modelYearCode"`
+
+	// ModelYears are the model years the code may stand for in ascending order. The code repeats every 30 years,
+	// for North American VINs position 7 tells which cycle applies. The list is empty if the code is invalid.
+	ModelYears []int `json:"modelYears"`
+
+	// PlantCode is the code of the plant the car was assembled in (position 11)
+	PlantCode string `json:"plantCode"`
+}
+
+// InconsistencyError is returned if the information decoded from a VIN contradicts the data of the car.
+type InconsistencyError struct {
+	Vin    string
+	Reason string
+}
+
+func (e *InconsistencyError) Error() string {
+	return fmt.Sprintf("VIN %s contradicts the car: %s", e.Vin, e.Reason)
+}
+
+// IsInconsistencyError checks if the error is an InconsistencyError.
+func IsInconsistencyError(err error) bool {
+	var inconsistencyError *InconsistencyError
+	return errors.As(err, &inconsistencyError)
+}
+
+// Decode returns the information encoded in the VIN.
+func Decode(vin string) Decoded {
+	decoded := Decoded{
+		Wmi:           vin[:3],
+		ModelYearCode: vin[modelYearPosition : modelYearPosition+1],
+		ModelYears:    modelYears(vin),
+		PlantCode:     vin[plantPosition : plantPosition+1],
+	}
+	if entry, ok := manufacturers[decoded.Wmi]; ok {
+		decoded.Manufacturer = &entry.name
+	}
+	return decoded
+}
+
+// CheckConsistency checks that the VIN does not contradict the brand and the production year of its car. The brand
+// must be one of the brands of the manufacturer of the WMI and one of the model years may differ from the production
+// year by at most one year. An unknown WMI or model year does not contradict the car. If the VIN contradicts the car,
+// an InconsistencyError is returned.
+func CheckConsistency(vin string, brand string, productionYear int) error {
+	if entry, ok := manufacturers[vin[:3]]; ok && !contains(entry.brands, normalizeBrand(brand)) {
+		return &InconsistencyError{
+			Vin:    vin,
+			Reason: fmt.Sprintf("the WMI belongs to %s, but the brand is %s", entry.name, brand),
+		}
+	}
+
+	years := modelYears(vin)
+	for _, year := range years {
+		if year >= productionYear-maxModelYearDeviation && year <= productionYear+maxModelYearDeviation {
+			return nil
+		}
+	}
+	if len(years) > 0 {
+		return &InconsistencyError{
+			Vin: vin,
+			Reason: fmt.Sprintf("the model year code %c does not match the production year %d",
+				vin[modelYearPosition], productionYear),
+		}
+	}
+	return nil
+}
+
+// modelYears returns the model years the code at position 10 may stand for.
+func modelYears(vin string) []int {
+	index := strings.IndexByte(modelYearCodes, vin[modelYearPosition])
+	if index < 0 {
+		return []int{}
+	}
+
+	years := make([]int, 0, modelYearCycles)
+	for cycle := 0; cycle < modelYearCycles; cycle++ {
+		years = append(years, firstModelYear+cycle*modelYearCycle+index)
+	}
+
+	// North American VINs use a letter at position 7 since the model year 2010
+	if IsNorthAmerican(vin) {
+		if unicode.IsDigit(rune(vin[typePosition])) {
+			return years[:1]
+		}
+		return years[1:]
+	}
+	return years
+}
+
+// readWmiTable parses the embedded WMI table. The brands of a manufacturer are separated by semicolons.
+func readWmiTable(table string) map[string]manufacturer {
+	records, err := csv.NewReader(strings.NewReader(table)).ReadAll()
+	if err != nil {
+		panic("Invalid WMI table. This is a bug.")
+	}
+
+	result := make(map[string]manufacturer, len(records))
+	// the first record is the header
+	for _, record := range records[1:] {
+		var brands []string
+		for _, brand := range strings.Split(record[2], ";") {
+			brands = append(brands, normalizeBrand(brand))
+		}
+		result[record[0]] = manufacturer{name: record[1], brands: brands}
+	}
+	return result
+}
+
+// normalizeBrand reduces a brand name to its lower case letters and digits, so that e.g. "Mercedes-Benz" and
+// "mercedes benz" are the same brand.
+func normalizeBrand(brand string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, brand)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
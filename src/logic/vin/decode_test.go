@@ -0,0 +1,84 @@
+package vin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	manufacturer := "Volkswagen"
+	assert.Equal(t, Decoded{
+		Wmi:           "WVW",
+		Manufacturer:  &manufacturer,
+		ModelYearCode: "8",
+		ModelYears:    []int{2008, 2038},
+		PlantCode:     "W",
+	}, Decode("WVWAA71K08W201030"))
+}
+
+func TestDecode_northAmerican(t *testing.T) {
+	// position 7 is a digit, so the model year is before 2010
+	decoded := Decode("1HGCM82633A004352")
+	assert.Equal(t, "Honda", *decoded.Manufacturer)
+	assert.Equal(t, []int{2003}, decoded.ModelYears)
+	assert.Equal(t, "A", decoded.PlantCode)
+
+	// position 7 is a letter, so the model year is since 2010
+	assert.Equal(t, []int{2019}, Decode("5YJ3E1EA7KF317000").ModelYears)
+}
+
+func TestDecode_unknown(t *testing.T) {
+	decoded := Decode("XXXAA71K0UW201030")
+
+	assert.Nil(t, decoded.Manufacturer)
+	assert.Equal(t, "U", decoded.ModelYearCode)
+	assert.Empty(t, decoded.ModelYears)
+}
+
+func TestCheckConsistency(t *testing.T) {
+	for _, test := range []struct {
+		vin            string
+		brand          string
+		productionYear int
+	}{
+		{"WVWAA71K08W201030", "Volkswagen", 2008},
+		{"WVWAA71K08W201030", "volkswagen", 2007},
+		{"WVWAA71K08W201030", "Volkswagen", 2037},
+		{"WDD1690071J236589", "Mercedes", 2001},
+		{"WDD1690071J236589", "mercedes benz", 2002},
+		{"1HGCM82633A004352", "Honda", 2002},
+		// unknown WMIs and model years do not contradict the car
+		{"XXXAA71K08W201030", "Audi", 2008},
+		{"WVWAA71K0UW201030", "Volkswagen", 2017},
+	} {
+		assert.Nil(t, CheckConsistency(test.vin, test.brand, test.productionYear), test.vin)
+	}
+}
+
+func TestCheckConsistency_brand(t *testing.T) {
+	err := CheckConsistency("WVWAA71K08W201030", "Audi", 2008)
+
+	assert.True(t, IsInconsistencyError(err))
+	assert.Equal(t, "VIN WVWAA71K08W201030 contradicts the car: the WMI belongs to Volkswagen, but the brand is Audi",
+		err.Error())
+}
+
+func TestCheckConsistency_modelYear(t *testing.T) {
+	for _, productionYear := range []int{2006, 2010, 2017} {
+		err := CheckConsistency("WVWAA71K08W201030", "Volkswagen", productionYear)
+		assert.True(t, IsInconsistencyError(err), productionYear)
+	}
+
+	// the cycle of North American VINs is unambiguous
+	err := CheckConsistency("1HGCM82633A004352", "Honda", 2033)
+	assert.Equal(t, &InconsistencyError{
+		Vin:    "1HGCM82633A004352",
+		Reason: "the model year code 3 does not match the production year 2033",
+	}, err)
+}
+
+func TestReadWmiTable(t *testing.T) {
+	assert.Equal(t, manufacturer{name: "Mercedes-Benz", brands: []string{"mercedesbenz", "mercedes"}},
+		manufacturers["WDD"])
+	assert.Equal(t, []string{"škoda", "skoda"}, manufacturers["TMB"].brands)
+}
@@ -0,0 +1,115 @@
+// Package vin provides checks of vehicle identification numbers (VIN) as defined by ISO 3779. The format of a VIN
+// is already validated by the API specification, so the functions of this package expect 17 valid characters.
+package vin
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CheckMode defines how a VIN with an invalid check digit is handled.
+type CheckMode string
+
+const (
+	// CheckModeStrict rejects a VIN with an invalid check digit.
+	CheckModeStrict CheckMode = "strict"
+
+	// CheckModeWarn accepts a VIN with an invalid check digit, but reports a warning.
+	CheckModeWarn CheckMode = "warn"
+
+	// CheckModeOff does not check the check digit at all.
+	CheckModeOff CheckMode = "off"
+)
+
+// checkDigitPosition is the index of the check digit in a VIN, i.e. position 9.
+const checkDigitPosition = 8
+
+// weights are the weights of the positions of a VIN for the calculation of the check digit (FMVSS 115).
+var weights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// letterValues are the values of the letters A to Z for the calculation of the check digit, '-' marks a letter that
+// is not allowed.
+const letterValues = "12345678-12345-7-923456789"
+
+var invalidCharacterError = errors.New("invalid VIN character")
+
+// ParseCheckMode parses the name of a check mode. If the name is unknown, an error is returned.
+func ParseCheckMode(name string) (CheckMode, error) {
+	switch mode := CheckMode(name); mode {
+	case CheckModeStrict, CheckModeWarn, CheckModeOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown VIN check mode %q, expected strict, warn or off", name)
+}
+
+// CheckDigitError is returned if the check digit of a VIN does not match the other characters.
+type CheckDigitError struct {
+	Vin      string
+	Expected byte
+}
+
+func (e *CheckDigitError) Error() string {
+	return fmt.Sprintf("VIN %s has an invalid check digit, expected %c at position 9", e.Vin, e.Expected)
+}
+
+// IsCheckDigitError checks if the error is a CheckDigitError.
+func IsCheckDigitError(err error) bool {
+	var checkDigitError *CheckDigitError
+	return errors.As(err, &checkDigitError)
+}
+
+// IsNorthAmerican checks if the VIN was assigned in North America (United States, Canada and Mexico), where the
+// check digit is mandatory. Other regions do not necessarily use position 9 as check digit.
+func IsNorthAmerican(vin string) bool {
+	return vin != "" && vin[0] >= '1' && vin[0] <= '5'
+}
+
+// CheckDigit calculates the check digit of the VIN, i.e. the expected character at position 9. The current character
+// at that position does not influence the result.
+func CheckDigit(vin string) (byte, error) {
+	if len(vin) != len(weights) {
+		return 0, fmt.Errorf("VIN must have %d characters", len(weights))
+	}
+
+	sum := 0
+	for i := range weights {
+		value, err := transliterate(vin[i])
+		if err != nil {
+			return 0, err
+		}
+		sum += value * weights[i]
+	}
+
+	if remainder := sum % 11; remainder < 10 {
+		return byte('0' + remainder), nil
+	}
+	return 'X', nil
+}
+
+// Validate checks the check digit of a North American VIN. VINs of other regions are always valid. If the check
+// digit does not match, a CheckDigitError is returned.
+func Validate(vin string) error {
+	if !IsNorthAmerican(vin) {
+		return nil
+	}
+
+	expected, err := CheckDigit(vin)
+	if err != nil {
+		return err
+	}
+	if vin[checkDigitPosition] != expected {
+		return &CheckDigitError{Vin: vin, Expected: expected}
+	}
+	return nil
+}
+
+// transliterate returns the numerical value of a VIN character. The letters I, O and Q are not allowed in a VIN.
+func transliterate(character byte) (int, error) {
+	switch {
+	case character >= '0' && character <= '9':
+		return int(character - '0'), nil
+	case character >= 'A' && character <= 'Z' && letterValues[character-'A'] != '-':
+		return int(letterValues[character-'A'] - '0'), nil
+	}
+	return 0, invalidCharacterError
+}
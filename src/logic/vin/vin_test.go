@@ -0,0 +1,63 @@
+package vin
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseCheckMode(t *testing.T) {
+	for _, mode := range []CheckMode{CheckModeStrict, CheckModeWarn, CheckModeOff} {
+		parsed, err := ParseCheckMode(string(mode))
+		assert.Nil(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+
+	_, err := ParseCheckMode("lenient")
+	assert.NotNil(t, err)
+}
+
+func TestCheckDigit(t *testing.T) {
+	for vin, expected := range map[string]byte{
+		"1M8GDM9AXKP042788": 'X',
+		"1HGCM82633A004352": '3',
+		"11111111111111111": '1',
+		"WVWAA71K08W201030": '0',
+	} {
+		checkDigit, err := CheckDigit(vin)
+		assert.Nil(t, err, vin)
+		assert.Equal(t, expected, checkDigit, vin)
+	}
+}
+
+func TestCheckDigit_invalid(t *testing.T) {
+	for _, vin := range []string{"", "1HGCM82633A00435", "1HGCM82633A0043521", "1HGCM8263IA004352",
+		"1hgcm82633a004352"} {
+
+		_, err := CheckDigit(vin)
+		assert.NotNil(t, err, vin)
+	}
+}
+
+func TestIsNorthAmerican(t *testing.T) {
+	assert.True(t, IsNorthAmerican("1HGCM82633A004352"))
+	assert.True(t, IsNorthAmerican("5YJ3E1EA7KF317000"))
+	assert.False(t, IsNorthAmerican("WVWAA71K08W201030"))
+	assert.False(t, IsNorthAmerican("JHMCM56557C404453"))
+	assert.False(t, IsNorthAmerican(""))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate("1HGCM82633A004352"))
+	assert.Nil(t, Validate("1M8GDM9AXKP042788"))
+
+	// the check digit is only mandatory in North America
+	assert.Nil(t, Validate("WVWAA71K18W201030"))
+}
+
+func TestValidate_invalidCheckDigit(t *testing.T) {
+	err := Validate("1HGCM82643A004352")
+
+	assert.True(t, IsCheckDigitError(err))
+	assert.Equal(t, &CheckDigitError{Vin: "1HGCM82643A004352", Expected: '3'}, err)
+	assert.Equal(t, "VIN 1HGCM82643A004352 has an invalid check digit, expected 3 at position 9", err.Error())
+}
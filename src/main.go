@@ -5,15 +5,20 @@ import (
 	"DCar/environment"
 	"DCar/infrastructure/database"
 	"DCar/infrastructure/database/db"
+	"DCar/logic/events"
+	"DCar/logic/operations"
+	"DCar/logic/simulator"
+	"context"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"log"
 	"net/http"
+	"time"
 )
 
 // newApp allows production as well as testing to create a new Echo instance for the API.
 // Configuration values are read from the environment.
-func newApp(dbConnection db.IConnection) (*echo.Echo, error) {
+func newApp(dbConnection db.IConnection, broker *events.Broker) (*echo.Echo, error) {
 	app := echo.New()
 
 	// add OpenAPI validation to the echo instance
@@ -22,8 +27,14 @@ func newApp(dbConnection db.IConnection) (*echo.Echo, error) {
 		return nil, err
 	}
 
-	// create a high level CRUD interface for the database and attach it to a controller handling the requests
-	err = api.RegisterHandlers(app, api.NewController(database.NewICRUD(dbConnection, environment.GetEnvironment())))
+	// create a high level CRUD interface for the database that publishes all changes to the broker, wrap it into
+	// the business logic layer and attach it to a controller handling the requests
+	crud := events.NewPublishingCRUD(database.NewICRUD(dbConnection, environment.GetEnvironment()), broker)
+	if err := createIndexes(crud); err != nil {
+		return nil, err
+	}
+	controller := api.NewController(operations.NewOperations(crud, broker, environment.GetEnvironment()))
+	err = api.RegisterHandlers(app, controller)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +57,14 @@ func newApp(dbConnection db.IConnection) (*echo.Echo, error) {
 	return app, nil
 }
 
+// createIndexes creates the database indexes the application relies on.
+func createIndexes(crud database.ICRUD) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return crud.CreateIndexes(ctx)
+}
+
 func main() {
 	// create a new database connection
 	dbConnection, err := db.NewDbConnection(environment.GetEnvironment())
@@ -60,11 +79,23 @@ func main() {
 		}
 	}()
 
-	app, err := newApp(dbConnection)
+	// the broker distributes the changes of the API as well as of the simulator to the event streams
+	broker := events.NewBroker()
+
+	app, err := newApp(dbConnection, broker)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
+	// move the cars with a running engine in the background if the simulator is enabled
+	if environment.GetEnvironment().IsSimulatorEnabled() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		crud := events.NewPublishingCRUD(database.NewICRUD(dbConnection, environment.GetEnvironment()), broker)
+		go simulator.NewSimulator(crud, environment.GetEnvironment()).Run(ctx)
+	}
+
 	// start the server on the configured port
 	app.Logger.Fatal(app.Start(fmt.Sprintf(":%d", environment.GetEnvironment().GetAppExposePort())))
 }
@@ -20,6 +20,24 @@ var ExampleCarWithDynamicData string
 //go:embed exampleCarUnlockedTrunk.json
 var ExampleCarUnlockedTrunk string
 
+//go:embed exampleCarUnlockedDoors.json
+var ExampleCarUnlockedDoors string
+
+//go:embed exampleCarEngineOn.json
+var ExampleCarEngineOn string
+
+//go:embed exampleCarWithReportedDynamicData.json
+var ExampleCarWithReportedDynamicData string
+
+//go:embed exampleDynamicData.json
+var ExampleDynamicData string
+
+//go:embed exampleDynamicDataEmptyTank.json
+var ExampleDynamicDataEmptyTank string
+
+//go:embed exampleDynamicDataInvalidFuelLevel.json
+var ExampleDynamicDataInvalidFuelLevel string
+
 const ExampleCarVinString = "WVWAA71K08W201030"
 const ExampleCar2VinString = "WVWAA71K08W201031"
 